@@ -0,0 +1,98 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"synergylabs/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ForgotPassword mails a password reset link. The response does not reveal whether the email is registered.
+func ForgotPassword(c echo.Context) error {
+	var forgotData struct {
+		Email string `json:"email"`
+	}
+	if err := c.Bind(&forgotData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	if forgotData.Email == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Email is required"})
+	}
+
+	accountService.RequestPasswordReset(c.Request().Context(), forgotData.Email)
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "If the email is registered, a password reset link has been sent"})
+}
+
+// ResetPassword sets a new password using a reset token and signs the account
+// out everywhere, since whoever knew the old password may still hold a session
+func ResetPassword(c echo.Context) error {
+	var resetData struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := c.Bind(&resetData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	if resetData.Token == "" || resetData.Password == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Token and password are required"})
+	}
+
+	ctx := c.Request().Context()
+	userID, err := accountService.ResetPassword(ctx, resetData.Token, resetData.Password)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidToken) || errors.Is(err, services.ErrPasswordTooShort) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Could not reset password"})
+	}
+
+	// The new password stands even if sessions cannot be revoked; the token service logs the failure
+	tokenService.RevokeAllSessions(ctx, userID)
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Password reset successfully"})
+}
+
+// VerifyEmail confirms ownership of an email address
+func VerifyEmail(c echo.Context) error {
+	var verifyData struct {
+		Token string `json:"token"`
+	}
+	if err := c.Bind(&verifyData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	if verifyData.Token == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Token is required"})
+	}
+
+	if err := accountService.VerifyEmail(c.Request().Context(), verifyData.Token); err != nil {
+		if errors.Is(err, services.ErrInvalidToken) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Could not verify email"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Email verified successfully"})
+}
+
+// ResendEmailVerification mails a new verification link
+func ResendEmailVerification(c echo.Context) error {
+	var resendData struct {
+		Email string `json:"email"`
+	}
+	if err := c.Bind(&resendData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	if resendData.Email == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Email is required"})
+	}
+
+	accountService.ResendEmailVerification(c.Request().Context(), resendData.Email)
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "If the email belongs to an unverified account, a verification link has been sent"})
+}
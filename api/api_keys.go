@@ -0,0 +1,87 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"synergylabs/models"
+	"synergylabs/services"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// apiKeyErrorResponse maps API key service errors onto HTTP responses
+func apiKeyErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrAPIKeyNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, services.ErrScopeNotHeld):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
+
+// CreateAPIKey issues a personal API key limited to the requested scopes
+func CreateAPIKey(c echo.Context) error {
+	var keyData struct {
+		Name      string              `json:"name"`
+		Scopes    []models.Permission `json:"scopes"`
+		ExpiresAt *time.Time          `json:"expires_at"`
+	}
+	if err := c.Bind(&keyData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	if keyData.Name == "" || len(keyData.Scopes) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Name and at least one scope are required"})
+	}
+	for _, scope := range keyData.Scopes {
+		if !scope.Valid() {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Unknown scope: " + string(scope)})
+		}
+	}
+	if keyData.ExpiresAt != nil && !keyData.ExpiresAt.After(time.Now()) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Expiry must be in the future"})
+	}
+
+	apiKey := models.APIKey{
+		UserID:    c.Get("userId").(uint),
+		Name:      keyData.Name,
+		Scopes:    keyData.Scopes,
+		ExpiresAt: keyData.ExpiresAt,
+	}
+	key, err := apiKeyService.CreateAPIKey(c.Request().Context(), &apiKey)
+	if err != nil {
+		return apiKeyErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"api_key": apiKey,
+		"key":     key,
+	})
+}
+
+// ListAPIKeys lists the caller's API keys without their secrets
+func ListAPIKeys(c echo.Context) error {
+	keys, err := apiKeyService.ListAPIKeys(c.Request().Context(), c.Get("userId").(uint))
+	if err != nil {
+		return apiKeyErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey revokes one of the caller's API keys
+func RevokeAPIKey(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("key_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid API key ID")
+	}
+
+	if err := apiKeyService.RevokeAPIKey(c.Request().Context(), c.Get("userId").(uint), uint(id)); err != nil {
+		return apiKeyErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "API key revoked successfully"})
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"synergylabs/services"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ListAuditEvents lists audit events, filtered by actor_id, action,
+// entity_type, entity_id and an RFC 3339 from/to time range
+func ListAuditEvents(c echo.Context) error {
+	filters := services.AuditFilters{
+		Action:     c.QueryParam("action"),
+		EntityType: c.QueryParam("entity_type"),
+		EntityID:   c.QueryParam("entity_id"),
+	}
+	var err error
+	if filters.Page, filters.PageSize, err = pageParams(c); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if actorID := c.QueryParam("actor_id"); actorID != "" {
+		id, err := strconv.ParseUint(actorID, 10, 64)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid actor ID")
+		}
+		filters.ActorID = uint(id)
+	}
+
+	if filters.From, err = parseTimeParam(c, "from"); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid from time"})
+	}
+	if filters.To, err = parseTimeParam(c, "to"); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid to time"})
+	}
+
+	events, err := auditService.ListEvents(c.Request().Context(), filters)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, events)
+}
+
+// VerifyAuditLog checks the audit log's hash chain for tampering
+func VerifyAuditLog(c echo.Context) error {
+	result, err := auditService.VerifyChain(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// parseTimeParam reads an optional RFC 3339 time from the query string
+func parseTimeParam(c echo.Context, name string) (time.Time, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
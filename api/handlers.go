@@ -1,11 +1,17 @@
 package api
 
 import (
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
+	"synergylabs/config"
 	"synergylabs/models"
 	"synergylabs/services"
 	"synergylabs/services/cache"
+	"synergylabs/services/mail"
+	"synergylabs/services/oidc"
+	"synergylabs/services/scheduler"
 	"synergylabs/util"
 
 	"github.com/labstack/echo/v4"
@@ -17,48 +23,228 @@ var (
 	userService   services.UserService
 	jobService    services.JobService
 	resumeService services.ResumeService
+	tokenService  services.TokenService
+
+	invitationService services.InvitationService
+	accountService    services.AccountService
+	mfaService        services.MFAService
+	rbacService       services.RBACService
+
+	organizationService services.OrganizationService
+	apiKeyService       services.APIKeyService
+	ssoService          services.SSOService
+
+	auditService         services.AuditService
+	impersonationService services.ImpersonationService
+
+	jobTemplateService services.JobTemplateService
+	feedService        services.FeedService
 )
 
 // SetupRoutes initializes the API routes
-func SetupRoutes(e *echo.Echo, db *gorm.DB, redisCache *cache.Cache, logger *zap.Logger) {
+func SetupRoutes(e *echo.Echo, cfg *config.Config, db *gorm.DB, redisCache *cache.Cache, mailer mail.Sender, logger *zap.Logger) {
 	userService = *services.NewUserService(db, redisCache, logger)
+	userService.RequireVerifiedEmail(cfg.RequireEmailVerification)
 	jobService = *services.NewJobService(db, redisCache, logger)
 	resumeService = *services.NewResumeService(db, logger)
+	tokenService = *services.NewTokenService(db, redisCache, logger)
+	rbacService = *services.NewRBACService(db, redisCache, logger)
+	invitationService = *services.NewInvitationService(db, &rbacService, logger)
+	accountService = *services.NewAccountService(db, mailer, logger, cfg.AppBaseURL)
+	mfaService = *services.NewMFAService(db, redisCache, logger, cfg.TOTPIssuer, cfg.MFARequiredForAdmins)
+	organizationService = *services.NewOrganizationService(db, redisCache, &rbacService, logger)
+	apiKeyService = *services.NewAPIKeyService(db, &rbacService, logger)
+	auditService = *services.NewAuditService(db, logger)
+	impersonationService = *services.NewImpersonationService(db, &auditService, logger)
+	jobTemplateService = *services.NewJobTemplateService(db, logger)
+	feedService = *services.NewFeedService(db, redisCache, &jobService, logger, cfg.AppBaseURL, cfg.PublicAPIURL, cfg.FeedTitle)
+
+	// Background tasks, started by StartBackgroundTasks
+	taskScheduler = scheduler.NewScheduler(db, redisCache, logger)
+	if err := registerTasks(taskScheduler); err != nil {
+		logger.Fatal("Failed to register background tasks", zap.Error(err))
+	}
+
+	if cfg.OIDCIssuerURL != "" {
+		provider := oidc.NewProvider(oidc.Config{
+			IssuerURL:    cfg.OIDCIssuerURL,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+		}, nil)
+		ssoService = *services.NewSSOService(db, redisCache, provider, logger)
+	}
+
+	util.SetRevocationChecker(&tokenService)
+	util.SetPermissionResolver(&rbacService)
+	util.SetPlatformAdminChecker(&rbacService)
+	util.SetAPIKeyAuthenticator(&apiKeyService)
+
+	e.Use(auditImpersonation)
 
 	// User routes
 	e.POST("/signup", Signup)
 	e.POST("/login", Login)
+	e.POST("/token/refresh", RefreshToken)
+	e.POST("/logout", Logout, util.AuthMiddleware, util.DenyAPIKeys)
+	e.GET("/.well-known/jwks.json", GetJWKS)
+
+	// Two-factor authentication routes
+	e.POST("/login/mfa", LoginMFA)
+	e.POST("/login/mfa/enroll", LoginMFAEnroll)
+	e.POST("/login/mfa/confirm", LoginMFAConfirm)
+	e.POST("/mfa/enroll", EnrollMFA, util.AuthMiddleware, util.DenyAPIKeys)
+	e.POST("/mfa/confirm", ConfirmMFA, util.AuthMiddleware, util.DenyAPIKeys)
+	e.DELETE("/mfa", DisableMFA, util.AuthMiddleware, util.DenyAPIKeys)
+
+	// Self-service account routes
+	e.GET("/me", GetMe, util.AuthMiddleware)
+	e.PATCH("/me", UpdateMe, util.AuthMiddleware, util.DenyAPIKeys)
+	e.DELETE("/me", CloseAccount, util.AuthMiddleware, util.DenyAPIKeys)
+	e.PATCH("/me/profile", UpdateMyProfile, util.AuthMiddleware, util.DenyAPIKeys)
+	e.POST("/me/password", ChangePassword, util.AuthMiddleware, util.DenyAPIKeys)
+
+	// Session routes
+	e.GET("/me/sessions", ListSessions, util.AuthMiddleware, util.DenyAPIKeys)
+	e.DELETE("/me/sessions", RevokeAllSessions, util.AuthMiddleware, util.DenyAPIKeys)
+	e.DELETE("/me/sessions/:session_id", RevokeSession, util.AuthMiddleware, util.DenyAPIKeys)
+
+	// API key routes
+	e.POST("/me/api-keys", CreateAPIKey, util.AuthMiddleware, util.DenyAPIKeys)
+	e.GET("/me/api-keys", ListAPIKeys, util.AuthMiddleware, util.DenyAPIKeys)
+	e.DELETE("/me/api-keys/:key_id", RevokeAPIKey, util.AuthMiddleware, util.DenyAPIKeys)
+
+	// Single sign-on routes, only when an OpenID Connect provider is configured
+	if cfg.OIDCIssuerURL != "" {
+		e.GET("/sso/oidc/login", SSOLogin)
+		e.GET("/sso/oidc/callback", SSOCallback)
+	}
+
+	// Account recovery routes
+	e.POST("/password/forgot", ForgotPassword)
+	e.POST("/password/reset", ResetPassword)
+	e.POST("/email/verify", VerifyEmail)
+	e.POST("/email/verify/resend", ResendEmailVerification)
+
+	// Invitation routes
+	e.POST("/invites/accept", AcceptInvitation)
+	e.POST("/admin/invites", CreateInvitation, util.AuthMiddleware, util.RequirePermission(models.PermUserInvite))
+	e.GET("/admin/invites", ListInvitations, util.AuthMiddleware, util.RequirePermission(models.PermUserInvite))
+	e.DELETE("/admin/invites/:invite_id", RevokeInvitation, util.AuthMiddleware, util.RequirePermission(models.PermUserInvite))
+
+	// Role routes
+	e.GET("/admin/permissions", ListPermissions, util.AuthMiddleware, util.RequirePermission(models.PermRoleManage))
+	e.GET("/admin/roles", ListRoles, util.AuthMiddleware, util.RequirePermission(models.PermRoleManage))
+	e.POST("/admin/roles", CreateRole, util.AuthMiddleware, util.RequirePermission(models.PermRoleManage))
+	e.PUT("/admin/roles/:role_id", UpdateRole, util.AuthMiddleware, util.RequirePermission(models.PermRoleManage))
+	e.DELETE("/admin/roles/:role_id", DeleteRole, util.AuthMiddleware, util.RequirePermission(models.PermRoleManage))
+	e.GET("/admin/users/:user_id/roles", GetUserRoles, util.AuthMiddleware, util.RequirePermission(models.PermRoleManage))
+	e.POST("/admin/users/:user_id/roles", AssignRole, util.AuthMiddleware, util.RequirePermission(models.PermRoleManage))
+	e.DELETE("/admin/users/:user_id/roles/:role_id", RemoveRole, util.AuthMiddleware, util.RequirePermission(models.PermRoleManage))
+
+	// Organization routes
+	e.POST("/admin/organizations", CreateOrganization, util.AuthMiddleware, util.RequirePermission(models.PermOrgCreate))
+	e.GET("/admin/organizations", ListOrganizations, util.AuthMiddleware, util.RequirePermission(models.PermOrgCreate))
+	e.GET("/admin/organizations/:org_id", GetOrganization, util.AuthMiddleware, util.RequirePermission(models.PermOrgManage))
+	e.PUT("/admin/organizations/:org_id/settings", UpdateOrganizationSettings, util.AuthMiddleware, util.RequirePermission(models.PermOrgManage))
+	e.GET("/admin/organizations/:org_id/members", ListOrganizationMembers, util.AuthMiddleware, util.RequirePermission(models.PermOrgManage))
+	e.POST("/admin/organizations/:org_id/members", AddOrganizationMember, util.AuthMiddleware, util.RequirePermission(models.PermOrgManage))
+	e.DELETE("/admin/organizations/:org_id/members/:user_id", RemoveOrganizationMember, util.AuthMiddleware, util.RequirePermission(models.PermOrgManage))
+
+	// Audit routes
+	e.GET("/admin/audit", ListAuditEvents, util.AuthMiddleware, util.RequirePermission(models.PermAuditRead))
+	e.GET("/admin/audit/verify", VerifyAuditLog, util.AuthMiddleware, util.RequirePermission(models.PermAuditRead))
+
+	// Background task routes
+	e.GET("/admin/tasks", ListTasks, util.AuthMiddleware, util.RequirePermission(models.PermTaskManage))
+	e.GET("/admin/tasks/:name/runs", ListTaskRuns, util.AuthMiddleware, util.RequirePermission(models.PermTaskManage))
+	e.POST("/admin/tasks/:name/run", RunTask, util.AuthMiddleware, util.RequirePermission(models.PermTaskManage))
 
 	// Resume routes
-	e.POST("/uploadResume", UploadResume, util.AuthMiddleware, util.ApplicantOnly)
+	e.POST("/uploadResume", UploadResume, util.AuthMiddleware, util.RequirePermission(models.PermResumeUpload))
 
 	// Job routes
-	e.POST("/admin/job", CreateJob, util.AuthMiddleware, util.AdminOnly)
-	e.GET("/admin/job/:job_id", GetJobWithApplicants, util.AuthMiddleware, util.AdminOnly)
-	e.GET("/admin/applicants", GetAllApplicants, util.AuthMiddleware, util.AdminOnly)
-	e.GET("/admin/applicant/:applicant_id", GetApplicantData, util.AuthMiddleware, util.AdminOnly)
+	e.POST("/admin/job", CreateJob, util.AuthMiddleware, util.RequirePermission(models.PermJobCreate))
+	e.GET("/admin/job/:job_id", GetJobWithApplicants, util.AuthMiddleware, util.RequireAnyPermission(models.PermJobRead, models.PermJobReadOwn))
+	e.PUT("/admin/job/:job_id", ReplaceJob, util.AuthMiddleware, util.RequireAnyPermission(models.PermJobUpdate, models.PermJobUpdateOwn))
+	e.PATCH("/admin/job/:job_id", PatchJob, util.AuthMiddleware, util.RequireAnyPermission(models.PermJobUpdate, models.PermJobUpdateOwn))
+	e.POST("/admin/job/:job_id/status", ChangeJobStatus, util.AuthMiddleware, util.RequireAnyPermission(models.PermJobUpdate, models.PermJobUpdateOwn))
+	e.GET("/admin/job/:job_id/transitions", ListJobTransitions, util.AuthMiddleware, util.RequireAnyPermission(models.PermJobRead, models.PermJobReadOwn))
+	e.DELETE("/admin/job/:job_id", DeleteJob, util.AuthMiddleware, util.RequireAnyPermission(models.PermJobDelete, models.PermJobDeleteOwn))
+	e.POST("/admin/jobs/import", ImportJobs, util.AuthMiddleware, util.RequirePermission(models.PermJobCreate, models.PermJobUpdate))
+	e.GET("/admin/jobs/export", ExportJobs, util.AuthMiddleware, util.RequirePermission(models.PermJobRead))
+	e.POST("/admin/job/:job_id/clone", CloneJob, util.AuthMiddleware, util.RequirePermission(models.PermJobCreate))
+	e.GET("/admin/job/:job_id/questions", ListScreeningQuestions, util.AuthMiddleware, util.RequireAnyPermission(models.PermJobRead, models.PermJobReadOwn))
+	e.PUT("/admin/job/:job_id/questions", ReplaceScreeningQuestions, util.AuthMiddleware, util.RequireAnyPermission(models.PermJobUpdate, models.PermJobUpdateOwn))
+	e.GET("/admin/job/:job_id/applications", ListJobApplications, util.AuthMiddleware, util.RequireAnyPermission(models.PermJobRead, models.PermJobReadOwn))
+
+	// Job template routes
+	e.GET("/admin/job-templates", ListJobTemplates, util.AuthMiddleware, util.RequirePermission(models.PermJobCreate))
+	e.POST("/admin/job-templates", CreateJobTemplate, util.AuthMiddleware, util.RequirePermission(models.PermJobCreate))
+	e.GET("/admin/job-templates/:template_id", GetJobTemplate, util.AuthMiddleware, util.RequirePermission(models.PermJobCreate))
+	e.PUT("/admin/job-templates/:template_id", UpdateJobTemplate, util.AuthMiddleware, util.RequirePermission(models.PermJobCreate, models.PermJobUpdate))
+	e.DELETE("/admin/job-templates/:template_id", DeleteJobTemplate, util.AuthMiddleware, util.RequirePermission(models.PermJobCreate, models.PermJobUpdate))
+	e.POST("/admin/job-templates/:template_id/jobs", CreateJobFromTemplate, util.AuthMiddleware, util.RequirePermission(models.PermJobCreate))
+
+	e.GET("/admin/applicants", GetAllApplicants, util.AuthMiddleware, util.RequirePermission(models.PermApplicantRead))
+	e.GET("/admin/applicant/:applicant_id", GetApplicantData, util.AuthMiddleware, util.RequirePermission(models.PermApplicantRead))
+	e.POST("/admin/users/:user_id/unlock", UnlockAccount, util.AuthMiddleware, util.RequirePermission(models.PermUserUnlock))
+	e.POST("/admin/users/:user_id/impersonate", Impersonate, util.AuthMiddleware, util.DenyAPIKeys, util.RequirePermission(models.PermUserImpersonate))
 
 	// Public job routes
 	e.GET("/jobs", GetJobs, util.AuthMiddleware)
-	e.GET("/jobs/apply", ApplyToJob, util.AuthMiddleware, util.ApplicantOnly)
+	e.GET("/jobs/apply", ApplyToJob, util.AuthMiddleware, util.DenyImpersonation, util.RequirePermission(models.PermApplicationCreate))
+	e.POST("/jobs/apply", ApplyToJob, util.AuthMiddleware, util.DenyImpersonation, util.RequirePermission(models.PermApplicationCreate))
+	e.GET("/jobs/:job_id/questions", ListApplicantScreeningQuestions, util.AuthMiddleware)
+
+	// Unauthenticated job pages and feeds for search engines and aggregators
+	e.GET("/public/jobs", PublicJobsFeed(services.FeedJSON))
+	e.GET("/public/jobs/:job_id", PublicJob(services.FeedJSON))
+	e.GET("/public/jobs/:job_id/jsonld", PublicJob(services.FeedJSONLD))
+	e.GET("/public/feeds/jobs.atom", PublicJobsFeed(services.FeedAtom))
+	e.GET("/public/feeds/jobs.rss", PublicJobsFeed(services.FeedRSS))
+	e.GET("/public/feeds/jobs.xml", PublicJobsFeed(services.FeedXML))
 }
 
-// Signup handles user registration
+// Signup handles applicant registration. Privileged accounts are created through invitations.
 func Signup(c echo.Context) error {
-	var user models.User
-	if err := c.Bind(&user); err != nil {
+	var signupData struct {
+		Name            string `json:"name"`
+		Email           string `json:"email"`
+		Address         string `json:"address"`
+		Password        string `json:"password"`
+		ProfileHeadline string `json:"profile_headline"`
+	}
+	if err := c.Bind(&signupData); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
 	}
 
 	// Validate user input
-	if user.Name == "" || user.Email == "" || user.PasswordHash == "" {
+	if signupData.Name == "" || signupData.Email == "" || signupData.Password == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Name, email, and password are required"})
 	}
 
+	user := models.User{
+		Name:            signupData.Name,
+		Email:           signupData.Email,
+		Address:         signupData.Address,
+		UserType:        models.UserTypeApplicant,
+		PasswordHash:    signupData.Password,
+		ProfileHeadline: signupData.ProfileHeadline,
+	}
 	if err := userService.CreateUser(c.Request().Context(), &user); err != nil {
+		if errors.Is(err, services.ErrEmailExists) {
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		if errors.Is(err, services.ErrPasswordTooShort) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
+	// Account creation succeeds even if the mail cannot be sent; the user can request a new link
+	accountService.SendEmailVerification(c.Request().Context(), &user)
+
 	return c.JSON(http.StatusCreated, map[string]string{"message": "User created successfully"})
 }
 
@@ -77,18 +263,84 @@ func Login(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Email and password are required"})
 	}
 
-	user, err := userService.ValidateLogin(c.Request().Context(), loginData.Email, loginData.Password)
+	user, err := userService.ValidateLogin(c.Request().Context(), loginData.Email, loginData.Password, c.RealIP())
+	var lockedErr *services.AccountLockedError
+	if errors.As(err, &lockedErr) {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+		return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "Too many failed login attempts, try again later"})
+	}
+	if errors.Is(err, services.ErrEmailNotVerified) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Email address has not been verified"})
+	}
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid credentials"})
 	}
 
-	// Generate JWT token
-	token, err := util.GenerateToken(user.ID, string(user.UserType))
+	return finishLogin(c, user)
+}
+
+// finishLogin answers a login whose first factor succeeded, however it was
+// checked. Accounts with a second factor, or that policy requires one for,
+// finish logging in through /login/mfa; everyone else gets their tokens.
+func finishLogin(c echo.Context, user *models.User) error {
+	if user.MFAEnabled() || mfaService.Required(user) {
+		mfaToken, err := util.GenerateMFAPendingToken(user.ID, string(user.UserType))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Could not generate token"})
+		}
+		if user.MFAEnabled() {
+			return c.JSON(http.StatusOK, map[string]interface{}{"mfa_required": true, "mfa_token": mfaToken})
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{"mfa_enrollment_required": true, "mfa_token": mfaToken})
+	}
+
+	// Generate access and refresh tokens
+	tokens, err := tokenService.IssueTokens(c.Request().Context(), user, clientInfo(c))
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Could not generate token"})
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{"token": token})
+	return c.JSON(http.StatusOK, tokens)
+}
+
+// GetJWKS publishes the public keys tokens can be verified with
+func GetJWKS(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, util.CurrentKeyring().JWKS())
+}
+
+// RefreshToken exchanges a refresh token for a new token pair
+func RefreshToken(c echo.Context) error {
+	var refreshData struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := c.Bind(&refreshData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	if refreshData.RefreshToken == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Refresh token is required"})
+	}
+
+	tokens, err := tokenService.Refresh(c.Request().Context(), refreshData.RefreshToken, clientInfo(c))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid refresh token"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Could not refresh token"})
+	}
+
+	return c.JSON(http.StatusOK, tokens)
+}
+
+// Logout revokes the caller's access token and its refresh token family
+func Logout(c echo.Context) error {
+	claims := c.Get("claims").(*util.Claims)
+	if err := tokenService.Logout(c.Request().Context(), claims); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Could not log out"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Logged out successfully"})
 }
 
 // UploadResume handles resume upload
@@ -118,11 +370,21 @@ func CreateJob(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Job title and description are required"})
 	}
 
+	// The poster is always the caller, and provenance is only recorded by
+	// the template and clone routes
+	job.PostedByID = c.Get("userId").(uint)
+	job.TemplateID = nil
+	job.ClonedFromID = nil
+
 	if err := jobService.CreateJob(c.Request().Context(), &job); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return jobErrorResponse(c, err)
+	}
+	if job.Status == models.JobStatusPublished {
+		warmJobsCache()
 	}
 
-	return c.JSON(http.StatusCreated, map[string]string{"message": "Job created successfully"})
+	c.Response().Header().Set("ETag", jobETag(&job))
+	return c.JSON(http.StatusCreated, map[string]interface{}{"message": "Job created successfully", "id": job.ID})
 }
 
 // GetJobWithApplicants retrieves job details and applicants
@@ -133,17 +395,34 @@ func GetJobWithApplicants(c echo.Context) error {
 		return c.String(http.StatusBadRequest, "Invalid job ID")
 	}
 	job, err := jobService.GetJobWithApplicants(c.Request().Context(), uint(id))
+	if errors.Is(err, services.ErrJobNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Job not found"})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
+	// Callers limited to their own jobs cannot tell other jobs exist
+	if !util.HasPermission(c, models.PermJobRead) && !job.IsManagedBy(c.Get("userId").(uint)) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Job not found"})
+	}
+
+	c.Response().Header().Set("ETag", jobETag(job))
 	return c.JSON(http.StatusOK, job)
 }
 
-// GetAllApplicants retrieves all applicants
+// GetAllApplicants retrieves applicants a page at a time
 func GetAllApplicants(c echo.Context) error {
-	applicants, err := userService.GetAllApplicants(c.Request().Context(), 1, 100) // Example pagination
+	req, err := pageRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	applicants, err := userService.GetAllApplicants(c.Request().Context(), req)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
@@ -158,6 +437,9 @@ func GetApplicantData(c echo.Context) error {
 		return c.String(http.StatusBadRequest, "Invalid Applicant ID")
 	}
 	applicant, err := userService.GetApplicantWithProfile(c.Request().Context(), uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Applicant not found"})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
@@ -165,17 +447,46 @@ func GetApplicantData(c echo.Context) error {
 	return c.JSON(http.StatusOK, applicant)
 }
 
-// GetJobs retrieves job openings
-func GetJobs(c echo.Context) error {
-	jobs, err := jobService.GetJobs(c.Request().Context(), services.JobFilters{}) // Add filters as needed
+// UnlockAccount lifts a login lockout
+func UnlockAccount(c echo.Context) error {
+	userID := c.Param("user_id")
+	id, err := strconv.ParseUint(userID, 10, 64)
 	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	if err := userService.UnlockAccount(c.Request().Context(), uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 
+	return c.JSON(http.StatusOK, map[string]string{"message": "Account unlocked successfully"})
+}
+
+// GetJobs retrieves job openings, filtered, sorted and paginated by the query string
+func GetJobs(c echo.Context) error {
+	filters, err := parseJobFilters(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	// Staff who can read every job may list drafts and closed jobs; everyone else sees open jobs
+	if filters.Status != "" && !util.HasPermission(c, models.PermJobRead) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Permission denied"})
+	}
+
+	jobs, err := jobService.GetJobs(c.Request().Context(), filters)
+	if err != nil {
+		return jobErrorResponse(c, err)
+	}
+
 	return c.JSON(http.StatusOK, jobs)
 }
 
-// ApplyToJob handles job applications
+// ApplyToJob handles job applications. Answers to the job's screening
+// questions are sent in the body of a POST; a GET applies without answers.
 func ApplyToJob(c echo.Context) error {
 	userID := c.Get("userId").(uint)
 	jobID := c.QueryParam("job_id")
@@ -189,8 +500,18 @@ func ApplyToJob(c echo.Context) error {
 		return c.String(http.StatusBadRequest, "Invalid job ID")
 	}
 
-	if err := jobService.ApplyToJob(c.Request().Context(), uint(id), userID); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	var applyData struct {
+		Answers []services.ApplicationAnswer `json:"answers"`
+	}
+	if c.Request().Method == http.MethodPost {
+		if err := c.Bind(&applyData); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+		}
+	}
+
+	// Applicants are not told whether a knockout rule rejected them
+	if _, err := jobService.ApplyToJob(c.Request().Context(), uint(id), userID, applyData.Answers); err != nil {
+		return jobErrorResponse(c, err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]string{"message": "Applied to job successfully"})
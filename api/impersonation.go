@@ -0,0 +1,69 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"synergylabs/models"
+	"synergylabs/services"
+	"synergylabs/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Impersonate issues the caller a read-only token acting as an applicant
+func Impersonate(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	token, err := impersonationService.Impersonate(c.Request().Context(), c.Get("userId").(uint), uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		case errors.Is(err, services.ErrNotApplicant):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Could not start impersonation"})
+	}
+
+	return c.JSON(http.StatusOK, token)
+}
+
+// auditImpersonation records every request made with an impersonation token,
+// including the ones refused, once the handler has responded
+func auditImpersonation(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		err := next(c)
+
+		adminID, ok := c.Get("impersonatorId").(uint)
+		if !ok {
+			return err
+		}
+		userID := c.Get("impersonatedUserId").(uint)
+
+		status := c.Response().Status
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			status = httpErr.Code
+		}
+
+		request := util.RequestInfoFromContext(c.Request().Context())
+		auditService.Record(c.Request().Context(), &models.AuditEvent{
+			ActorID:       &adminID,
+			SubjectUserID: &userID,
+			Action:        models.AuditActionImpersonationRequest,
+			Metadata: map[string]interface{}{
+				"method": c.Request().Method,
+				"path":   c.Request().URL.RequestURI(),
+				"route":  c.Path(),
+				"status": status,
+			},
+			RequestID: request.ID,
+			IP:        request.IP,
+		})
+		return err
+	}
+}
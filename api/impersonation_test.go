@@ -0,0 +1,86 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"synergylabs/models"
+	"synergylabs/services"
+	"synergylabs/util"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+func TestAuditImpersonationRecordsEveryRequest(t *testing.T) {
+	conn := testDB(t)
+	auditService = *services.NewAuditService(conn, zap.NewNop())
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating signing key: %v", err)
+	}
+	keyring, err := util.NewKeyring("test", &util.SigningKey{ID: "test", Method: util.EdDSA, PrivateKey: private, PublicKey: public})
+	if err != nil {
+		t.Fatalf("building keyring: %v", err)
+	}
+	util.SetKeyring(keyring)
+
+	suffix := time.Now().UnixNano()
+	admin := models.User{Name: "admin", Email: fmt.Sprintf("admin-%d@example.com", suffix), UserType: models.UserTypeAdmin}
+	applicant := models.User{Name: "applicant", Email: fmt.Sprintf("applicant-%d@example.com", suffix), UserType: models.UserTypeApplicant}
+	for _, user := range []*models.User{&admin, &applicant} {
+		if err := conn.Create(user).Error; err != nil {
+			t.Fatalf("creating %s: %v", user.Name, err)
+		}
+	}
+	token, err := util.GenerateToken(applicant.ID, string(applicant.UserType), util.WithImpersonator(admin.ID))
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	e := echo.New()
+	e.Use(auditImpersonation)
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusNoContent) }
+	e.GET("/me", ok, util.AuthMiddleware)
+	e.PUT("/me", ok, util.AuthMiddleware)
+
+	requests := []struct {
+		method string
+		want   int
+	}{
+		{http.MethodGet, http.StatusNoContent},
+		{http.MethodPut, http.StatusForbidden},
+	}
+	for _, r := range requests {
+		req := httptest.NewRequest(r.method, "/me?fields=name", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if rec.Code != r.want {
+			t.Errorf("%s /me: status %d, want %d", r.method, rec.Code, r.want)
+		}
+	}
+
+	// Refused requests are recorded too, with the status they got
+	var events []models.AuditEvent
+	if err := conn.Where("action = ? AND actor_id = ? AND subject_user_id = ?", models.AuditActionImpersonationRequest, admin.ID, applicant.ID).
+		Order("id").Find(&events).Error; err != nil {
+		t.Fatalf("loading audit events: %v", err)
+	}
+	if len(events) != len(requests) {
+		t.Fatalf("%d audit events, want %d", len(events), len(requests))
+	}
+	for i, event := range events {
+		if event.Metadata["method"] != requests[i].method || event.Metadata["path"] != "/me?fields=name" || event.Metadata["route"] != "/me" {
+			t.Errorf("event %d metadata = %v", i, event.Metadata)
+		}
+		if status, _ := event.Metadata["status"].(float64); int(status) != requests[i].want {
+			t.Errorf("event %d status = %v, want %d", i, event.Metadata["status"], requests[i].want)
+		}
+	}
+}
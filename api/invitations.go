@@ -0,0 +1,141 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"synergylabs/models"
+	"synergylabs/services"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CreateInvitation invites a person to create a privileged account
+func CreateInvitation(c echo.Context) error {
+	var inviteData struct {
+		Email          string     `json:"email"`
+		Role           string     `json:"role"`
+		ExpiresAt      *time.Time `json:"expires_at"`
+		OrganizationID *uint      `json:"organization_id"`
+	}
+	if err := c.Bind(&inviteData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	if inviteData.Email == "" || inviteData.Role == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Email and role are required"})
+	}
+
+	expiresAt := time.Now().Add(services.DefaultInvitationTTL)
+	if inviteData.ExpiresAt != nil {
+		expiresAt = *inviteData.ExpiresAt
+	}
+	if !expiresAt.After(time.Now()) || time.Until(expiresAt) > services.MaxInvitationTTL {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Expiry must be in the future and at most 30 days away"})
+	}
+
+	invitation := models.Invitation{
+		Email:          inviteData.Email,
+		Role:           inviteData.Role,
+		ExpiresAt:      expiresAt,
+		InvitedByID:    c.Get("userId").(uint),
+		OrganizationID: inviteData.OrganizationID,
+	}
+	token, err := invitationService.CreateInvitation(c.Request().Context(), &invitation)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrEmailExists):
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		case errors.Is(err, services.ErrRoleNotFound):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid role"})
+		case errors.Is(err, services.ErrRoleNotGrantable):
+			return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+		case errors.Is(err, services.ErrOrganizationNotFound),
+			errors.Is(err, services.ErrNotStaff),
+			errors.Is(err, services.ErrEmailDomainNotAllowed):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"invitation": invitation,
+		"token":      token,
+	})
+}
+
+// ListInvitations lists invitations, newest first
+func ListInvitations(c echo.Context) error {
+	page, pageSize, err := pageParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	invitations, err := invitationService.ListInvitations(c.Request().Context(), page, pageSize)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, invitations)
+}
+
+// RevokeInvitation revokes a pending invitation
+func RevokeInvitation(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("invite_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid invitation ID")
+	}
+
+	if err := invitationService.RevokeInvitation(c.Request().Context(), uint(id)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvitationNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		case errors.Is(err, services.ErrInvitationAccepted):
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Invitation revoked successfully"})
+}
+
+// AcceptInvitation creates the invited account from an invite token
+func AcceptInvitation(c echo.Context) error {
+	var acceptData struct {
+		Token           string `json:"token"`
+		Name            string `json:"name"`
+		Address         string `json:"address"`
+		Password        string `json:"password"`
+		ProfileHeadline string `json:"profile_headline"`
+	}
+	if err := c.Bind(&acceptData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	if acceptData.Token == "" || acceptData.Name == "" || acceptData.Password == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Token, name, and password are required"})
+	}
+
+	user := models.User{
+		Name:            acceptData.Name,
+		Address:         acceptData.Address,
+		PasswordHash:    acceptData.Password,
+		ProfileHeadline: acceptData.ProfileHeadline,
+	}
+	if err := invitationService.AcceptInvitation(c.Request().Context(), acceptData.Token, &user); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidInvitation):
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		case errors.Is(err, services.ErrPasswordTooShort):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		case errors.Is(err, services.ErrInvitationAccepted),
+			errors.Is(err, services.ErrInvitationRevoked),
+			errors.Is(err, services.ErrInvitationExpired),
+			errors.Is(err, services.ErrEmailExists):
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]string{"message": "Account created successfully"})
+}
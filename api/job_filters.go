@@ -0,0 +1,135 @@
+package api
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"synergylabs/models"
+	"synergylabs/services"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// jobFilterParams are the query parameters GET /jobs understands
+var jobFilterParams = map[string]bool{
+	"status": true, "q": true, "title": true, "company": true, "posted_after": true,
+	"city": true, "country": true, "remote": true, "employment_type": true, "seniority": true, "department": true,
+	"salary_min": true, "salary_max": true, "currency": true, "salary_period": true,
+	"lat": true, "lng": true, "radius_km": true,
+	"sort": true, "page": true, "page_size": true, "cursor": true, "count": true,
+}
+
+// parseJobFilters reads and validates the job listing query parameters.
+// Unknown parameters are rejected so a misspelled filter is not silently ignored.
+func parseJobFilters(c echo.Context) (services.JobFilters, error) {
+	query := c.QueryParams()
+	for name := range query {
+		if !jobFilterParams[name] {
+			return services.JobFilters{}, fmt.Errorf("unknown query parameter %q", name)
+		}
+	}
+
+	filters := services.JobFilters{
+		Status:      query.Get("status"),
+		Query:       strings.TrimSpace(query.Get("q")),
+		Title:       strings.TrimSpace(query.Get("title")),
+		CompanyName: strings.TrimSpace(query.Get("company")),
+		City:        strings.TrimSpace(query.Get("city")),
+		Country:     strings.ToUpper(strings.TrimSpace(query.Get("country"))),
+		Department:  strings.TrimSpace(query.Get("department")),
+		Sort:        query.Get("sort"),
+	}
+	if filters.Status != "" && filters.Status != services.JobStatusAll && !models.JobStatus(filters.Status).Valid() {
+		return filters, fmt.Errorf("invalid status %q", filters.Status)
+	}
+
+	if value := query.Get("posted_after"); value != "" {
+		postedAfter, err := parseDateParam(value)
+		if err != nil {
+			return filters, fmt.Errorf("posted_after must be a date (2006-01-02) or an RFC 3339 time")
+		}
+		filters.PostedAfter = postedAfter
+	}
+
+	for _, value := range listParam(query.Get("remote")) {
+		filters.RemotePolicies = append(filters.RemotePolicies, models.RemotePolicy(value))
+	}
+	for _, value := range listParam(query.Get("employment_type")) {
+		filters.EmploymentTypes = append(filters.EmploymentTypes, models.EmploymentType(value))
+	}
+	for _, value := range listParam(query.Get("seniority")) {
+		filters.Seniorities = append(filters.Seniorities, models.Seniority(value))
+	}
+
+	var err error
+	if filters.SalaryMin, err = int64Param(query.Get("salary_min"), "salary_min"); err != nil {
+		return filters, err
+	}
+	if filters.SalaryMax, err = int64Param(query.Get("salary_max"), "salary_max"); err != nil {
+		return filters, err
+	}
+	filters.SalaryCurrency = strings.ToUpper(query.Get("currency"))
+	filters.SalaryPeriod = models.SalaryPeriod(strings.ToUpper(query.Get("salary_period")))
+
+	lat, lng := query.Get("lat"), query.Get("lng")
+	if lat != "" || lng != "" {
+		point := &services.GeoPoint{}
+		if point.Latitude, err = strconv.ParseFloat(lat, 64); err != nil {
+			return filters, fmt.Errorf("lat and lng must both be numbers")
+		}
+		if point.Longitude, err = strconv.ParseFloat(lng, 64); err != nil {
+			return filters, fmt.Errorf("lat and lng must both be numbers")
+		}
+		filters.Near = point
+	}
+	if value := query.Get("radius_km"); value != "" {
+		if filters.RadiusKm, err = strconv.ParseFloat(value, 64); err != nil {
+			return filters, fmt.Errorf("radius_km must be a number")
+		}
+	}
+
+	if filters.Sort != "" && !validJobSort(filters.Sort) {
+		return filters, fmt.Errorf("sort must be one of %s, optionally prefixed with -", strings.Join(services.JobSortFields, ", "))
+	}
+
+	if filters.PageRequest, err = pageRequest(c); err != nil {
+		return filters, err
+	}
+	return filters, nil
+}
+
+func validJobSort(sort string) bool {
+	return slices.Contains(services.JobSortFields, strings.TrimPrefix(sort, "-"))
+}
+
+// listParam splits a comma-separated query value into upper-case items
+func listParam(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, strings.ToUpper(item))
+		}
+	}
+	return items
+}
+
+func int64Param(value, name string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an integer", name)
+	}
+	return &n, nil
+}
+
+// parseDateParam accepts a plain date or an RFC 3339 time
+func parseDateParam(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
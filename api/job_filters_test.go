@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"synergylabs/models"
+	"synergylabs/services"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+func TestGetJobsRejectsInvalidFilters(t *testing.T) {
+	// Every request below is refused before the service reads anything
+	jobService = *services.NewJobService(nil, nil, zap.NewNop())
+
+	for _, query := range []string{
+		"locaton=Berlin",
+		"status=OPEN",
+		"posted_after=yesterday",
+		"remote=SPACE",
+		"employment_type=FULL_TIME,GIG",
+		"seniority=WIZARD",
+		"country=DEU",
+		"salary_min=lots&currency=EUR&salary_period=YEAR",
+		"salary_max=1e5&currency=EUR&salary_period=YEAR",
+		"salary_min=50000",
+		"salary_min=50000&currency=euro&salary_period=YEAR",
+		"salary_min=50000&currency=EUR&salary_period=FORTNIGHT",
+		"salary_min=70000&salary_max=50000&currency=EUR&salary_period=YEAR",
+		"lat=52.5",
+		"lat=52.5&lng=east&radius_km=10",
+		"lat=91&lng=13.4&radius_km=10",
+		"lat=52.5&lng=181&radius_km=10",
+		"lat=52.5&lng=13.4",
+		"lat=52.5&lng=13.4&radius_km=-5",
+		"lat=52.5&lng=13.4&radius_km=far",
+		"radius_km=10",
+		"sort=salary_desc",
+		"sort=--title",
+		"sort=distance",
+		"page=0",
+		"page=2&cursor=abc",
+		"page_size=ten",
+		"count=maybe",
+	} {
+		t.Run(query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/jobs?"+query, nil), rec)
+			if err := GetJobs(c); err != nil {
+				t.Fatalf("GetJobs: %v", err)
+			}
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+			}
+		})
+	}
+}
+
+func TestParseJobFilters(t *testing.T) {
+	query := "q=+go+&city=Berlin&country=de&remote=remote,+hybrid&seniority=senior" +
+		"&salary_min=50000&currency=eur&salary_period=year&lat=52.5&lng=13.4&radius_km=25&sort=-salary&page_size=500"
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/jobs?"+query, nil), httptest.NewRecorder())
+
+	filters, err := parseJobFilters(c)
+	if err != nil {
+		t.Fatalf("parseJobFilters: %v", err)
+	}
+	min := int64(50000)
+	want := services.JobFilters{
+		Query:          "go",
+		City:           "Berlin",
+		Country:        "DE",
+		RemotePolicies: []models.RemotePolicy{models.RemotePolicyRemote, models.RemotePolicyHybrid},
+		Seniorities:    []models.Seniority{models.SenioritySenior},
+		SalaryMin:      &min,
+		SalaryCurrency: "EUR",
+		SalaryPeriod:   models.SalaryPerYear,
+		Near:           &services.GeoPoint{Latitude: 52.5, Longitude: 13.4},
+		RadiusKm:       25,
+		Sort:           "-salary",
+		PageRequest:    services.PageRequest{Page: 1, PageSize: maxPageSize},
+	}
+	if !reflect.DeepEqual(filters, want) {
+		t.Errorf("filters = %+v\nwant %+v", filters, want)
+	}
+}
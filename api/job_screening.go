@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"synergylabs/models"
+	"synergylabs/services"
+	"synergylabs/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ListScreeningQuestions returns a job's screening questions with their knockout rules
+func ListScreeningQuestions(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("job_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid job ID")
+	}
+
+	if err := checkJobAccess(c, uint(id)); err != nil {
+		return jobErrorResponse(c, err)
+	}
+
+	questions, err := jobService.ListScreeningQuestions(c.Request().Context(), uint(id))
+	if err != nil {
+		return jobErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, questions)
+}
+
+// ReplaceScreeningQuestions sets the questions applicants to a job answer
+func ReplaceScreeningQuestions(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("job_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid job ID")
+	}
+
+	var questionsData struct {
+		Questions []models.ScreeningQuestion `json:"questions"`
+	}
+	if err := c.Bind(&questionsData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	var pre services.JobPrecondition
+	if !util.HasPermission(c, models.PermJobUpdate) {
+		pre.OwnerID = c.Get("userId").(uint)
+	}
+
+	questions, err := jobService.ReplaceScreeningQuestions(c.Request().Context(), uint(id), questionsData.Questions, pre)
+	if err != nil {
+		return jobErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, questions)
+}
+
+// ListApplicantScreeningQuestions returns the questions to answer when applying
+// to an open job, without their knockout rules
+func ListApplicantScreeningQuestions(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("job_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid job ID")
+	}
+
+	questions, err := jobService.ApplicantScreeningQuestions(c.Request().Context(), uint(id))
+	if err != nil {
+		return jobErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, questions)
+}
+
+// ListJobApplications returns a job's applications with their screening
+// answers, optionally only those with the status in the status query parameter
+func ListJobApplications(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("job_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid job ID")
+	}
+
+	status := models.ApplicationStatus(c.QueryParam("status"))
+	if status != "" && !status.Valid() {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid application status"})
+	}
+
+	if err := checkJobAccess(c, uint(id)); err != nil {
+		return jobErrorResponse(c, err)
+	}
+
+	applications, err := jobService.ListJobApplications(c.Request().Context(), uint(id), status)
+	if err != nil {
+		return jobErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, applications)
+}
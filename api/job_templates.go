@@ -0,0 +1,175 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"synergylabs/models"
+	"synergylabs/services"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func jobTemplateErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrJobTemplateNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Job template not found"})
+	case errors.Is(err, services.ErrJobTemplateExists):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidTemplateVariables):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return jobErrorResponse(c, err)
+}
+
+// ListJobTemplates lists the job templates of the caller's organization
+func ListJobTemplates(c echo.Context) error {
+	templates, err := jobTemplateService.ListTemplates(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, templates)
+}
+
+// GetJobTemplate returns a template with the variables it uses
+func GetJobTemplate(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("template_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid template ID")
+	}
+
+	template, err := jobTemplateService.GetTemplate(c.Request().Context(), uint(id))
+	if err != nil {
+		return jobTemplateErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, template)
+}
+
+// CreateJobTemplate saves a new template
+func CreateJobTemplate(c echo.Context) error {
+	template, err := bindJobTemplate(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	template.CreatedByID = c.Get("userId").(uint)
+
+	if err := jobTemplateService.CreateTemplate(c.Request().Context(), template); err != nil {
+		return jobTemplateErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{"message": "Job template created successfully", "id": template.ID})
+}
+
+// UpdateJobTemplate replaces a template's name and content
+func UpdateJobTemplate(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("template_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid template ID")
+	}
+	template, err := bindJobTemplate(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	template.ID = uint(id)
+
+	if err := jobTemplateService.UpdateTemplate(c.Request().Context(), template); err != nil {
+		return jobTemplateErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Job template updated successfully"})
+}
+
+// DeleteJobTemplate deletes a template. Jobs created from it are kept.
+func DeleteJobTemplate(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("template_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid template ID")
+	}
+
+	if err := jobTemplateService.DeleteTemplate(c.Request().Context(), uint(id)); err != nil {
+		return jobTemplateErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Job template deleted successfully"})
+}
+
+// bindJobTemplate reads a template from the request body
+func bindJobTemplate(c echo.Context) (*models.JobTemplate, error) {
+	var template models.JobTemplate
+	if err := c.Bind(&template); err != nil {
+		return nil, errors.New("Invalid input")
+	}
+	template.Name = strings.TrimSpace(template.Name)
+	if template.Name == "" || template.Title == "" || template.Description == "" {
+		return nil, errors.New("Template name, title and description are required")
+	}
+	return &template, nil
+}
+
+// CreateJobFromTemplate creates a job from a template, filling in its
+// variables. The schedule and other per-job fields are given in the request.
+func CreateJobFromTemplate(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("template_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid template ID")
+	}
+	var body struct {
+		Variables       map[string]string `json:"variables"`
+		Status          models.JobStatus  `json:"status"`
+		PublishAt       *time.Time        `json:"publish_at"`
+		ClosesAt        *time.Time        `json:"closes_at"`
+		HiringManagerID *uint             `json:"hiring_manager_id"`
+		Latitude        *float64          `json:"latitude"`
+		Longitude       *float64          `json:"longitude"`
+	}
+	if err := c.Bind(&body); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	job, err := jobTemplateService.RenderTemplate(c.Request().Context(), uint(id), body.Variables)
+	if err != nil {
+		return jobTemplateErrorResponse(c, err)
+	}
+	job.PostedByID = c.Get("userId").(uint)
+	job.Status = body.Status
+	job.PublishAt = body.PublishAt
+	job.ClosesAt = body.ClosesAt
+	job.HiringManagerID = body.HiringManagerID
+	job.Latitude = body.Latitude
+	job.Longitude = body.Longitude
+
+	if err := jobService.CreateJob(c.Request().Context(), job); err != nil {
+		return jobErrorResponse(c, err)
+	}
+	if job.Status == models.JobStatusPublished {
+		warmJobsCache()
+	}
+
+	c.Response().Header().Set("ETag", jobETag(job))
+	return c.JSON(http.StatusCreated, map[string]interface{}{"message": "Job created successfully", "id": job.ID})
+}
+
+// CloneJob creates a draft copy of a job, posted by the caller
+func CloneJob(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("job_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid job ID")
+	}
+	userID := c.Get("userId").(uint)
+
+	if err := checkJobAccess(c, uint(id)); err != nil {
+		return jobErrorResponse(c, err)
+	}
+
+	clone, err := jobService.CloneJob(c.Request().Context(), uint(id), userID)
+	if err != nil {
+		return jobErrorResponse(c, err)
+	}
+
+	c.Response().Header().Set("ETag", jobETag(clone))
+	return c.JSON(http.StatusCreated, map[string]interface{}{"message": "Job cloned successfully", "id": clone.ID})
+}
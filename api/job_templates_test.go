@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"synergylabs/models"
+	"synergylabs/services"
+	"synergylabs/util"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+func TestCreateJobFromTemplate(t *testing.T) {
+	conn := testDB(t)
+	jobService = *services.NewJobService(conn, testCache(t), zap.NewNop())
+	jobTemplateService = *services.NewJobTemplateService(conn, zap.NewNop())
+	ctx := util.ContextWithAllOrganizations(context.Background())
+
+	poster := models.User{Name: "Recruiter", Email: fmt.Sprintf("templates-%d@example.com", time.Now().UnixNano()), UserType: models.UserTypeAdmin}
+	if err := conn.Create(&poster).Error; err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	template := models.JobTemplate{
+		Name:         fmt.Sprintf("template-%d", time.Now().UnixNano()),
+		Title:        "{{level}} Engineer",
+		Description:  "Work from {{city}}.",
+		CompanyName:  "Acme",
+		LocationCity: "{{city}}",
+	}
+	if err := jobTemplateService.CreateTemplate(ctx, &template); err != nil {
+		t.Fatalf("CreateTemplate: %v", err)
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)).WithContext(ctx)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.SetParamNames("template_id")
+		c.SetParamValues(fmt.Sprint(template.ID))
+		c.Set("userId", poster.ID)
+		if err := CreateJobFromTemplate(c); err != nil {
+			t.Fatalf("CreateJobFromTemplate: %v", err)
+		}
+		return rec
+	}
+
+	for _, tt := range []struct {
+		name string
+		body string
+	}{
+		{"missing variable", `{"variables": {"city": "Berlin"}, "status": "DRAFT"}`},
+		{"unknown variable", `{"variables": {"city": "Berlin", "level": "Senior", "team": "Core"}, "status": "DRAFT"}`},
+	} {
+		if rec := post(tt.body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400: %s", tt.name, rec.Code, rec.Body)
+		}
+	}
+
+	rec := post(`{"variables": {"city": "Berlin", "level": "Senior"}, "status": "DRAFT"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var created struct {
+		ID uint `json:"id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	var job models.Job
+	if err := conn.First(&job, created.ID).Error; err != nil {
+		t.Fatalf("loading job: %v", err)
+	}
+	if job.Title != "Senior Engineer" || job.Description != "Work from Berlin." || job.LocationCity != "Berlin" {
+		t.Errorf("job = %q, %q, %q", job.Title, job.Description, job.LocationCity)
+	}
+	if job.TemplateID == nil || *job.TemplateID != template.ID || job.PostedByID != poster.ID || job.Status != models.JobStatusDraft {
+		t.Errorf("job template = %v, poster = %d, status = %s", job.TemplateID, job.PostedByID, job.Status)
+	}
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"synergylabs/models"
+	"synergylabs/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxImportBytes bounds the size of an import file
+const maxImportBytes = 20 << 20
+
+// ImportJobs creates and updates jobs from a CSV or JSON Lines file sent as
+// the request body, and reports the rows that failed
+func ImportJobs(c echo.Context) error {
+	format, err := jobDataFormat(c, c.Request().Header.Get(echo.HeaderContentType))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	opts := services.JobImportOptions{Format: format, PosterID: c.Get("userId").(uint)}
+	if opts.DryRun, err = queryBool(c, "dry_run"); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if opts.AllOrNothing, err = queryBool(c, "all_or_nothing"); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	body := http.MaxBytesReader(c.Response(), c.Request().Body, maxImportBytes)
+	report, err := jobService.ImportJobs(c.Request().Context(), body, opts)
+	if err != nil {
+		return jobErrorResponse(c, err)
+	}
+	if report.Committed && report.Created+report.Updated > 0 {
+		warmJobsCache()
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// ExportJobs streams the caller's organization's jobs as CSV or JSON Lines
+func ExportJobs(c echo.Context) error {
+	format, err := jobDataFormat(c, "")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	status := models.JobStatus(c.QueryParam("status"))
+	if status != "" && !status.Valid() {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid status"})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, format.ContentType())
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="jobs.%s"`, format))
+	c.Response().WriteHeader(http.StatusOK)
+
+	// Headers are already sent, so a failure can only cut the file short
+	_, err = jobService.ExportJobs(c.Request().Context(), c.Response(), format, status)
+	return err
+}
+
+// jobDataFormat reads the file format from the format query parameter,
+// falling back to the given content type. Exports default to CSV.
+func jobDataFormat(c echo.Context, contentType string) (services.JobDataFormat, error) {
+	value := c.QueryParam("format")
+	if value == "" && contentType != "" {
+		value, _, _ = mime.ParseMediaType(contentType)
+	}
+	if value == "" {
+		return services.JobFormatCSV, nil
+	}
+	return services.ParseJobDataFormat(value)
+}
+
+// queryBool reads an optional true/false query parameter
+func queryBool(c echo.Context, name string) (bool, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", name)
+	}
+	return parsed, nil
+}
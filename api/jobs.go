@@ -0,0 +1,240 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"synergylabs/models"
+	"synergylabs/services"
+	"synergylabs/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+// errPreconditionRequired is returned when a job update has no If-Match header
+var errPreconditionRequired = errors.New("missing If-Match header; send the ETag the job was read with")
+
+// jobErrorResponse maps job service errors onto HTTP responses
+func jobErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrJobNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Job not found"})
+	case errors.Is(err, services.ErrNotJobOwner):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	case errors.Is(err, services.ErrJobVersionConflict):
+		return c.JSON(http.StatusPreconditionFailed, map[string]string{"error": err.Error()})
+	case errors.Is(err, errPreconditionRequired):
+		return c.JSON(http.StatusPreconditionRequired, map[string]string{"error": err.Error()})
+	case errors.Is(err, services.ErrJobTransitionNotAllowed),
+		errors.Is(err, services.ErrJobNotOpen),
+		errors.Is(err, services.ErrJobExternalRefExists),
+		errors.Is(err, services.ErrAlreadyApplied):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, services.ErrJobFieldNotUpdatable),
+		errors.Is(err, services.ErrInvalidHiringManager),
+		errors.Is(err, services.ErrInvalidJobStatus),
+		errors.Is(err, services.ErrInvalidJobSchedule),
+		errors.Is(err, services.ErrInvalidJobAttribute),
+		errors.Is(err, services.ErrInvalidJobFilter),
+		errors.Is(err, services.ErrInvalidCursor),
+		errors.Is(err, services.ErrInvalidJobImport),
+		errors.Is(err, services.ErrInvalidScreeningQuestion),
+		errors.Is(err, services.ErrInvalidScreeningAnswers):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
+
+// ReplaceJob overwrites every editable field of a job
+func ReplaceJob(c echo.Context) error {
+	return updateJob(c, true)
+}
+
+// PatchJob changes only the fields present in the request body
+func PatchJob(c echo.Context) error {
+	return updateJob(c, false)
+}
+
+func updateJob(c echo.Context, replace bool) error {
+	id, err := strconv.ParseUint(c.Param("job_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid job ID")
+	}
+	version, err := ifMatchVersion(c, true)
+	if err != nil {
+		return jobErrorResponse(c, err)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	var changes models.Job
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(body, &present); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	if err := json.Unmarshal(body, &changes); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	// A replacement writes every editable field; a patch only the ones sent
+	var fields []string
+	if replace {
+		fields = services.JobUpdatableFields()
+	} else {
+		for field := range present {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No fields to update"})
+	}
+
+	_, hasTitle := present["title"]
+	_, hasDescription := present["description"]
+	if ((replace || hasTitle) && changes.Title == "") || ((replace || hasDescription) && changes.Description == "") {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Job title and description are required"})
+	}
+
+	pre := services.JobPrecondition{Version: version}
+	if !util.HasPermission(c, models.PermJobUpdate) {
+		pre.OwnerID = c.Get("userId").(uint)
+	}
+
+	job, err := jobService.UpdateJob(c.Request().Context(), uint(id), &changes, fields, pre)
+	if err != nil {
+		return jobErrorResponse(c, err)
+	}
+
+	c.Response().Header().Set("ETag", jobETag(job))
+	return c.JSON(http.StatusOK, job)
+}
+
+// DeleteJob removes a job. An If-Match header is optional and, when sent, must match.
+func DeleteJob(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("job_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid job ID")
+	}
+	version, err := ifMatchVersion(c, false)
+	if err != nil {
+		return jobErrorResponse(c, err)
+	}
+
+	pre := services.JobPrecondition{Version: version}
+	if !util.HasPermission(c, models.PermJobDelete) {
+		pre.OwnerID = c.Get("userId").(uint)
+	}
+
+	if err := jobService.DeleteJob(c.Request().Context(), uint(id), pre); err != nil {
+		return jobErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Job deleted successfully"})
+}
+
+// ChangeJobStatus moves a job through its lifecycle, e.g. from draft to published
+func ChangeJobStatus(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("job_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid job ID")
+	}
+	version, err := ifMatchVersion(c, false)
+	if err != nil {
+		return jobErrorResponse(c, err)
+	}
+
+	var statusData struct {
+		Status models.JobStatus `json:"status"`
+	}
+	if err := c.Bind(&statusData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	if statusData.Status == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Status is required"})
+	}
+
+	pre := services.JobPrecondition{Version: version}
+	if !util.HasPermission(c, models.PermJobUpdate) {
+		pre.OwnerID = c.Get("userId").(uint)
+	}
+
+	job, err := jobService.TransitionJob(c.Request().Context(), uint(id), statusData.Status, pre)
+	if err != nil {
+		return jobErrorResponse(c, err)
+	}
+	if job.Status == models.JobStatusPublished {
+		warmJobsCache()
+	}
+
+	c.Response().Header().Set("ETag", jobETag(job))
+	return c.JSON(http.StatusOK, job)
+}
+
+// ListJobTransitions returns who changed a job's status and when
+func ListJobTransitions(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("job_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid job ID")
+	}
+
+	if err := checkJobAccess(c, uint(id)); err != nil {
+		return jobErrorResponse(c, err)
+	}
+
+	transitions, err := jobService.ListJobTransitions(c.Request().Context(), uint(id))
+	if err != nil {
+		return jobErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, transitions)
+}
+
+// checkJobAccess lets callers holding job:read through, and those with only
+// job:read:own when they posted or manage the job. Callers limited to their own
+// jobs cannot tell other jobs exist, so they get ErrJobNotFound.
+func checkJobAccess(c echo.Context, id uint) error {
+	if util.HasPermission(c, models.PermJobRead) {
+		return nil
+	}
+	job, err := jobService.JobOwner(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	if !job.IsManagedBy(c.Get("userId").(uint)) {
+		return services.ErrJobNotFound
+	}
+	return nil
+}
+
+// jobETag is the strong entity tag of a job's current version
+func jobETag(job *models.Job) string {
+	return fmt.Sprintf(`"%d"`, job.Version)
+}
+
+// ifMatchVersion reads the job version from the If-Match header. "*" and a
+// missing optional header return 0, which matches any version.
+func ifMatchVersion(c echo.Context, required bool) (uint, error) {
+	header := strings.TrimSpace(c.Request().Header.Get("If-Match"))
+	switch header {
+	case "":
+		if required {
+			return 0, errPreconditionRequired
+		}
+		return 0, nil
+	case "*":
+		return 0, nil
+	}
+
+	// Weak tags never match If-Match, and neither does anything that is not one of our tags
+	version, err := strconv.ParseUint(strings.Trim(header, `"`), 10, 64)
+	if err != nil || version == 0 || !strings.HasPrefix(header, `"`) {
+		return 0, services.ErrJobVersionConflict
+	}
+	return uint(version), nil
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"os"
+	"sync"
+	"synergylabs/db"
+	"synergylabs/services/cache"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	migrateOnce sync.Once
+	migrateErr  error
+)
+
+// testDB connects to the database in TEST_DATABASE_URL and migrates it. Tests
+// that need a database are skipped when it is not set.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	conn, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		TranslateError: true,
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("connecting to test database: %v", err)
+	}
+	migrateOnce.Do(func() { migrateErr = db.Migrate(conn) })
+	if migrateErr != nil {
+		t.Fatalf("migrating test database: %v", migrateErr)
+	}
+	return conn
+}
+
+// testCache connects to the Redis server in TEST_REDIS_ADDR. Tests that need
+// Redis are skipped when it is not set.
+func testCache(t *testing.T) *cache.Cache {
+	t.Helper()
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR is not set")
+	}
+	return cache.NewCache(addr)
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"synergylabs/services"
+	"synergylabs/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+// accountErrorResponse maps self-service account errors onto HTTP responses
+func accountErrorResponse(c echo.Context, err error) error {
+	var lockedErr *services.AccountLockedError
+	switch {
+	case errors.As(err, &lockedErr):
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+		return c.JSON(http.StatusTooManyRequests, map[string]string{"error": "Too many failed attempts, try again later"})
+	case errors.Is(err, services.ErrInvalidCredentials):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Current password is incorrect"})
+	case errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrProfileNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, services.ErrNoPassword), errors.Is(err, services.ErrPasswordTooShort):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, services.ErrLastSuperAdmin):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
+
+// GetMe returns the caller's account
+func GetMe(c echo.Context) error {
+	user, err := userService.GetAccount(c.Request().Context(), c.Get("userId").(uint))
+	if err != nil {
+		return accountErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// UpdateMe changes the caller's name, address or headline
+func UpdateMe(c echo.Context) error {
+	var update services.AccountUpdate
+	if err := c.Bind(&update); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	if update.Name != nil && strings.TrimSpace(*update.Name) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Name cannot be empty"})
+	}
+
+	user, err := userService.UpdateAccount(c.Request().Context(), c.Get("userId").(uint), update)
+	if err != nil {
+		return accountErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// UpdateMyProfile corrects fields parsed from the caller's resume
+func UpdateMyProfile(c echo.Context) error {
+	var update services.ProfileUpdate
+	if err := c.Bind(&update); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	profile, err := userService.UpdateProfile(c.Request().Context(), c.Get("userId").(uint), update)
+	if err != nil {
+		return accountErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, profile)
+}
+
+// ChangePassword replaces the caller's password and signs out their other devices
+func ChangePassword(c echo.Context) error {
+	var passwordData struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := c.Bind(&passwordData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	if passwordData.CurrentPassword == "" || passwordData.NewPassword == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Current and new password are required"})
+	}
+
+	claims := c.Get("claims").(*util.Claims)
+	ctx := c.Request().Context()
+	if err := userService.ChangePassword(ctx, claims.UserId, passwordData.CurrentPassword, passwordData.NewPassword, c.RealIP()); err != nil {
+		return accountErrorResponse(c, err)
+	}
+
+	// The change stands even if other devices cannot be signed out; they can still be revoked from /me/sessions
+	tokenService.RevokeOtherSessions(ctx, claims.UserId, claims.Family)
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Password changed successfully"})
+}
+
+// CloseAccount deletes the caller's account and signs it out everywhere
+func CloseAccount(c echo.Context) error {
+	var closeData struct {
+		Password string `json:"password"`
+	}
+	if err := c.Bind(&closeData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	userID := c.Get("userId").(uint)
+	ctx := c.Request().Context()
+	if err := userService.CloseAccount(ctx, userID, closeData.Password, c.RealIP()); err != nil {
+		return accountErrorResponse(c, err)
+	}
+
+	// The account is already closed; a failure to revoke its tokens is logged by the token service
+	tokenService.RevokeAllSessions(ctx, userID)
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Account closed successfully"})
+}
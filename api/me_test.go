@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"synergylabs/models"
+	"synergylabs/services"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+func TestAccountNeverSerializesSecrets(t *testing.T) {
+	enabled := time.Now()
+	user := models.User{Name: "Jane", PasswordHash: "$2a$10$hash", TOTPSecret: "TOTPSECRET", MFAEnabledAt: &enabled}
+	body, err := json.Marshal(user)
+	if err != nil {
+		t.Fatalf("marshalling user: %v", err)
+	}
+	for _, secret := range []string{"password", "$2a$10$hash", "totp", "TOTPSECRET"} {
+		if strings.Contains(strings.ToLower(string(body)), strings.ToLower(secret)) {
+			t.Errorf("user JSON contains %q: %s", secret, body)
+		}
+	}
+}
+
+func TestUpdateMeKeepsProtectedFields(t *testing.T) {
+	conn := testDB(t)
+	userService = *services.NewUserService(conn, testCache(t), zap.NewNop())
+
+	email := fmt.Sprintf("me-%d@example.com", time.Now().UnixNano())
+	user := models.User{Name: "Jane", Email: email, UserType: models.UserTypeApplicant, PasswordHash: "$2a$10$unchanged"}
+	if err := conn.Create(&user).Error; err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	if err := conn.Exec("INSERT INTO user_roles (user_id, role_id) SELECT ?, id FROM roles WHERE name = ?", user.ID, models.RoleApplicant).Error; err != nil {
+		t.Fatalf("assigning role: %v", err)
+	}
+
+	// Everything but the name is outside what /me may change
+	body := `{
+		"name": "Jane Doe",
+		"email": "someone-else@example.com",
+		"user_type": "ADMIN",
+		"organization_id": 1,
+		"roles": [{"name": "super_admin"}],
+		"email_verified_at": "2020-01-01T00:00:00Z",
+		"mfa_enabled_at": "2020-01-01T00:00:00Z",
+		"password_hash": "$2a$10$attacker",
+		"PasswordHash": "$2a$10$attacker"
+	}`
+	req := httptest.NewRequest(http.MethodPatch, "/me", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.Set("userId", user.ID)
+	if err := UpdateMe(c); err != nil {
+		t.Fatalf("UpdateMe: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if strings.Contains(rec.Body.String(), "$2a$10$") {
+		t.Errorf("response contains the password hash: %s", rec.Body)
+	}
+
+	var stored models.User
+	if err := conn.Preload("Roles").First(&stored, user.ID).Error; err != nil {
+		t.Fatalf("reloading user: %v", err)
+	}
+	if stored.Name != "Jane Doe" {
+		t.Errorf("name = %q, want Jane Doe", stored.Name)
+	}
+	if stored.Email != email || stored.UserType != models.UserTypeApplicant || stored.OrganizationID != nil ||
+		stored.EmailVerifiedAt != nil || stored.MFAEnabledAt != nil || stored.PasswordHash != "$2a$10$unchanged" {
+		t.Errorf("protected fields changed: %+v", stored)
+	}
+	if len(stored.Roles) != 1 || stored.Roles[0].Name != models.RoleApplicant {
+		t.Errorf("roles = %+v, want only %s", stored.Roles, models.RoleApplicant)
+	}
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"synergylabs/services"
+	"synergylabs/util"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// mfaErrorResponse maps MFA service errors onto HTTP responses
+func mfaErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidMFACode):
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	case errors.Is(err, services.ErrMFATooManyAttempts):
+		return c.JSON(http.StatusTooManyRequests, map[string]string{"error": err.Error()})
+	case errors.Is(err, services.ErrMFAAlreadyEnabled),
+		errors.Is(err, services.ErrMFANotEnrolled),
+		errors.Is(err, services.ErrMFARequired):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid token"})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
+
+// validateMFAToken checks the intermediate token handed out by Login
+func validateMFAToken(token string) (*util.Claims, bool) {
+	claims, err := util.ValidateToken(token)
+	if err != nil || claims.TokenType != util.TokenTypeMFAPending {
+		return nil, false
+	}
+	return claims, true
+}
+
+// LoginMFA completes a login with a TOTP code or a recovery code
+func LoginMFA(c echo.Context) error {
+	var mfaData struct {
+		MFAToken     string `json:"mfa_token"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+	if err := c.Bind(&mfaData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	if mfaData.MFAToken == "" || (mfaData.Code == "" && mfaData.RecoveryCode == "") {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "MFA token and a code or recovery code are required"})
+	}
+
+	claims, ok := validateMFAToken(mfaData.MFAToken)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid token"})
+	}
+
+	ctx := c.Request().Context()
+	if err := mfaService.Verify(ctx, claims.UserId, claims.Id, mfaData.Code, mfaData.RecoveryCode); err != nil {
+		return mfaErrorResponse(c, err)
+	}
+
+	user, err := userService.GetUser(ctx, claims.UserId)
+	if err != nil {
+		return mfaErrorResponse(c, err)
+	}
+
+	tokens, err := tokenService.IssueTokens(ctx, user, clientInfo(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Could not generate token"})
+	}
+
+	return c.JSON(http.StatusOK, tokens)
+}
+
+// LoginMFAEnroll starts the enrollment an account must complete before its first login
+func LoginMFAEnroll(c echo.Context) error {
+	var enrollData struct {
+		MFAToken string `json:"mfa_token"`
+	}
+	if err := c.Bind(&enrollData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	claims, ok := validateMFAToken(enrollData.MFAToken)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid token"})
+	}
+
+	enrollment, err := mfaService.BeginEnrollment(c.Request().Context(), claims.UserId)
+	if err != nil {
+		return mfaErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, enrollment)
+}
+
+// LoginMFAConfirm confirms a login-time enrollment and completes the login
+func LoginMFAConfirm(c echo.Context) error {
+	var confirmData struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code"`
+	}
+	if err := c.Bind(&confirmData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	if confirmData.MFAToken == "" || confirmData.Code == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "MFA token and code are required"})
+	}
+
+	claims, ok := validateMFAToken(confirmData.MFAToken)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid token"})
+	}
+
+	ctx := c.Request().Context()
+	recoveryCodes, err := mfaService.ConfirmEnrollment(ctx, claims.UserId, confirmData.Code)
+	if err != nil {
+		return mfaErrorResponse(c, err)
+	}
+
+	user, err := userService.GetUser(ctx, claims.UserId)
+	if err != nil {
+		return mfaErrorResponse(c, err)
+	}
+
+	tokens, err := tokenService.IssueTokens(ctx, user, clientInfo(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Could not generate token"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"recovery_codes": recoveryCodes,
+		"tokens":         tokens,
+	})
+}
+
+// EnrollMFA starts TOTP enrollment for the logged-in user
+func EnrollMFA(c echo.Context) error {
+	userID := c.Get("userId").(uint)
+	enrollment, err := mfaService.BeginEnrollment(c.Request().Context(), userID)
+	if err != nil {
+		return mfaErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmMFA activates TOTP for the logged-in user and returns recovery codes
+func ConfirmMFA(c echo.Context) error {
+	var confirmData struct {
+		Code string `json:"code"`
+	}
+	if err := c.Bind(&confirmData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	if confirmData.Code == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Code is required"})
+	}
+
+	userID := c.Get("userId").(uint)
+	recoveryCodes, err := mfaService.ConfirmEnrollment(c.Request().Context(), userID, confirmData.Code)
+	if err != nil {
+		return mfaErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"recovery_codes": recoveryCodes})
+}
+
+// DisableMFA turns off TOTP for the logged-in user
+func DisableMFA(c echo.Context) error {
+	var disableData struct {
+		Code string `json:"code"`
+	}
+	if err := c.Bind(&disableData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	if disableData.Code == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Code is required"})
+	}
+
+	userID := c.Get("userId").(uint)
+	if err := mfaService.Disable(c.Request().Context(), userID, disableData.Code); err != nil {
+		return mfaErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Two-factor authentication disabled"})
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"synergylabs/models"
+	"synergylabs/services"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// organizationErrorResponse maps organization service errors onto HTTP responses
+func organizationErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrOrganizationNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "User not found"})
+	case errors.Is(err, services.ErrNotStaff),
+		errors.Is(err, services.ErrEmailDomainNotAllowed),
+		errors.Is(err, services.ErrRoleNotFound),
+		errors.Is(err, services.ErrRoleUserTypeMismatch),
+		errors.Is(err, services.ErrInvalidSSODomain),
+		errors.Is(err, services.ErrSuperAdminSSORole):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, services.ErrPlatformAdminRequired),
+		errors.Is(err, services.ErrRoleNotGrantable):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	case errors.Is(err, services.ErrOrganizationExists),
+		errors.Is(err, services.ErrUserInOtherOrganization),
+		errors.Is(err, services.ErrLastSuperAdmin),
+		errors.Is(err, services.ErrSSODomainTaken):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
+
+// CreateOrganization creates an organization
+func CreateOrganization(c echo.Context) error {
+	var org models.Organization
+	if err := c.Bind(&org); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	if org.Name == "" || org.Slug == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Organization name and slug are required"})
+	}
+
+	if err := organizationService.CreateOrganization(c.Request().Context(), &org); err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, org)
+}
+
+// ListOrganizations lists all organizations
+func ListOrganizations(c echo.Context) error {
+	page, pageSize, err := pageParams(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	orgs, err := organizationService.ListOrganizations(c.Request().Context(), page, pageSize)
+	if err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, orgs)
+}
+
+// GetOrganization retrieves an organization with its settings
+func GetOrganization(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("org_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid organization ID")
+	}
+
+	org, err := organizationService.GetOrganization(c.Request().Context(), uint(id))
+	if err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, org)
+}
+
+// UpdateOrganizationSettings replaces an organization's settings
+func UpdateOrganizationSettings(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("org_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid organization ID")
+	}
+
+	var settings models.OrganizationSettings
+	if err := c.Bind(&settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	org, err := organizationService.UpdateSettings(c.Request().Context(), uint(id), settings)
+	if err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, org)
+}
+
+// ListOrganizationMembers lists the staff accounts of an organization
+func ListOrganizationMembers(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("org_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid organization ID")
+	}
+
+	members, err := organizationService.ListMembers(c.Request().Context(), uint(id))
+	if err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, members)
+}
+
+// AddOrganizationMember adds an existing staff account to an organization
+func AddOrganizationMember(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("org_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid organization ID")
+	}
+
+	var memberData struct {
+		UserID uint `json:"user_id"`
+	}
+	if err := c.Bind(&memberData); err != nil || memberData.UserID == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "User ID is required"})
+	}
+
+	if err := organizationService.AddMember(c.Request().Context(), uint(id), memberData.UserID); err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Member added successfully"})
+}
+
+// RemoveOrganizationMember removes a staff account from an organization and
+// signs it out everywhere
+func RemoveOrganizationMember(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("org_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid organization ID")
+	}
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	ctx := c.Request().Context()
+	if err := organizationService.RemoveMember(ctx, uint(id), uint(userID)); err != nil {
+		return organizationErrorResponse(c, err)
+	}
+
+	// The member has no roles left; a failure to revoke their tokens is logged by the token service
+	tokenService.RevokeAllSessions(ctx, uint(userID))
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Member removed successfully"})
+}
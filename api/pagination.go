@@ -0,0 +1,56 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"synergylabs/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultPageSize = services.DefaultPageSize
+	maxPageSize     = 100
+)
+
+// pageParams reads page and page_size from the query string, defaulting to
+// the first page and clamping the page size. Malformed values are rejected
+// instead of falling back to the defaults.
+func pageParams(c echo.Context) (page, pageSize int, err error) {
+	page, pageSize = 1, defaultPageSize
+	if value := c.QueryParam("page"); value != "" {
+		if page, err = strconv.Atoi(value); err != nil || page < 1 {
+			return 0, 0, fmt.Errorf("page must be a positive integer")
+		}
+	}
+	if value := c.QueryParam("page_size"); value != "" {
+		if pageSize, err = strconv.Atoi(value); err != nil || pageSize < 1 {
+			return 0, 0, fmt.Errorf("page_size must be a positive integer")
+		}
+		if pageSize > maxPageSize {
+			pageSize = maxPageSize
+		}
+	}
+	return page, pageSize, nil
+}
+
+// pageRequest reads page, page_size, cursor and count from the query string,
+// rejecting malformed values instead of falling back to defaults
+func pageRequest(c echo.Context) (services.PageRequest, error) {
+	req := services.PageRequest{Cursor: c.QueryParam("cursor")}
+	if req.Cursor != "" && c.QueryParam("page") != "" {
+		return req, fmt.Errorf("page cannot be combined with cursor")
+	}
+	var err error
+	if req.Page, req.PageSize, err = pageParams(c); err != nil {
+		return req, err
+	}
+	if value := c.QueryParam("count"); value != "" {
+		count, err := strconv.ParseBool(value)
+		if err != nil {
+			return req, fmt.Errorf("count must be true or false")
+		}
+		req.SkipCount = !count
+	}
+	return req, nil
+}
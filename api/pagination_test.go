@@ -0,0 +1,32 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestListingsRejectInvalidPages(t *testing.T) {
+	// Every request below is refused before a service is called
+	listings := map[string]echo.HandlerFunc{
+		"/admin/invitations":   ListInvitations,
+		"/admin/organizations": ListOrganizations,
+		"/admin/audit":         ListAuditEvents,
+	}
+	for path, handler := range listings {
+		for _, query := range []string{"page=0", "page=-1", "page=two", "page_size=0", "page_size=ten"} {
+			t.Run(path+"?"+query, func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, path+"?"+query, nil), rec)
+				if err := handler(c); err != nil {
+					t.Fatalf("handler: %v", err)
+				}
+				if rec.Code != http.StatusBadRequest {
+					t.Errorf("status %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"synergylabs/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// publicMaxAge lets browsers and proxies reuse a public document as long as it is cached here
+const publicMaxAge = "public, max-age=300"
+
+// PublicJobsFeed serves the newest published jobs in a format, without
+// authentication. The organization query parameter limits them to one
+// organization, by slug.
+func PublicJobsFeed(format services.FeedFormat) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		doc, err := feedService.JobsFeed(c.Request().Context(), format, c.QueryParam("organization"), c.Path())
+		if err != nil {
+			return publicErrorResponse(c, err)
+		}
+		return servePublicDocument(c, doc)
+	}
+}
+
+// PublicJob serves one published job in a format, without authentication
+func PublicJob(format services.FeedFormat) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := strconv.ParseUint(c.Param("job_id"), 10, 64)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid job ID")
+		}
+		doc, err := feedService.JobDocument(c.Request().Context(), uint(id), format)
+		if err != nil {
+			return publicErrorResponse(c, err)
+		}
+		return servePublicDocument(c, doc)
+	}
+}
+
+// servePublicDocument writes a document, or 304 when the client's copy is current
+func servePublicDocument(c echo.Context, doc *services.PublicDocument) error {
+	header := c.Response().Header()
+	header.Set("ETag", doc.ETag)
+	header.Set("Last-Modified", doc.LastModified.UTC().Format(http.TimeFormat))
+	header.Set("Cache-Control", publicMaxAge)
+	if notModified(c.Request(), doc) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.Blob(http.StatusOK, doc.ContentType, doc.Body)
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when there is no
+// If-None-Match (RFC 9110, section 13.2.2). Entity tags compare weakly.
+func notModified(r *http.Request, doc *services.PublicDocument) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == doc.ETag {
+				return true
+			}
+		}
+		return false
+	}
+	if header := r.Header.Get("If-Modified-Since"); header != "" {
+		since, err := http.ParseTime(header)
+		return err == nil && !doc.LastModified.After(since)
+	}
+	return false
+}
+
+func publicErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrJobNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Job not found"})
+	case errors.Is(err, services.ErrOrganizationNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Organization not found"})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
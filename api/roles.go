@@ -0,0 +1,166 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"synergylabs/models"
+	"synergylabs/services"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// roleErrorResponse maps RBAC service errors onto HTTP responses
+func roleErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrRoleNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, services.ErrUnknownPermission), errors.Is(err, services.ErrRoleUserTypeMismatch):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, services.ErrRoleNotGrantable), errors.Is(err, services.ErrPlatformAdminRequired):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	case errors.Is(err, services.ErrRoleExists),
+		errors.Is(err, services.ErrSystemRole),
+		errors.Is(err, services.ErrLastSuperAdmin):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
+
+// ListPermissions lists every permission roles can grant
+func ListPermissions(c echo.Context) error {
+	return c.JSON(http.StatusOK, models.AllPermissions)
+}
+
+// ListRoles lists all roles with their permissions
+func ListRoles(c echo.Context) error {
+	roles, err := rbacService.ListRoles(c.Request().Context())
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, roles)
+}
+
+// CreateRole creates a custom role
+func CreateRole(c echo.Context) error {
+	var roleData struct {
+		Name        string              `json:"name"`
+		Description string              `json:"description"`
+		Permissions []models.Permission `json:"permissions"`
+	}
+	if err := c.Bind(&roleData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	if roleData.Name == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Role name is required"})
+	}
+
+	role := models.Role{
+		Name:        roleData.Name,
+		Description: roleData.Description,
+		Permissions: roleData.Permissions,
+	}
+	if err := rbacService.CreateRole(c.Request().Context(), &role); err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, role)
+}
+
+// UpdateRole replaces a custom role's description and permissions
+func UpdateRole(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("role_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid role ID")
+	}
+
+	var roleData struct {
+		Description string              `json:"description"`
+		Permissions []models.Permission `json:"permissions"`
+	}
+	if err := c.Bind(&roleData); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	role := models.Role{
+		Description: roleData.Description,
+		Permissions: roleData.Permissions,
+	}
+	role.ID = uint(id)
+	if err := rbacService.UpdateRole(c.Request().Context(), &role); err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole deletes a custom role and removes it from its holders
+func DeleteRole(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("role_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid role ID")
+	}
+
+	if err := rbacService.DeleteRole(c.Request().Context(), uint(id)); err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Role deleted successfully"})
+}
+
+// GetUserRoles lists the roles assigned to a user
+func GetUserRoles(c echo.Context) error {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	roles, err := rbacService.GetUserRoles(c.Request().Context(), uint(userID))
+	if err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, roles)
+}
+
+// AssignRole gives a user a role
+func AssignRole(c echo.Context) error {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid user ID")
+	}
+
+	var assignData struct {
+		RoleID uint `json:"role_id"`
+	}
+	if err := c.Bind(&assignData); err != nil || assignData.RoleID == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Role ID is required"})
+	}
+
+	if err := rbacService.AssignRole(c.Request().Context(), uint(userID), assignData.RoleID); err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Role assigned successfully"})
+}
+
+// RemoveRole takes a role away from a user
+func RemoveRole(c echo.Context) error {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid user ID")
+	}
+	roleID, err := strconv.ParseUint(c.Param("role_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid role ID")
+	}
+
+	if err := rbacService.RemoveRole(c.Request().Context(), uint(userID), uint(roleID)); err != nil {
+		return roleErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Role removed successfully"})
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"synergylabs/services"
+	"synergylabs/util"
+
+	"github.com/labstack/echo/v4"
+)
+
+// clientInfo describes the device making the request
+func clientInfo(c echo.Context) services.ClientInfo {
+	return services.ClientInfo{
+		UserAgent: c.Request().UserAgent(),
+		IP:        c.RealIP(),
+	}
+}
+
+// ListSessions lists the devices the caller is logged in on
+func ListSessions(c echo.Context) error {
+	claims := c.Get("claims").(*util.Claims)
+	sessions, err := tokenService.ListSessions(c.Request().Context(), claims.UserId, claims.Family)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession signs one of the caller's devices out
+func RevokeSession(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("session_id"), 10, 64)
+	if err != nil {
+		return c.String(http.StatusBadRequest, "Invalid session ID")
+	}
+
+	if err := tokenService.RevokeSession(c.Request().Context(), c.Get("userId").(uint), uint(id)); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Session revoked successfully"})
+}
+
+// RevokeAllSessions signs the caller out on every device, including this one
+func RevokeAllSessions(c echo.Context) error {
+	if err := tokenService.RevokeAllSessions(c.Request().Context(), c.Get("userId").(uint)); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Could not sign out everywhere"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "Signed out of all sessions"})
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"synergylabs/services"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ssoErrorResponse maps SSO service errors onto HTTP responses
+func ssoErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidSSOState), errors.Is(err, services.ErrSSOFailed):
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	case errors.Is(err, services.ErrSSOEmailNotVerified),
+		errors.Is(err, services.ErrSSOAccountNotFound),
+		errors.Is(err, services.ErrNotStaff):
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	case errors.Is(err, services.ErrEmailExists):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
+
+// ssoStateCookie binds a single sign-on login to the browser that started it
+const ssoStateCookie = "sso_state"
+
+// setSSOStateCookie stores the login state for the callback; a zero maxAge removes it
+func setSSOStateCookie(c echo.Context, state string, maxAge time.Duration) {
+	cookie := &http.Cookie{
+		Name:     ssoStateCookie,
+		Value:    state,
+		Path:     "/sso/oidc",
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		// Lax still sends the cookie on the provider's top-level redirect back to us
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(maxAge.Seconds()),
+	}
+	if maxAge <= 0 {
+		cookie.MaxAge = -1
+	}
+	c.SetCookie(cookie)
+}
+
+// SSOLogin redirects to the identity provider to start a single sign-on login
+func SSOLogin(c echo.Context) error {
+	authURL, state, err := ssoService.BeginLogin(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": "Identity provider is unavailable"})
+	}
+
+	setSSOStateCookie(c, state, services.SSOStateTTL)
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+// SSOCallback completes a single sign-on login and answers it like /login
+func SSOCallback(c echo.Context) error {
+	var browserState string
+	if cookie, err := c.Cookie(ssoStateCookie); err == nil {
+		browserState = cookie.Value
+	}
+	setSSOStateCookie(c, "", 0)
+
+	if providerErr := c.QueryParam("error"); providerErr != "" {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Identity provider refused the login: " + providerErr})
+	}
+
+	code, state := c.QueryParam("code"), c.QueryParam("state")
+	if code == "" || state == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Code and state are required"})
+	}
+
+	user, err := ssoService.CompleteLogin(c.Request().Context(), code, state, browserState)
+	if err != nil {
+		return ssoErrorResponse(c, err)
+	}
+
+	// SSO logins get the same second factor checks as password logins
+	return finishLogin(c, user)
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"synergylabs/models"
+	"synergylabs/services"
+	"synergylabs/util"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func TestSSOCallbackRequiresStateCookie(t *testing.T) {
+	ssoService = *services.NewSSOService(nil, nil, nil, zap.NewNop())
+
+	for _, cookie := range []string{"", "state-of-another-login"} {
+		req := httptest.NewRequest(http.MethodGet, "/sso/oidc/callback?code=code&state=state", nil)
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: ssoStateCookie, Value: cookie})
+		}
+		rec := httptest.NewRecorder()
+		if err := SSOCallback(echo.New().NewContext(req, rec)); err != nil {
+			t.Fatalf("SSOCallback: %v", err)
+		}
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("cookie %q: status = %d, want %d", cookie, rec.Code, http.StatusUnauthorized)
+		}
+		// The cookie is cleared whatever the outcome, so it cannot be reused
+		if cleared := rec.Result().Cookies(); len(cleared) != 1 || cleared[0].Name != ssoStateCookie || cleared[0].MaxAge >= 0 {
+			t.Errorf("cookie %q: response cookies = %v, want %s cleared", cookie, cleared, ssoStateCookie)
+		}
+	}
+}
+
+func TestFinishLoginRequiresSecondFactor(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating signing key: %v", err)
+	}
+	keyring, err := util.NewKeyring("test", &util.SigningKey{ID: "test", Method: util.EdDSA, PrivateKey: private, PublicKey: public})
+	if err != nil {
+		t.Fatalf("building keyring: %v", err)
+	}
+	util.SetKeyring(keyring)
+
+	enabled := time.Now()
+	tests := []struct {
+		name     string
+		user     models.User
+		required bool
+		want     string
+	}{
+		{"second factor enabled", models.User{Model: gorm.Model{ID: 1}, UserType: models.UserTypeAdmin, MFAEnabledAt: &enabled}, false, "mfa_required"},
+		{"second factor required for staff", models.User{Model: gorm.Model{ID: 2}, UserType: models.UserTypeAdmin}, true, "mfa_enrollment_required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mfaService = *services.NewMFAService(nil, nil, zap.NewNop(), "test", tt.required)
+			rec := httptest.NewRecorder()
+			if err := finishLogin(echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec), &tt.user); err != nil {
+				t.Fatalf("finishLogin: %v", err)
+			}
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if body[tt.want] != true || body["mfa_token"] == nil || body["access_token"] != nil {
+				t.Errorf("response = %v, want %s with only a pending MFA token", body, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"synergylabs/models"
+	"synergylabs/services/scheduler"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var taskScheduler *scheduler.Scheduler
+
+// Background task names
+const (
+	taskJobSchedules  = "job-schedules"
+	taskWarmJobsCache = "warm-jobs-cache"
+	taskPurgeExpired  = "purge-expired"
+)
+
+const (
+	// Emailed tokens and sessions are purged a day after they expire
+	expiredTokenRetention = 24 * time.Hour
+	// Unaccepted invitations stay listed as expired or revoked for a month
+	invitationRetention = 30 * 24 * time.Hour
+	taskRunRetention    = 30 * 24 * time.Hour
+
+	defaultTaskRunsLimit = 50
+)
+
+// StartBackgroundTasks runs the scheduled tasks until ctx is done. Call it
+// after SetupRoutes.
+func StartBackgroundTasks(ctx context.Context) {
+	taskScheduler.Start(ctx)
+}
+
+// WaitForBackgroundWork blocks until the task runs in progress when the
+// context passed to StartBackgroundTasks was cancelled have finished and
+// every email queued in the background has been handed to the mailer
+func WaitForBackgroundWork() {
+	taskScheduler.Wait()
+	accountService.Wait()
+}
+
+// registerTasks adds the background tasks to the scheduler
+func registerTasks(s *scheduler.Scheduler) error {
+	tasks := []scheduler.Task{
+		{
+			// Publishes drafts whose publish_at has passed and closes jobs past closes_at
+			Name:        taskJobSchedules,
+			Schedule:    "* * * * *",
+			Timeout:     time.Minute,
+			MaxAttempts: 3,
+			RetryDelay:  5 * time.Second,
+			Run: func(ctx context.Context) (string, error) {
+				changed, err := jobService.ApplyScheduledTransitions(ctx)
+				if changed > 0 {
+					warmJobsCache()
+				}
+				return fmt.Sprintf("%d jobs changed status", changed), err
+			},
+		},
+		{
+			// Also triggered whenever a job is published, since listings were just invalidated
+			Name:        taskWarmJobsCache,
+			Schedule:    "*/5 * * * *",
+			Timeout:     30 * time.Second,
+			MaxAttempts: 2,
+			RetryDelay:  5 * time.Second,
+			Run: func(ctx context.Context) (string, error) {
+				jobs, err := jobService.WarmJobsCache(ctx)
+				return fmt.Sprintf("%d jobs cached", jobs), err
+			},
+		},
+		{
+			Name:        taskPurgeExpired,
+			Schedule:    "30 3 * * *",
+			MaxAttempts: 3,
+			RetryDelay:  time.Minute,
+			Run:         purgeExpired,
+		},
+	}
+
+	for _, task := range tasks {
+		if err := s.Register(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// purgeExpired deletes expired tokens, invitations and sessions, and old task runs
+func purgeExpired(ctx context.Context) (string, error) {
+	now := time.Now()
+	tokens, err := accountService.PurgeExpiredTokens(ctx, now.Add(-expiredTokenRetention))
+	if err != nil {
+		return "", err
+	}
+	sessions, err := tokenService.PurgeExpiredSessions(ctx, now.Add(-expiredTokenRetention))
+	if err != nil {
+		return "", err
+	}
+	invitations, err := invitationService.PurgeExpiredInvitations(ctx, now.Add(-invitationRetention))
+	if err != nil {
+		return "", err
+	}
+	runs, err := taskScheduler.PruneRuns(ctx, now.Add(-taskRunRetention))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("purged %d tokens, %d sessions, %d invitations and %d task runs", tokens, sessions, invitations, runs), nil
+}
+
+// warmJobsCache refills the cached job listing in the background. The task is
+// always registered, so the trigger cannot fail.
+func warmJobsCache() {
+	taskScheduler.Trigger(taskWarmJobsCache, models.TaskTriggerEvent)
+}
+
+// ListTasks lists the background tasks with their schedules and latest runs
+func ListTasks(c echo.Context) error {
+	tasks, err := taskScheduler.Tasks(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, tasks)
+}
+
+// ListTaskRuns returns the run history of a task, newest first
+func ListTaskRuns(c echo.Context) error {
+	limit := defaultTaskRunsLimit
+	if value := c.QueryParam("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "limit must be a positive integer"})
+		}
+		if limit > maxPageSize {
+			limit = maxPageSize
+		}
+	}
+
+	runs, err := taskScheduler.Runs(c.Request().Context(), c.Param("name"), limit)
+	if err != nil {
+		return taskErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, runs)
+}
+
+// RunTask starts a run of a task outside its schedule
+func RunTask(c echo.Context) error {
+	if err := taskScheduler.Trigger(c.Param("name"), models.TaskTriggerManual); err != nil {
+		return taskErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{"message": "Task run requested successfully"})
+}
+
+func taskErrorResponse(c echo.Context, err error) error {
+	if errors.Is(err, scheduler.ErrUnknownTask) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Task not found"})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
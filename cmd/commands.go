@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"synergylabs/config"
+	"synergylabs/db"
+	"synergylabs/models"
+	"synergylabs/services"
+	"synergylabs/services/cache"
+	"synergylabs/util"
+
+	"go.uber.org/zap"
+)
+
+// runCommand runs a command-line subcommand instead of the server and
+// returns the process exit code
+func runCommand(cfg *config.Config, logger *zap.Logger, name string, args []string) int {
+	var run func(*config.Config, *zap.Logger, []string) error
+	switch name {
+	case "import-jobs":
+		run = importJobsCommand
+	case "export-jobs":
+		run = exportJobsCommand
+	case "assign-unowned-jobs":
+		run = assignUnownedJobsCommand
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q; the commands are import-jobs, export-jobs and assign-unowned-jobs\n", name)
+		return 2
+	}
+
+	if err := run(cfg, logger, args); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+// importJobsCommand imports jobs from a file or stdin and prints the report
+func importJobsCommand(cfg *config.Config, logger *zap.Logger, args []string) error {
+	flags := flag.NewFlagSet("import-jobs", flag.ContinueOnError)
+	file := flags.String("file", "-", "CSV or JSON Lines file to import, - for stdin")
+	format := flags.String("format", "", "csv or jsonl; taken from the file extension when empty")
+	posterID := flags.Uint("poster", 0, "ID of the staff user recorded as the poster of new jobs (required)")
+	orgID := flags.Uint("org", 0, "organization to import into; 0 for jobs outside any organization")
+	dryRun := flags.Bool("dry-run", false, "validate every row without saving anything")
+	allOrNothing := flags.Bool("all-or-nothing", false, "save nothing if any row fails")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *posterID == 0 {
+		return fmt.Errorf("-poster is required")
+	}
+
+	opts := services.JobImportOptions{PosterID: uint(*posterID), DryRun: *dryRun, AllOrNothing: *allOrNothing}
+	var err error
+	if opts.Format, err = fileFormat(*format, *file); err != nil {
+		return err
+	}
+	input := io.Reader(os.Stdin)
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+
+	ctx := commandContext(uint(*orgID))
+	ctx = util.ContextWithRequestInfo(ctx, util.RequestInfo{ID: "cli", ActorID: opts.PosterID})
+	report, err := newJobService(cfg, logger).ImportJobs(ctx, input, opts)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d rows failed", report.Failed, report.Rows)
+	}
+	return nil
+}
+
+// exportJobsCommand writes jobs to a file or stdout
+func exportJobsCommand(cfg *config.Config, logger *zap.Logger, args []string) error {
+	flags := flag.NewFlagSet("export-jobs", flag.ContinueOnError)
+	file := flags.String("file", "-", "file to write, - for stdout")
+	format := flags.String("format", "", "csv or jsonl; taken from the file extension when empty")
+	orgID := flags.Uint("org", 0, "organization to export; 0 for every job")
+	status := flags.String("status", "", "only export jobs with this status")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *status != "" && !models.JobStatus(*status).Valid() {
+		return fmt.Errorf("unknown status %q", *status)
+	}
+
+	dataFormat, err := fileFormat(*format, *file)
+	if err != nil {
+		return err
+	}
+	output := io.Writer(os.Stdout)
+	if *file != "-" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		output = f
+	}
+
+	exported, err := newJobService(cfg, logger).ExportJobs(commandContext(uint(*orgID)), output, dataFormat, models.JobStatus(*status))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d jobs\n", exported)
+	return nil
+}
+
+// assignUnownedJobsCommand records a poster for jobs created before posters were recorded
+func assignUnownedJobsCommand(cfg *config.Config, logger *zap.Logger, args []string) error {
+	flags := flag.NewFlagSet("assign-unowned-jobs", flag.ContinueOnError)
+	posterID := flags.Uint("poster", 0, "ID of the staff user to record as the poster (required)")
+	orgID := flags.Uint("org", 0, "only assign jobs of this organization; 0 for every job")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *posterID == 0 {
+		return fmt.Errorf("-poster is required")
+	}
+
+	ctx := util.ContextWithRequestInfo(commandContext(uint(*orgID)), util.RequestInfo{ID: "cli"})
+	assigned, err := newJobService(cfg, logger).AssignUnownedJobs(ctx, uint(*posterID))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "assigned %d jobs\n", assigned)
+	return nil
+}
+
+func newJobService(cfg *config.Config, logger *zap.Logger) *services.JobService {
+	return services.NewJobService(db.InitDB(cfg.DatabaseURL), cache.NewCache(cfg.RedisAddr), logger)
+}
+
+// commandContext scopes a command to an organization, like a request from one
+// of its members. Without one the command acts as a platform administrator.
+func commandContext(orgID uint) context.Context {
+	ctx := context.Background()
+	if orgID != 0 {
+		return util.ContextWithOrganization(ctx, orgID)
+	}
+	return util.ContextWithAllOrganizations(ctx)
+}
+
+// fileFormat returns the named format, or the one matching the file's extension
+func fileFormat(format, file string) (services.JobDataFormat, error) {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(file), ".")
+	}
+	if format == "" {
+		return services.JobFormatCSV, nil
+	}
+	return services.ParseJobDataFormat(format)
+}
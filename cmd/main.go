@@ -1,16 +1,30 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	"synergylabs/api"
+	"synergylabs/config"
 	"synergylabs/db"
 	"synergylabs/services/cache"
+	"synergylabs/services/mail"
+	"synergylabs/util"
+	"syscall"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 	"go.uber.org/zap"
 )
 
+// shutdownTimeout is how long requests in flight may take to finish after a
+// shutdown signal
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	// Initialize logger
 	logger, err := zap.NewProduction()
@@ -19,20 +33,78 @@ func main() {
 	}
 	defer logger.Sync()
 
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Subcommands such as import-jobs run instead of the server
+	if len(os.Args) > 1 {
+		code := runCommand(cfg, logger, os.Args[1], os.Args[2:])
+		logger.Sync()
+		os.Exit(code)
+	}
+
+	// Load JWT signing keys
+	keyring, err := util.LoadKeyring(cfg.JWTKeyDir, cfg.JWTActiveKeyID)
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+	util.SetKeyring(keyring)
+
+	// Load the pagination cursor key; config.Load only lets development leave it unset
+	cursorKey := []byte(cfg.CursorSecret)
+	if len(cursorKey) == 0 {
+		logger.Warn("CURSOR_SECRET is not set in development, pagination cursors only work on this instance until it restarts")
+		if cursorKey, err = util.NewCursorKey(); err != nil {
+			log.Fatalf("Failed to generate cursor key: %v", err)
+		}
+	}
+	util.SetCursorKey(cursorKey)
+
 	// Initialize database
-	dsn := os.Getenv("DATABASE_URL") // Set your database URL in environment variable
-	database := db.InitDB(dsn)
+	database := db.InitDB(cfg.DatabaseURL)
 
 	// Initialize Redis cache
-	redisAddr := os.Getenv("REDIS_ADDR") // Set your Redis address in environment variable
-	redisCache := cache.NewCache(redisAddr)
+	redisCache := cache.NewCache(cfg.RedisAddr)
+
+	// Initialize mail delivery; config.Load only lets development leave SMTP_HOST unset
+	var mailer mail.Sender
+	if cfg.SMTPHost != "" {
+		mailer = mail.NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	} else {
+		logger.Warn("SMTP_HOST is not set in development, outgoing mail is kept in memory and not delivered")
+		mailer = mail.NewMemorySender()
+	}
 
 	// Initialize Echo framework
 	e := echo.New()
+	e.IPExtractor = util.IPExtractor(cfg.TrustedProxies)
+	e.Use(middleware.RequestID())
+	e.Use(util.RequestContext)
 
 	// Set up API routes
-	api.SetupRoutes(e, database, redisCache, logger)
+	api.SetupRoutes(e, cfg, database, redisCache, mailer, logger)
+
+	// SIGINT and SIGTERM stop the server and the background tasks
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	api.StartBackgroundTasks(ctx)
 
 	// Start the server
-	e.Logger.Fatal(e.Start(":3000")) // Change the port as needed
+	go func() {
+		if err := e.Start(":3000"); err != nil && !errors.Is(err, http.ErrServerClosed) { // Change the port as needed
+			logger.Fatal("Server stopped", zap.Error(err))
+		}
+	}()
+
+	<-ctx.Done()
+	logger.Info("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Failed to shut down the server", zap.Error(err))
+	}
+	api.WaitForBackgroundWork()
 }
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// EnvDevelopment is the APP_ENV of local setups, which may leave secrets unset
+const EnvDevelopment = "development"
+
+// Config holds the settings read from the environment at startup
+type Config struct {
+	// Environment is APP_ENV; anything other than development requires every secret
+	Environment string
+
+	DatabaseURL string
+	RedisAddr   string
+
+	// JWTKeyDir contains one PEM file per signing key, named <kid>.pem
+	JWTKeyDir string
+	// JWTActiveKeyID selects the key new tokens are signed with
+	JWTActiveKeyID string
+
+	// AppBaseURL is the front-end address used for links in emails
+	AppBaseURL string
+	// RequireEmailVerification blocks login until the account's email is verified
+	RequireEmailVerification bool
+
+	// MFARequiredForAdmins forces admins to enroll TOTP before they can log in
+	MFARequiredForAdmins bool
+	// TOTPIssuer names the service in authenticator apps
+	TOTPIssuer string
+
+	// SMTPHost is the relay outgoing mail is delivered through; only
+	// development may leave it empty, and then mail is only kept in memory
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
+
+	// OIDCIssuerURL enables single sign-on through an OpenID Connect provider
+	OIDCIssuerURL    string
+	OIDCClientID     string
+	OIDCClientSecret string
+	// OIDCRedirectURL must point at /sso/oidc/callback and be registered with the provider
+	OIDCRedirectURL string
+
+	// CursorSecret signs pagination cursors; only development may leave it
+	// empty, and then a random key is used per process
+	CursorSecret string
+
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For header is
+	// believed. When empty the client IP is the connection's peer address and
+	// forwarding headers are ignored.
+	TrustedProxies []*net.IPNet
+
+	// PublicAPIURL is the address of this API as seen by feed readers
+	PublicAPIURL string
+	// FeedTitle names the public job feeds
+	FeedTitle string
+}
+
+// Load reads the configuration from environment variables
+func Load() (*Config, error) {
+	cfg := &Config{
+		Environment:    getEnv("APP_ENV", EnvDevelopment),
+		DatabaseURL:    os.Getenv("DATABASE_URL"),
+		RedisAddr:      os.Getenv("REDIS_ADDR"),
+		JWTKeyDir:      os.Getenv("JWT_KEY_DIR"),
+		JWTActiveKeyID: os.Getenv("JWT_ACTIVE_KID"),
+		AppBaseURL:     getEnv("APP_BASE_URL", "http://localhost:3000"),
+		SMTPHost:       os.Getenv("SMTP_HOST"),
+		SMTPUsername:   os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:   os.Getenv("SMTP_PASSWORD"),
+		MailFrom:       getEnv("MAIL_FROM", "no-reply@synergylabs.local"),
+		TOTPIssuer:     getEnv("TOTP_ISSUER", "Synergy Labs"),
+
+		OIDCIssuerURL:    os.Getenv("OIDC_ISSUER_URL"),
+		OIDCClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		OIDCClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:  getEnv("OIDC_REDIRECT_URL", "http://localhost:3000/sso/oidc/callback"),
+
+		CursorSecret: os.Getenv("CURSOR_SECRET"),
+
+		PublicAPIURL: getEnv("PUBLIC_API_URL", "http://localhost:3000"),
+		FeedTitle:    getEnv("FEED_TITLE", "Synergy Labs jobs"),
+	}
+
+	if cfg.JWTKeyDir == "" {
+		return nil, errors.New("JWT_KEY_DIR must be set")
+	}
+	if cfg.OIDCIssuerURL != "" && cfg.OIDCClientID == "" {
+		return nil, errors.New("OIDC_CLIENT_ID must be set when OIDC_ISSUER_URL is")
+	}
+	if cfg.CursorSecret == "" && cfg.Environment != EnvDevelopment {
+		return nil, errors.New("CURSOR_SECRET must be set outside development")
+	}
+	if cfg.SMTPHost == "" && cfg.Environment != EnvDevelopment {
+		return nil, errors.New("SMTP_HOST must be set outside development")
+	}
+
+	var err error
+	if cfg.TrustedProxies, err = getEnvNetworks("TRUSTED_PROXIES"); err != nil {
+		return nil, err
+	}
+	if cfg.SMTPPort, err = getEnvInt("SMTP_PORT", 587); err != nil {
+		return nil, err
+	}
+	if cfg.RequireEmailVerification, err = getEnvBool("REQUIRE_EMAIL_VERIFICATION", false); err != nil {
+		return nil, err
+	}
+	if cfg.MFARequiredForAdmins, err = getEnvBool("MFA_REQUIRED_FOR_ADMINS", false); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.New(key + " must be an integer")
+	}
+	return n, nil
+}
+
+func getEnvBool(key string, fallback bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.New(key + " must be a boolean")
+	}
+	return b, nil
+}
+
+// getEnvNetworks parses a comma-separated list of CIDR ranges and single IPs
+func getEnvNetworks(key string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, errors.New(key + " must list IP addresses or CIDR ranges")
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, errors.New(key + " must list IP addresses or CIDR ranges")
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
@@ -0,0 +1,71 @@
+package config
+
+import "testing"
+
+func TestGetEnvNetworks(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", " 10.0.0.0/8, 192.168.1.10 ,,2001:db8::1 ")
+	networks, err := getEnvNetworks("TRUSTED_PROXIES")
+	if err != nil {
+		t.Fatalf("getEnvNetworks: %v", err)
+	}
+	want := []string{"10.0.0.0/8", "192.168.1.10/32", "2001:db8::1/128"}
+	if len(networks) != len(want) {
+		t.Fatalf("got %v, want %v", networks, want)
+	}
+	for i, network := range networks {
+		if network.String() != want[i] {
+			t.Errorf("network %d = %s, want %s", i, network, want[i])
+		}
+	}
+
+	for _, invalid := range []string{"10.0.0.0/33", "proxy.internal", "10.0.0"} {
+		t.Setenv("TRUSTED_PROXIES", invalid)
+		if _, err := getEnvNetworks("TRUSTED_PROXIES"); err == nil {
+			t.Errorf("%q was accepted", invalid)
+		}
+	}
+}
+
+func TestLoadRequiresCursorSecretOutsideDevelopment(t *testing.T) {
+	t.Setenv("JWT_KEY_DIR", t.TempDir())
+	t.Setenv("CURSOR_SECRET", "")
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+
+	for _, env := range []string{"", EnvDevelopment} {
+		t.Setenv("APP_ENV", env)
+		if _, err := Load(); err != nil {
+			t.Errorf("APP_ENV=%q without CURSOR_SECRET: %v", env, err)
+		}
+	}
+
+	t.Setenv("APP_ENV", "production")
+	if _, err := Load(); err == nil {
+		t.Error("production started without CURSOR_SECRET")
+	}
+	t.Setenv("CURSOR_SECRET", "a shared secret")
+	if _, err := Load(); err != nil {
+		t.Errorf("production with CURSOR_SECRET: %v", err)
+	}
+}
+
+func TestLoadRequiresSMTPHostOutsideDevelopment(t *testing.T) {
+	t.Setenv("JWT_KEY_DIR", t.TempDir())
+	t.Setenv("CURSOR_SECRET", "a shared secret")
+	t.Setenv("SMTP_HOST", "")
+
+	for _, env := range []string{"", EnvDevelopment} {
+		t.Setenv("APP_ENV", env)
+		if _, err := Load(); err != nil {
+			t.Errorf("APP_ENV=%q without SMTP_HOST: %v", env, err)
+		}
+	}
+
+	t.Setenv("APP_ENV", "production")
+	if _, err := Load(); err == nil {
+		t.Error("production started without SMTP_HOST")
+	}
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	if _, err := Load(); err != nil {
+		t.Errorf("production with SMTP_HOST: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package db
+
+import "gorm.io/gorm"
+
+// ProtectAuditLog installs triggers that make audit_events append-only, so
+// even a bug or a compromised account using the application's database user
+// cannot rewrite history
+func ProtectAuditLog(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+			BEGIN
+				RAISE EXCEPTION 'audit_events is append-only';
+			END;
+			$$ LANGUAGE plpgsql`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`DROP TRIGGER IF EXISTS audit_events_no_update ON audit_events`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`
+			CREATE TRIGGER audit_events_no_update
+			BEFORE UPDATE OR DELETE ON audit_events
+			FOR EACH ROW EXECUTE FUNCTION audit_events_append_only()`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`DROP TRIGGER IF EXISTS audit_events_no_truncate ON audit_events`).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`
+			CREATE TRIGGER audit_events_no_truncate
+			BEFORE TRUNCATE ON audit_events
+			FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only()`).Error
+	})
+}
@@ -1,7 +1,9 @@
 package db
 
 import (
+	"fmt"
 	"log"
+	"strings"
 	"synergylabs/models"
 	"time"
 
@@ -15,7 +17,7 @@ func InitDB(dsn string) *gorm.DB {
 
 	// Retry loop to wait for DB readiness
 	for i := 0; i < 5; i++ {
-		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 		if err == nil {
 			log.Println("Connected to the database successfully!")
 			break
@@ -29,12 +31,96 @@ func InitDB(dsn string) *gorm.DB {
 		log.Fatalf("Failed to connect to database after multiple attempts: %v", err)
 	}
 
-	// Auto-migrate models
-	err = db.AutoMigrate(&models.User{}, &models.Job{}, &models.Profile{})
-	if err != nil {
+	if err := Migrate(db); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 	log.Println("Migration Successful")
 
 	return db
 }
+
+// Migrate brings the schema up to date and seeds the built-in roles
+func Migrate(db *gorm.DB) error {
+	// Accounts from before email verification signed in without verifying
+	backfillVerified := !db.Migrator().HasColumn(&models.User{}, "EmailVerifiedAt")
+	// Users from before roles get the role of their user type, once: later
+	// users without roles had them removed on purpose
+	backfillRoles := !db.Migrator().HasTable("user_roles")
+
+	err := db.AutoMigrate(
+		&models.User{},
+		&models.Job{},
+		// Adds the status and answers columns to Job's join table with applicants
+		&models.JobApplication{},
+		&models.ScreeningQuestion{},
+		&models.JobStatusTransition{},
+		&models.JobTemplate{},
+		&models.Profile{},
+		&models.Invitation{},
+		&models.UserToken{},
+		&models.MFARecoveryCode{},
+		&models.Role{},
+		&models.Organization{},
+		&models.APIKey{},
+		&models.UserIdentity{},
+		&models.Session{},
+		&models.AuditEvent{},
+		&models.TaskRun{},
+	)
+	if err != nil {
+		return err
+	}
+
+	if backfillVerified {
+		if err := db.Model(&models.User{}).Unscoped().
+			Where("email_verified_at IS NULL").
+			Update("email_verified_at", gorm.Expr("created_at")).Error; err != nil {
+			return fmt.Errorf("backfilling verified emails: %w", err)
+		}
+	}
+
+	if err := normalizeUserEmails(db); err != nil {
+		return fmt.Errorf("normalizing user emails: %w", err)
+	}
+	if err := SetupJobSearch(db); err != nil {
+		return fmt.Errorf("setting up job search: %w", err)
+	}
+	if err := ProtectAuditLog(db); err != nil {
+		return fmt.Errorf("protecting audit log: %w", err)
+	}
+	if err := SeedRoles(db, backfillRoles); err != nil {
+		return fmt.Errorf("seeding roles: %w", err)
+	}
+	return nil
+}
+
+// normalizeUserEmails lower-cases the stored emails and makes them unique
+// whatever their case. It refuses to run while two accounts differ only in the
+// case of their email, since which one to keep is for an operator to decide.
+func normalizeUserEmails(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var duplicates []string
+		if err := tx.Raw(`SELECT LOWER(TRIM(email)) FROM users
+			WHERE email IS NOT NULL
+			GROUP BY 1 HAVING COUNT(*) > 1`).Scan(&duplicates).Error; err != nil {
+			return err
+		}
+		if len(duplicates) > 0 {
+			return fmt.Errorf("several accounts use %s in different cases; merge or rename them first", strings.Join(duplicates, ", "))
+		}
+
+		statements := []string{
+			`UPDATE users SET email = LOWER(TRIM(email)) WHERE email <> LOWER(TRIM(email))`,
+			// The case-sensitive constraint the email column used to have
+			`ALTER TABLE users DROP CONSTRAINT IF EXISTS uni_users_email`,
+			`DROP INDEX IF EXISTS idx_users_email`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (LOWER(email))`,
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,31 @@
+package db
+
+import "gorm.io/gorm"
+
+// SetupJobSearch adds the full-text search column and indexes on jobs. The
+// search_vector column is generated by Postgres from the title, company and
+// description, weighted in that order, so it never drifts from the row.
+// Trigram indexes serve ILIKE filters on title and company and the
+// did-you-mean suggestions.
+func SetupJobSearch(db *gorm.DB) error {
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (
+				setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+				setweight(to_tsvector('english', coalesce(company_name, '')), 'B') ||
+				setweight(to_tsvector('english', coalesce(description, '')), 'C')
+			) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_search_vector ON jobs USING GIN (search_vector)`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_title_trgm ON jobs USING GIN (title gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_jobs_company_name_trgm ON jobs USING GIN (company_name gin_trgm_ops)`,
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
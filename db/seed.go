@@ -0,0 +1,43 @@
+package db
+
+import (
+	"synergylabs/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SeedRoles creates the built-in roles, keeping their permissions current.
+// With backfillUsers, which Migrate only sets when it creates user_roles, it
+// also gives users that predate roles the role equivalent to their user type.
+func SeedRoles(db *gorm.DB, backfillUsers bool) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, role := range models.SystemRoles {
+			role := role
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "name"}},
+				DoUpdates: clause.AssignmentColumns([]string{"description", "permissions", "system", "updated_at"}),
+			}).Create(&role).Error; err != nil {
+				return err
+			}
+		}
+
+		if !backfillUsers {
+			return nil
+		}
+		for _, userType := range []models.UserType{models.UserTypeAdmin, models.UserTypeApplicant} {
+			if err := tx.Exec(`
+				INSERT INTO user_roles (user_id, role_id)
+				SELECT users.id, roles.id FROM users
+				JOIN roles ON roles.name = ?
+				WHERE users.user_type = ?
+				AND NOT EXISTS (SELECT 1 FROM user_roles WHERE user_roles.user_id = users.id)`,
+				models.DefaultRoleFor(userType), userType,
+			).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey authenticates machine-to-machine requests on behalf of its owner.
+// Prefix identifies the key in logs and lookups; only the key's hash is stored.
+type APIKey struct {
+	gorm.Model
+	UserID     uint         `json:"user_id" gorm:"index"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix" gorm:"uniqueIndex"`
+	KeyHash    string       `json:"-"`
+	Scopes     []Permission `json:"scopes" gorm:"serializer:json"`
+	ExpiresAt  *time.Time   `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time   `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time   `json:"revoked_at,omitempty"`
+}
+
+// Active reports whether the key can still be used
+func (k *APIKey) Active() bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt))
+}
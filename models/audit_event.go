@@ -0,0 +1,125 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// AuditEvent records an action taken through the API. Events are only ever
+// inserted, and each one includes the hash of the one before it in its chain,
+// so editing or deleting an event breaks the chain.
+type AuditEvent struct {
+	ID        uint      `json:"id" gorm:"primarykey;index:idx_audit_events_chain,priority:2"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	// ActorID is the person who acted; for impersonated requests the admin
+	ActorID *uint `json:"actor_id,omitempty" gorm:"index"`
+	// SubjectUserID is the user an impersonating admin acted as
+	SubjectUserID *uint `json:"subject_user_id,omitempty" gorm:"index"`
+	// OrganizationID is the organization the actor was acting in
+	OrganizationID *uint                  `json:"organization_id,omitempty" gorm:"index"`
+	Action         string                 `json:"action" gorm:"index"`
+	EntityType     string                 `json:"entity_type,omitempty" gorm:"index:idx_audit_events_entity"`
+	EntityID       string                 `json:"entity_id,omitempty" gorm:"index:idx_audit_events_entity"`
+	Changes        map[string]AuditChange `json:"changes,omitempty" gorm:"serializer:json"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty" gorm:"serializer:json"`
+	RequestID      string                 `json:"request_id,omitempty"`
+	IP             string                 `json:"ip,omitempty"`
+	// Chain names the hash chain the event belongs to; events from before
+	// chains were split by organization and entity type have none
+	Chain    string `json:"chain,omitempty" gorm:"not null;default:'';index:idx_audit_events_chain,priority:1"`
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash" gorm:"uniqueIndex"`
+}
+
+// AuditChange is the value of a field before and after a change
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// ComputeHash hashes the event's content together with PrevHash
+func (e *AuditEvent) ComputeHash() (string, error) {
+	content, err := json.Marshal(struct {
+		PrevHash       string                 `json:"prev_hash"`
+		Chain          string                 `json:"chain,omitempty"`
+		CreatedAt      string                 `json:"created_at"`
+		ActorID        *uint                  `json:"actor_id"`
+		SubjectUserID  *uint                  `json:"subject_user_id"`
+		OrganizationID *uint                  `json:"organization_id"`
+		Action         string                 `json:"action"`
+		EntityType     string                 `json:"entity_type"`
+		EntityID       string                 `json:"entity_id"`
+		Changes        map[string]AuditChange `json:"changes"`
+		Metadata       map[string]interface{} `json:"metadata"`
+		RequestID      string                 `json:"request_id"`
+		IP             string                 `json:"ip"`
+	}{
+		PrevHash:       e.PrevHash,
+		Chain:          e.Chain,
+		CreatedAt:      e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		ActorID:        e.ActorID,
+		SubjectUserID:  e.SubjectUserID,
+		OrganizationID: e.OrganizationID,
+		Action:         e.Action,
+		EntityType:     e.EntityType,
+		EntityID:       e.EntityID,
+		Changes:        e.Changes,
+		Metadata:       e.Metadata,
+		RequestID:      e.RequestID,
+		IP:             e.IP,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+const (
+	AuditActionImpersonationStart   = "impersonation.start"
+	AuditActionImpersonationRequest = "impersonation.request"
+
+	AuditActionUserCreate = "user.create"
+	AuditActionUserUpdate = "user.update"
+	AuditActionUserDelete = "user.delete"
+	AuditActionUserUnlock = "user.unlock"
+
+	AuditActionPasswordReset  = "user.password_reset"
+	AuditActionPasswordChange = "user.password_change"
+	AuditActionAccountClose   = "user.close"
+	AuditActionEmailVerify    = "user.email_verify"
+	AuditActionMFAEnable      = "user.mfa_enable"
+	AuditActionMFADisable     = "user.mfa_disable"
+	AuditActionProfileUpdate  = "profile.update"
+
+	AuditActionJobCreate    = "job.create"
+	AuditActionJobUpdate    = "job.update"
+	AuditActionJobDelete    = "job.delete"
+	AuditActionJobStatus    = "job.status"
+	AuditActionJobQuestions = "job.questions"
+	AuditActionApplication  = "job.apply"
+
+	AuditActionJobTemplateCreate = "job_template.create"
+	AuditActionJobTemplateUpdate = "job_template.update"
+	AuditActionJobTemplateDelete = "job_template.delete"
+
+	AuditActionRoleCreate = "role.create"
+	AuditActionRoleUpdate = "role.update"
+	AuditActionRoleDelete = "role.delete"
+	AuditActionRoleAssign = "role.assign"
+	AuditActionRoleRemove = "role.remove"
+
+	AuditActionOrganizationCreate   = "organization.create"
+	AuditActionOrganizationSettings = "organization.settings"
+	AuditActionMemberAdd            = "organization.member_add"
+	AuditActionMemberRemove         = "organization.member_remove"
+
+	AuditActionInvitationCreate = "invitation.create"
+	AuditActionInvitationRevoke = "invitation.revoke"
+	AuditActionInvitationAccept = "invitation.accept"
+
+	AuditActionAPIKeyCreate = "api_key.create"
+	AuditActionAPIKeyRevoke = "api_key.revoke"
+)
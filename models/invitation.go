@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type InvitationStatus string
+
+const (
+	InvitationStatusPending  InvitationStatus = "PENDING"
+	InvitationStatusAccepted InvitationStatus = "ACCEPTED"
+	InvitationStatusRevoked  InvitationStatus = "REVOKED"
+	InvitationStatusExpired  InvitationStatus = "EXPIRED"
+)
+
+type Invitation struct {
+	gorm.Model
+	Email          string     `json:"email" gorm:"index"`
+	Role           string     `json:"role"`
+	TokenHash      string     `json:"-" gorm:"uniqueIndex"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	InvitedByID    uint       `json:"invited_by_id"`
+	OrganizationID *uint      `json:"organization_id,omitempty" gorm:"index"`
+	AcceptedAt     *time.Time `json:"accepted_at,omitempty"`
+	AcceptedUserID *uint      `json:"accepted_user_id,omitempty"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Status derives the invitation state from its timestamps
+func (i *Invitation) Status() InvitationStatus {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationStatusAccepted
+	case i.RevokedAt != nil:
+		return InvitationStatusRevoked
+	case time.Now().After(i.ExpiresAt):
+		return InvitationStatusExpired
+	default:
+		return InvitationStatusPending
+	}
+}
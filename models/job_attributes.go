@@ -0,0 +1,77 @@
+package models
+
+import "slices"
+
+type RemotePolicy string
+
+const (
+	RemotePolicyOnsite RemotePolicy = "ONSITE"
+	RemotePolicyHybrid RemotePolicy = "HYBRID"
+	RemotePolicyRemote RemotePolicy = "REMOTE"
+)
+
+// Valid reports whether p is one of the known remote policies
+func (p RemotePolicy) Valid() bool {
+	return p == RemotePolicyOnsite || p == RemotePolicyHybrid || p == RemotePolicyRemote
+}
+
+type EmploymentType string
+
+const (
+	EmploymentFullTime   EmploymentType = "FULL_TIME"
+	EmploymentPartTime   EmploymentType = "PART_TIME"
+	EmploymentContract   EmploymentType = "CONTRACT"
+	EmploymentTemporary  EmploymentType = "TEMPORARY"
+	EmploymentInternship EmploymentType = "INTERNSHIP"
+)
+
+// Valid reports whether t is one of the known employment types
+func (t EmploymentType) Valid() bool {
+	switch t {
+	case EmploymentFullTime, EmploymentPartTime, EmploymentContract, EmploymentTemporary, EmploymentInternship:
+		return true
+	}
+	return false
+}
+
+type Seniority string
+
+const (
+	SeniorityIntern    Seniority = "INTERN"
+	SeniorityJunior    Seniority = "JUNIOR"
+	SeniorityMid       Seniority = "MID"
+	SenioritySenior    Seniority = "SENIOR"
+	SeniorityLead      Seniority = "LEAD"
+	SeniorityPrincipal Seniority = "PRINCIPAL"
+	SeniorityExecutive Seniority = "EXECUTIVE"
+)
+
+// Seniorities lists the seniority levels from most junior to most senior
+var Seniorities = []Seniority{
+	SeniorityIntern,
+	SeniorityJunior,
+	SeniorityMid,
+	SenioritySenior,
+	SeniorityLead,
+	SeniorityPrincipal,
+	SeniorityExecutive,
+}
+
+// Valid reports whether s is one of the known seniority levels
+func (s Seniority) Valid() bool {
+	return slices.Contains(Seniorities, s)
+}
+
+type SalaryPeriod string
+
+const (
+	SalaryPerHour  SalaryPeriod = "HOUR"
+	SalaryPerDay   SalaryPeriod = "DAY"
+	SalaryPerMonth SalaryPeriod = "MONTH"
+	SalaryPerYear  SalaryPeriod = "YEAR"
+)
+
+// Valid reports whether p is one of the known salary periods
+func (p SalaryPeriod) Valid() bool {
+	return p == SalaryPerHour || p == SalaryPerDay || p == SalaryPerMonth || p == SalaryPerYear
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	JobStatusDraft     JobStatus = "DRAFT"
+	JobStatusPublished JobStatus = "PUBLISHED"
+	JobStatusPaused    JobStatus = "PAUSED"
+	JobStatusClosed    JobStatus = "CLOSED"
+	JobStatusArchived  JobStatus = "ARCHIVED"
+)
+
+// jobTransitions lists the statuses each status can move to
+var jobTransitions = map[JobStatus][]JobStatus{
+	JobStatusDraft:     {JobStatusPublished, JobStatusArchived},
+	JobStatusPublished: {JobStatusPaused, JobStatusClosed},
+	JobStatusPaused:    {JobStatusPublished, JobStatusClosed},
+	JobStatusClosed:    {JobStatusPublished, JobStatusArchived},
+	JobStatusArchived:  {},
+}
+
+// Valid reports whether s is one of the known job statuses
+func (s JobStatus) Valid() bool {
+	_, ok := jobTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether a job in status s may move to next
+func (s JobStatus) CanTransitionTo(next JobStatus) bool {
+	return slices.Contains(jobTransitions[s], next)
+}
+
+// JobStatusTransition records a change of a job's status. ActorID is nil for
+// changes made by the schedule (PublishAt and ClosesAt).
+type JobStatusTransition struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time `json:"created_at"`
+	JobID      uint      `json:"job_id" gorm:"index"`
+	FromStatus JobStatus `json:"from"`
+	ToStatus   JobStatus `json:"to"`
+	ActorID    *uint     `json:"actor_id,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+}
+
+// Job status transition reasons
+const (
+	JobTransitionManual           = "manual"
+	JobTransitionScheduledPublish = "scheduled_publish"
+	JobTransitionScheduledClose   = "scheduled_close"
+)
+
+// AcceptsApplications reports whether the job is open at time now, taking
+// schedules that have come due but not been applied yet into account
+func (j *Job) AcceptsApplications(now time.Time) bool {
+	if j.ClosesAt != nil && !now.Before(*j.ClosesAt) {
+		return false
+	}
+	switch j.Status {
+	case JobStatusPublished:
+		return true
+	case JobStatusDraft:
+		return j.PublishAt != nil && !now.Before(*j.PublishAt)
+	}
+	return false
+}
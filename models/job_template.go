@@ -0,0 +1,90 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+	"time"
+)
+
+// templatePlaceholder matches {{name}} in a template's text fields
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z][A-Za-z0-9_]*)\s*\}\}`)
+
+// JobTemplate is a reusable job posting. Its text fields may contain
+// placeholders such as {{location}} that are filled in when a job is created
+// from it. Templates belong to the organization of the staff member who
+// created them and their names are unique within it.
+type JobTemplate struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	OrganizationID *uint     `json:"organization_id,omitempty" gorm:"uniqueIndex:idx_job_templates_org_name"`
+	Name           string    `json:"name" gorm:"not null;uniqueIndex:idx_job_templates_org_name"`
+	CreatedByID    uint      `json:"created_by_id"`
+
+	Title           string         `json:"title"`
+	Description     string         `json:"description"`
+	CompanyName     string         `json:"company_name"`
+	LocationCity    string         `json:"location_city,omitempty"`
+	LocationCountry string         `json:"location_country,omitempty" gorm:"size:2"`
+	RemotePolicy    RemotePolicy   `json:"remote_policy,omitempty"`
+	EmploymentType  EmploymentType `json:"employment_type,omitempty"`
+	Seniority       Seniority      `json:"seniority,omitempty"`
+	Department      string         `json:"department,omitempty"`
+	SalaryMin       *int64         `json:"salary_min,omitempty"`
+	SalaryMax       *int64         `json:"salary_max,omitempty"`
+	SalaryCurrency  string         `json:"salary_currency,omitempty" gorm:"size:3"`
+	SalaryPeriod    SalaryPeriod   `json:"salary_period,omitempty"`
+}
+
+// textFields are the fields placeholders are filled in
+func (t *JobTemplate) textFields() []*string {
+	return []*string{&t.Title, &t.Description, &t.CompanyName, &t.LocationCity, &t.Department}
+}
+
+// Variables lists the placeholder names used in the template, sorted
+func (t *JobTemplate) Variables() []string {
+	seen := map[string]bool{}
+	variables := []string{}
+	for _, field := range t.textFields() {
+		for _, match := range templatePlaceholder.FindAllStringSubmatch(*field, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				variables = append(variables, match[1])
+			}
+		}
+	}
+	sort.Strings(variables)
+	return variables
+}
+
+// Render returns a copy of the template with every placeholder replaced by
+// its value in vars. Callers check that vars covers Variables first.
+func (t *JobTemplate) Render(vars map[string]string) JobTemplate {
+	rendered := *t
+	for _, field := range rendered.textFields() {
+		*field = templatePlaceholder.ReplaceAllStringFunc(*field, func(placeholder string) string {
+			return vars[templatePlaceholder.FindStringSubmatch(placeholder)[1]]
+		})
+	}
+	return rendered
+}
+
+// Job returns a job with the template's content. The template must have been
+// rendered first.
+func (t *JobTemplate) Job() *Job {
+	return &Job{
+		Title:           t.Title,
+		Description:     t.Description,
+		CompanyName:     t.CompanyName,
+		LocationCity:    t.LocationCity,
+		LocationCountry: t.LocationCountry,
+		RemotePolicy:    t.RemotePolicy,
+		EmploymentType:  t.EmploymentType,
+		Seniority:       t.Seniority,
+		Department:      t.Department,
+		SalaryMin:       t.SalaryMin,
+		SalaryMax:       t.SalaryMax,
+		SalaryCurrency:  t.SalaryCurrency,
+		SalaryPeriod:    t.SalaryPeriod,
+	}
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MFARecoveryCode is a single-use code that replaces a TOTP code when the
+// authenticator is unavailable. Only its hash is stored.
+type MFARecoveryCode struct {
+	gorm.Model
+	UserID   uint       `json:"user_id" gorm:"index"`
+	CodeHash string     `json:"-"`
+	UsedAt   *time.Time `json:"used_at,omitempty"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type OrganizationSettings struct {
+	// AllowedEmailDomains restricts which email addresses can become members; empty allows any
+	AllowedEmailDomains []string `json:"allowed_email_domains"`
+	CareersPageURL      string   `json:"careers_page_url"`
+
+	// SSOProvisioningDomains lists email domains whose single sign-on users
+	// get an account in this organization on their first login
+	SSOProvisioningDomains []string `json:"sso_provisioning_domains"`
+	// SSODefaultRole is the role given to provisioned users; defaults to recruiter
+	SSODefaultRole string `json:"sso_default_role"`
+}
+
+// AllowsEmail reports whether an address may join the organization
+func (s OrganizationSettings) AllowsEmail(email string) bool {
+	if len(s.AllowedEmailDomains) == 0 {
+		return true
+	}
+	domain := emailDomain(email)
+	if domain == "" {
+		return false
+	}
+	for _, allowed := range s.AllowedEmailDomains {
+		if domain == strings.ToLower(allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProvisionsSSOEmail reports whether a first single sign-on login from email creates an account
+func (s OrganizationSettings) ProvisionsSSOEmail(email string) bool {
+	return s.ProvisionsSSODomain(emailDomain(email))
+}
+
+// ProvisionsSSODomain reports whether domain is one of the provisioning domains
+func (s OrganizationSettings) ProvisionsSSODomain(domain string) bool {
+	for _, d := range s.SSOProvisioningDomains {
+		if domain != "" && strings.ToLower(domain) == strings.ToLower(d) {
+			return true
+		}
+	}
+	return false
+}
+
+// SSORole returns the role for users provisioned through single sign-on
+func (s OrganizationSettings) SSORole() string {
+	if s.SSODefaultRole == "" {
+		return RoleRecruiter
+	}
+	return s.SSODefaultRole
+}
+
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(email[at+1:])
+}
+
+// Organization is a company whose staff, jobs and applicants are isolated from other organizations
+type Organization struct {
+	gorm.Model
+	Name     string               `json:"name"`
+	Slug     string               `json:"slug" gorm:"uniqueIndex"`
+	Settings OrganizationSettings `json:"settings" gorm:"serializer:json"`
+}
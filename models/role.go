@@ -0,0 +1,139 @@
+package models
+
+import (
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+type Permission string
+
+const (
+	PermJobCreate         Permission = "job:create"
+	PermJobRead           Permission = "job:read"
+	PermJobReadOwn        Permission = "job:read:own"
+	PermJobUpdate         Permission = "job:update"
+	PermJobUpdateOwn      Permission = "job:update:own"
+	PermJobDelete         Permission = "job:delete"
+	PermJobDeleteOwn      Permission = "job:delete:own"
+	PermApplicantRead     Permission = "applicant:read"
+	PermApplicationCreate Permission = "application:create"
+	PermApplicationUpdate Permission = "application:update"
+	PermResumeUpload      Permission = "resume:upload"
+	PermUserInvite        Permission = "user:invite"
+	PermUserUnlock        Permission = "user:unlock"
+	PermUserImpersonate   Permission = "user:impersonate"
+	PermRoleManage        Permission = "role:manage"
+	PermOrgCreate         Permission = "organization:create"
+	PermOrgManage         Permission = "organization:manage"
+	PermAuditRead         Permission = "audit:read"
+	PermTaskManage        Permission = "task:manage"
+)
+
+// AllPermissions lists every permission the API checks
+var AllPermissions = []Permission{
+	PermJobCreate,
+	PermJobRead,
+	PermJobReadOwn,
+	PermJobUpdate,
+	PermJobUpdateOwn,
+	PermJobDelete,
+	PermJobDeleteOwn,
+	PermApplicantRead,
+	PermApplicationCreate,
+	PermApplicationUpdate,
+	PermResumeUpload,
+	PermUserInvite,
+	PermUserUnlock,
+	PermUserImpersonate,
+	PermRoleManage,
+	PermOrgCreate,
+	PermOrgManage,
+	PermAuditRead,
+	PermTaskManage,
+}
+
+// Valid reports whether p is a known permission
+func (p Permission) Valid() bool {
+	return slices.Contains(AllPermissions, p)
+}
+
+// Built-in role names
+const (
+	RoleSuperAdmin    = "super_admin"
+	RoleRecruiter     = "recruiter"
+	RoleHiringManager = "hiring_manager"
+	RoleInterviewer   = "interviewer"
+	RoleApplicant     = "applicant"
+	// RoleFormerMember marks staff removed from their organization. It grants
+	// nothing, and gives the account a role so it is never mistaken for one
+	// that predates roles.
+	RoleFormerMember = "former_member"
+)
+
+type Role struct {
+	gorm.Model
+	Name        string       `json:"name" gorm:"uniqueIndex"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions" gorm:"serializer:json"`
+	// System roles are seeded at startup and cannot be deleted
+	System bool `json:"system"`
+}
+
+// SystemRoles are seeded at startup. ADMIN and APPLICANT users created before
+// roles existed are migrated to super_admin and applicant.
+var SystemRoles = []Role{
+	{
+		Name:        RoleSuperAdmin,
+		Description: "Full access, including role management",
+		Permissions: AllPermissions,
+		System:      true,
+	},
+	{
+		Name:        RoleRecruiter,
+		Description: "Manages their job postings and applications",
+		Permissions: []Permission{PermJobCreate, PermJobRead, PermJobUpdateOwn, PermJobDeleteOwn, PermApplicantRead, PermApplicationUpdate},
+		System:      true,
+	},
+	{
+		Name:        RoleHiringManager,
+		Description: "Read-only access to the jobs they manage and their applicants",
+		Permissions: []Permission{PermJobReadOwn},
+		System:      true,
+	},
+	{
+		Name:        RoleInterviewer,
+		Description: "Reads applicant profiles",
+		Permissions: []Permission{PermApplicantRead},
+		System:      true,
+	},
+	{
+		Name:        RoleApplicant,
+		Description: "Uploads resumes and applies to jobs",
+		Permissions: []Permission{PermApplicationCreate, PermResumeUpload},
+		System:      true,
+	},
+	{
+		Name:        RoleFormerMember,
+		Description: "Removed from their organization, with no access",
+		Permissions: []Permission{},
+		System:      true,
+	},
+}
+
+// DefaultRoleFor returns the role a user of the given type starts with
+func DefaultRoleFor(userType UserType) string {
+	if userType == UserTypeApplicant {
+		return RoleApplicant
+	}
+	return RoleSuperAdmin
+}
+
+// UserTypeForRole returns the account type for users holding role. Every
+// role other than applicant belongs to staff accounts.
+func UserTypeForRole(role string) UserType {
+	if role == RoleApplicant {
+		return UserTypeApplicant
+	}
+	return UserTypeAdmin
+}
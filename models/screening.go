@@ -0,0 +1,93 @@
+package models
+
+import "time"
+
+type ScreeningQuestionType string
+
+const (
+	ScreeningYesNo          ScreeningQuestionType = "YES_NO"
+	ScreeningSingleChoice   ScreeningQuestionType = "SINGLE_CHOICE"
+	ScreeningMultipleChoice ScreeningQuestionType = "MULTI_CHOICE"
+	ScreeningNumber         ScreeningQuestionType = "NUMBER"
+	ScreeningText           ScreeningQuestionType = "TEXT"
+)
+
+// Valid reports whether t is one of the known question types
+func (t ScreeningQuestionType) Valid() bool {
+	switch t {
+	case ScreeningYesNo, ScreeningSingleChoice, ScreeningMultipleChoice, ScreeningNumber, ScreeningText:
+		return true
+	}
+	return false
+}
+
+// HasOptions reports whether answers to questions of type t are picked from options
+func (t ScreeningQuestionType) HasOptions() bool {
+	return t == ScreeningSingleChoice || t == ScreeningMultipleChoice
+}
+
+// ScreeningQuestion is a question applicants answer when they apply to a job.
+// Questions are asked in Position order.
+type ScreeningQuestion struct {
+	ID        uint                  `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+	JobID     uint                  `json:"job_id" gorm:"index"`
+	Position  int                   `json:"position"`
+	Prompt    string                `json:"prompt" gorm:"not null"`
+	Type      ScreeningQuestionType `json:"type" gorm:"not null"`
+	// Options are the choices of SINGLE_CHOICE and MULTI_CHOICE questions
+	Options  []string      `json:"options,omitempty" gorm:"serializer:json"`
+	Required bool          `json:"required"`
+	Knockout *KnockoutRule `json:"knockout,omitempty" gorm:"serializer:json"`
+}
+
+// KnockoutRule rejects applications whose answer to a question fails it. Which
+// fields apply depends on the question type.
+type KnockoutRule struct {
+	// Expected is the answer a YES_NO question must have
+	Expected *bool `json:"expected,omitempty"`
+	// Disqualifying options reject an application when chosen
+	Disqualifying []string `json:"disqualifying,omitempty"`
+	// Min and Max bound the answer to a NUMBER question, inclusively
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+type ApplicationStatus string
+
+const (
+	ApplicationSubmitted ApplicationStatus = "SUBMITTED"
+	// ApplicationRejected applications failed a knockout rule
+	ApplicationRejected ApplicationStatus = "REJECTED"
+)
+
+// Valid reports whether s is one of the known application statuses
+func (s ApplicationStatus) Valid() bool {
+	return s == ApplicationSubmitted || s == ApplicationRejected
+}
+
+// JobApplication is a row of the job_applications table behind Job.Applicants,
+// with what the applicant answered. Applications that predate screening
+// questions have no answers and are SUBMITTED.
+type JobApplication struct {
+	JobID     uint              `json:"job_id" gorm:"primaryKey"`
+	UserID    uint              `json:"user_id" gorm:"primaryKey"`
+	User      User              `json:"user" gorm:"foreignKey:UserID"`
+	CreatedAt time.Time         `json:"created_at"`
+	Status    ApplicationStatus `json:"status" gorm:"not null;default:'SUBMITTED';index"`
+	// RejectionReason names the knockout rule a rejected application failed
+	RejectionReason string            `json:"rejection_reason,omitempty"`
+	Answers         []ScreeningAnswer `json:"answers,omitempty" gorm:"serializer:json"`
+}
+
+// ScreeningAnswer is an answer to a screening question. The question is copied
+// so the answer still reads correctly after the question changes.
+type ScreeningAnswer struct {
+	QuestionID uint                  `json:"question_id"`
+	Prompt     string                `json:"prompt"`
+	Type       ScreeningQuestionType `json:"type"`
+	// Answer is a bool, string, list of strings or number, depending on Type
+	Answer     interface{} `json:"answer"`
+	KnockedOut bool        `json:"knocked_out,omitempty"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+type TaskRunStatus string
+
+const (
+	TaskRunRunning   TaskRunStatus = "RUNNING"
+	TaskRunSucceeded TaskRunStatus = "SUCCEEDED"
+	TaskRunFailed    TaskRunStatus = "FAILED"
+)
+
+// Task run triggers
+const (
+	TaskTriggerSchedule = "schedule"
+	TaskTriggerManual   = "manual"
+	TaskTriggerEvent    = "event"
+)
+
+// TaskRun records one run of a scheduled task, including its retries. Runs
+// left RUNNING belong to an instance that stopped before finishing them.
+type TaskRun struct {
+	ID         uint          `json:"id" gorm:"primaryKey"`
+	Task       string        `json:"task" gorm:"index:idx_task_runs_task_started"`
+	Trigger    string        `json:"trigger"`
+	Instance   string        `json:"instance"`
+	StartedAt  time.Time     `json:"started_at" gorm:"index:idx_task_runs_task_started"`
+	FinishedAt *time.Time    `json:"finished_at,omitempty"`
+	Status     TaskRunStatus `json:"status"`
+	Attempts   int           `json:"attempts"`
+	Result     string        `json:"result,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
@@ -13,15 +13,32 @@ const (
 	UserTypeApplicant UserType = "APPLICANT"
 )
 
+// Valid reports whether t is one of the known user types
+func (t UserType) Valid() bool {
+	return t == UserTypeAdmin || t == UserTypeApplicant
+}
+
 type User struct {
 	gorm.Model
-	Name            string   `json:"name"`
-	Email           string   `json:"email" gorm:"unique"`
-	Address         string   `json:"address"`
-	UserType        UserType `json:"user_type"`
-	PasswordHash    string   `json:"password_hash"`
-	ProfileHeadline string   `json:"profile_headline"`
-	Profile         *Profile `json:"profile,omitempty" gorm:"foreignKey:ApplicantID"`
+	Name string `json:"name"`
+	// Email is stored lower-cased and is unique whatever its case, through
+	// the index db.Migrate creates on LOWER(email)
+	Email           string     `json:"email"`
+	Address         string     `json:"address"`
+	UserType        UserType   `json:"user_type"`
+	PasswordHash    string     `json:"-"`
+	ProfileHeadline string     `json:"profile_headline"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	TOTPSecret      string     `json:"-"`
+	MFAEnabledAt    *time.Time `json:"mfa_enabled_at,omitempty"`
+	Roles           []Role     `json:"roles,omitempty" gorm:"many2many:user_roles;"`
+	OrganizationID  *uint      `json:"organization_id,omitempty" gorm:"index"`
+	Profile         *Profile   `json:"profile,omitempty" gorm:"foreignKey:ApplicantID"`
+}
+
+// MFAEnabled reports whether the user has completed TOTP enrollment
+func (u *User) MFAEnabled() bool {
+	return u.MFAEnabledAt != nil
 }
 
 type Profile struct {
@@ -45,5 +62,40 @@ type Job struct {
 	CompanyName       string    `json:"company_name"`
 	PostedByID        uint      `json:"posted_by_id"`
 	PostedBy          User      `json:"posted_by" gorm:"foreignKey:PostedByID"`
+	HiringManagerID   *uint     `json:"hiring_manager_id,omitempty"`
+	OrganizationID    *uint     `json:"organization_id,omitempty" gorm:"index;uniqueIndex:idx_jobs_org_external_ref"`
 	Applicants        []User    `json:"applicants,omitempty" gorm:"many2many:job_applications;"`
+	// Status defaults to published so jobs created before statuses existed stay open
+	Status            JobStatus  `json:"status" gorm:"not null;default:'PUBLISHED';index"`
+	PublishAt         *time.Time `json:"publish_at,omitempty"`
+	ClosesAt          *time.Time `json:"closes_at,omitempty"`
+	StatusChangedAt   *time.Time `json:"status_changed_at,omitempty"`
+	StatusChangedByID *uint      `json:"status_changed_by_id,omitempty"`
+	// Location; coordinates are WGS 84 degrees and both or neither are set
+	LocationCity    string       `json:"location_city,omitempty"`
+	LocationCountry string       `json:"location_country,omitempty" gorm:"size:2;index"`
+	Latitude        *float64     `json:"latitude,omitempty" gorm:"index:idx_jobs_geo"`
+	Longitude       *float64     `json:"longitude,omitempty" gorm:"index:idx_jobs_geo"`
+	RemotePolicy    RemotePolicy `json:"remote_policy,omitempty" gorm:"index"`
+	// Salary range in whole units of SalaryCurrency (ISO 4217) per SalaryPeriod
+	SalaryMin      *int64         `json:"salary_min,omitempty"`
+	SalaryMax      *int64         `json:"salary_max,omitempty"`
+	SalaryCurrency string         `json:"salary_currency,omitempty" gorm:"size:3"`
+	SalaryPeriod   SalaryPeriod   `json:"salary_period,omitempty"`
+	EmploymentType EmploymentType `json:"employment_type,omitempty" gorm:"index"`
+	Seniority      Seniority      `json:"seniority,omitempty" gorm:"index"`
+	Department     string         `json:"department,omitempty"`
+	// Version is incremented by every update and guards against lost updates
+	Version uint `json:"version" gorm:"not null;default:1"`
+	// ExternalRef identifies the job in the system it was imported from and is
+	// unique within the organization
+	ExternalRef *string `json:"external_ref,omitempty" gorm:"size:100;uniqueIndex:idx_jobs_org_external_ref,where:deleted_at IS NULL"`
+	// TemplateID and ClonedFromID record what the job was created from
+	TemplateID   *uint `json:"template_id,omitempty"`
+	ClonedFromID *uint `json:"cloned_from_id,omitempty"`
+}
+
+// IsManagedBy reports whether the user posted the job or is its hiring manager
+func (j *Job) IsManagedBy(userID uint) bool {
+	return j.PostedByID == userID || (j.HiringManagerID != nil && *j.HiringManagerID == userID)
 }
@@ -0,0 +1,11 @@
+package models
+
+import "gorm.io/gorm"
+
+// UserIdentity links a user to an account at an external identity provider
+type UserIdentity struct {
+	gorm.Model
+	UserID  uint   `json:"user_id" gorm:"index"`
+	Issuer  string `json:"issuer" gorm:"uniqueIndex:idx_user_identities_issuer_subject"`
+	Subject string `json:"subject" gorm:"uniqueIndex:idx_user_identities_issuer_subject"`
+}
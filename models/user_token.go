@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TokenPurpose string
+
+const (
+	TokenPurposePasswordReset     TokenPurpose = "PASSWORD_RESET"
+	TokenPurposeEmailVerification TokenPurpose = "EMAIL_VERIFICATION"
+)
+
+// UserToken is a single-use token mailed to a user. Only its hash is stored.
+type UserToken struct {
+	gorm.Model
+	UserID    uint         `json:"user_id" gorm:"index"`
+	Purpose   TokenPurpose `json:"purpose"`
+	TokenHash string       `json:"-" gorm:"uniqueIndex"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    *time.Time   `json:"used_at,omitempty"`
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"synergylabs/models"
+	"synergylabs/services/mail"
+	"synergylabs/util"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	PasswordResetTokenTTL     = time.Hour
+	EmailVerificationTokenTTL = 48 * time.Hour
+
+	backgroundEmailTimeout = 30 * time.Second
+)
+
+type AccountService struct {
+	db      *gorm.DB
+	mailer  mail.Sender
+	logger  *zap.Logger
+	baseURL string
+	sending *sync.WaitGroup
+}
+
+var _ AccountServiceInterface = (*AccountService)(nil)
+
+// NewAccountService creates the service handling password recovery and email
+// verification. baseURL is the front-end address links in emails point to.
+func NewAccountService(db *gorm.DB, mailer mail.Sender, logger *zap.Logger, baseURL string) *AccountService {
+	return &AccountService{
+		db:      db,
+		mailer:  mailer,
+		logger:  logger,
+		baseURL: baseURL,
+		sending: &sync.WaitGroup{},
+	}
+}
+
+// RequestPasswordReset mails a reset link if the email belongs to an account.
+// The lookup and the email happen in the background, so neither the result
+// nor how long the call takes tells callers whether the email is registered.
+func (s *AccountService) RequestPasswordReset(ctx context.Context, email string) {
+	s.background(ctx, func(ctx context.Context) {
+		var user models.User
+		if err := s.db.WithContext(ctx).Scopes(emailScope(email)).First(&user).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				s.logger.Error("Failed to fetch user for password reset", zap.Error(err))
+			}
+			return
+		}
+
+		token, err := s.issueToken(ctx, user.ID, models.TokenPurposePasswordReset, PasswordResetTokenTTL)
+		if err != nil {
+			return
+		}
+
+		msg := mail.Message{
+			To:      user.Email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %s.\n\n%s\n\nIf you did not request a password reset, you can ignore this email.\n",
+				user.Name, PasswordResetTokenTTL, s.link("/password/reset", token)),
+		}
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			s.logger.Error("Failed to send password reset email", zap.Uint("user_id", user.ID), zap.Error(err))
+			return
+		}
+
+		s.logger.Info("Password reset requested", zap.Uint("user_id", user.ID))
+	})
+}
+
+// ResetPassword consumes a reset token and replaces the account's password,
+// returning the account's ID so its sessions can be revoked
+func (s *AccountService) ResetPassword(ctx context.Context, token, newPassword string) (uint, error) {
+	// Checked first so a rejected password does not use up the token
+	if err := validatePassword(newPassword); err != nil {
+		return 0, err
+	}
+
+	hashedPassword, err := hashPassword(newPassword)
+	if err != nil {
+		s.logger.Error("Failed to hash password", zap.Error(err))
+		return 0, err
+	}
+
+	var userID uint
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		userToken, err := s.consumeToken(tx, token, models.TokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+		userID = userToken.UserID
+
+		// Completing a reset also proves ownership of the email
+		if err := tx.Model(&models.User{}).
+			Where("id = ?", userToken.UserID).
+			Updates(map[string]interface{}{
+				"password_hash":     hashedPassword,
+				"email_verified_at": gorm.Expr("COALESCE(email_verified_at, ?)", time.Now()),
+			}).Error; err != nil {
+			return err
+		}
+		return appendAuditEvent(ctx, tx, userAuditEvent(models.AuditActionPasswordReset, userID))
+	})
+	if err != nil {
+		if !errors.Is(err, ErrInvalidToken) {
+			s.logger.Error("Failed to reset password", zap.Error(err))
+		}
+		return 0, err
+	}
+
+	s.logger.Info("Password reset completed", zap.Uint("user_id", userID))
+	return userID, nil
+}
+
+// SendEmailVerification mails a verification link to the user
+func (s *AccountService) SendEmailVerification(ctx context.Context, user *models.User) error {
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	token, err := s.issueToken(ctx, user.ID, models.TokenPurposeEmailVerification, EmailVerificationTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below. It expires in %s.\n\n%s\n",
+			user.Name, EmailVerificationTokenTTL, s.link("/email/verify", token)),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		s.logger.Error("Failed to send verification email", zap.Uint("user_id", user.ID), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// ResendEmailVerification mails a new verification link if the email belongs
+// to an unverified account. Like RequestPasswordReset, it works in the
+// background so callers cannot probe for registered emails.
+func (s *AccountService) ResendEmailVerification(ctx context.Context, email string) {
+	s.background(ctx, func(ctx context.Context) {
+		var user models.User
+		if err := s.db.WithContext(ctx).Scopes(emailScope(email)).First(&user).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				s.logger.Error("Failed to fetch user for email verification", zap.Error(err))
+			}
+			return
+		}
+		s.SendEmailVerification(ctx, &user)
+	})
+}
+
+// VerifyEmail consumes a verification token and marks the email as verified
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) error {
+	var userID uint
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		userToken, err := s.consumeToken(tx, token, models.TokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+		userID = userToken.UserID
+
+		if err := tx.Model(&models.User{}).
+			Where("id = ? AND email_verified_at IS NULL", userToken.UserID).
+			Update("email_verified_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return appendAuditEvent(ctx, tx, userAuditEvent(models.AuditActionEmailVerify, userID))
+	})
+	if err != nil {
+		if !errors.Is(err, ErrInvalidToken) {
+			s.logger.Error("Failed to verify email", zap.Error(err))
+		}
+		return err
+	}
+
+	s.logger.Info("Email verified", zap.Uint("user_id", userID))
+	return nil
+}
+
+// issueToken stores the hash of a new token, invalidating earlier unused
+// tokens issued to the user for the same purpose
+func (s *AccountService) issueToken(ctx context.Context, userID uint, purpose models.TokenPurpose, ttl time.Duration) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		s.logger.Error("Failed to generate token", zap.Error(err))
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.UserToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: util.HashToken(token),
+			ExpiresAt: time.Now().Add(ttl),
+		}).Error
+	})
+	if err != nil {
+		s.logger.Error("Failed to store token", zap.String("purpose", string(purpose)), zap.Error(err))
+		return "", err
+	}
+
+	return token, nil
+}
+
+// consumeToken locks and marks a valid token as used inside tx
+func (s *AccountService) consumeToken(tx *gorm.DB, token string, purpose models.TokenPurpose) (*models.UserToken, error) {
+	var userToken models.UserToken
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ? AND purpose = ?", util.HashToken(token), purpose).
+		First(&userToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	if userToken.UsedAt != nil || time.Now().After(userToken.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+
+	if err := tx.Model(&userToken).Update("used_at", time.Now()).Error; err != nil {
+		return nil, err
+	}
+	return &userToken, nil
+}
+
+// background runs send outside the request, which may be over before it is
+func (s *AccountService) background(ctx context.Context, send func(ctx context.Context)) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), backgroundEmailTimeout)
+	s.sending.Add(1)
+	go func() {
+		defer s.sending.Done()
+		defer cancel()
+		send(ctx)
+	}()
+}
+
+// Wait blocks until the emails being sent in the background are sent
+func (s *AccountService) Wait() {
+	s.sending.Wait()
+}
+
+func (s *AccountService) link(path, token string) string {
+	return s.baseURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// PurgeExpiredTokens deletes emailed tokens that expired before the cutoff,
+// used or not
+func (s *AccountService) PurgeExpiredTokens(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Unscoped().Where("expires_at < ?", before).Delete(&models.UserToken{})
+	if result.Error != nil {
+		s.logger.Error("Failed to purge expired tokens", zap.Error(result.Error))
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
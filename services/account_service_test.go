@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"synergylabs/models"
+	"synergylabs/services/mail"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+func newTestAccountService(t *testing.T) (*AccountService, *mail.MemorySender) {
+	t.Helper()
+	conn := testDB(t)
+	mailer := mail.NewMemorySender()
+	return NewAccountService(conn, mailer, testLogger(), "https://jobs.example.com"), mailer
+}
+
+func newTestUser(t *testing.T, s *AccountService, verified bool) *models.User {
+	t.Helper()
+	hashed, err := hashPassword("old-password")
+	if err != nil {
+		t.Fatalf("hashing password: %v", err)
+	}
+	user := &models.User{Name: "Jane", Email: testEmail("account"), UserType: models.UserTypeApplicant, PasswordHash: hashed}
+	if err := s.db.Create(user).Error; err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	if verified {
+		if err := s.db.Model(user).Update("email_verified_at", gorm.Expr("NOW()")).Error; err != nil {
+			t.Fatalf("verifying user: %v", err)
+		}
+	}
+	return user
+}
+
+// mailedToken returns the token in the link of the only message sent to email
+func mailedToken(t *testing.T, mailer *mail.MemorySender, email string) string {
+	t.Helper()
+	var sent []mail.Message
+	for _, msg := range mailer.Messages() {
+		if msg.To == email {
+			sent = append(sent, msg)
+		}
+	}
+	if len(sent) != 1 {
+		t.Fatalf("%d messages sent to %s, want 1", len(sent), email)
+	}
+	_, query, found := strings.Cut(sent[0].Body, "?token=")
+	if !found {
+		t.Fatalf("no token link in %q", sent[0].Body)
+	}
+	token, err := url.QueryUnescape(strings.Fields(query)[0])
+	if err != nil {
+		t.Fatalf("unescaping token: %v", err)
+	}
+	return token
+}
+
+func TestVerifyEmail(t *testing.T) {
+	s, mailer := newTestAccountService(t)
+	user := newTestUser(t, s, false)
+	ctx := context.Background()
+
+	if err := s.SendEmailVerification(ctx, user); err != nil {
+		t.Fatalf("SendEmailVerification: %v", err)
+	}
+	token := mailedToken(t, mailer, user.Email)
+
+	if err := s.VerifyEmail(ctx, token); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	var stored models.User
+	if err := s.db.First(&stored, user.ID).Error; err != nil {
+		t.Fatalf("loading user: %v", err)
+	}
+	if stored.EmailVerifiedAt == nil {
+		t.Error("email is not verified")
+	}
+
+	if err := s.VerifyEmail(ctx, token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("reusing the token: error = %v, want %v", err, ErrInvalidToken)
+	}
+	if err := s.VerifyEmail(ctx, "not-a-token"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("unknown token: error = %v, want %v", err, ErrInvalidToken)
+	}
+
+	// Verified accounts are not mailed again
+	mailer.Reset()
+	if err := s.SendEmailVerification(ctx, &stored); err != nil {
+		t.Fatalf("SendEmailVerification: %v", err)
+	}
+	if n := len(mailer.Messages()); n != 0 {
+		t.Errorf("%d messages sent to a verified account", n)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	s, mailer := newTestAccountService(t)
+	user := newTestUser(t, s, false)
+	ctx := context.Background()
+
+	s.RequestPasswordReset(ctx, user.Email)
+	s.RequestPasswordReset(ctx, testEmail("nobody"))
+	s.Wait()
+	if n := len(mailer.Messages()); n != 1 {
+		t.Fatalf("%d messages sent, want only the one to the registered email", n)
+	}
+	token := mailedToken(t, mailer, user.Email)
+
+	if _, err := s.ResetPassword(ctx, "not-a-token", "new-password"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("unknown token: error = %v, want %v", err, ErrInvalidToken)
+	}
+	if _, err := s.ResetPassword(ctx, token, "short"); !errors.Is(err, ErrPasswordTooShort) {
+		t.Fatalf("short password: error = %v, want %v", err, ErrPasswordTooShort)
+	}
+	userID, err := s.ResetPassword(ctx, token, "new-password")
+	if err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if userID != user.ID {
+		t.Errorf("ResetPassword returned user %d, want %d", userID, user.ID)
+	}
+
+	var stored models.User
+	if err := s.db.First(&stored, user.ID).Error; err != nil {
+		t.Fatalf("loading user: %v", err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(stored.PasswordHash), []byte("new-password")) != nil {
+		t.Error("password was not changed")
+	}
+	if stored.EmailVerifiedAt == nil {
+		t.Error("completing a reset did not verify the email")
+	}
+
+	if _, err := s.ResetPassword(ctx, token, "another-password"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("reusing the token: error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestPasswordResetSupersedesEarlierTokens(t *testing.T) {
+	s, mailer := newTestAccountService(t)
+	user := newTestUser(t, s, true)
+	ctx := context.Background()
+
+	s.RequestPasswordReset(ctx, user.Email)
+	s.Wait()
+	first := mailedToken(t, mailer, user.Email)
+	mailer.Reset()
+	s.RequestPasswordReset(ctx, user.Email)
+	s.Wait()
+	second := mailedToken(t, mailer, user.Email)
+
+	if _, err := s.ResetPassword(ctx, first, "new-password"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("superseded token: error = %v, want %v", err, ErrInvalidToken)
+	}
+	if _, err := s.ResetPassword(ctx, second, "new-password"); err != nil {
+		t.Errorf("latest token: %v", err)
+	}
+}
+
+func TestResendEmailVerification(t *testing.T) {
+	s, mailer := newTestAccountService(t)
+	unverified := newTestUser(t, s, false)
+	verified := newTestUser(t, s, true)
+	ctx := context.Background()
+
+	s.ResendEmailVerification(ctx, unverified.Email)
+	s.ResendEmailVerification(ctx, verified.Email)
+	s.ResendEmailVerification(ctx, testEmail("nobody"))
+	s.Wait()
+	if n := len(mailer.Messages()); n != 1 {
+		t.Fatalf("%d messages sent, want only the one to the unverified account", n)
+	}
+	first := mailedToken(t, mailer, unverified.Email)
+
+	mailer.Reset()
+	s.ResendEmailVerification(ctx, unverified.Email)
+	s.Wait()
+	second := mailedToken(t, mailer, unverified.Email)
+
+	if err := s.VerifyEmail(ctx, first); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("superseded token: error = %v, want %v", err, ErrInvalidToken)
+	}
+	if err := s.VerifyEmail(ctx, second); err != nil {
+		t.Errorf("latest token: %v", err)
+	}
+}
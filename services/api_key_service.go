@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"synergylabs/models"
+	"synergylabs/util"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// apiKeyLastUsedResolution limits how often last_used_at is written for a busy key
+const apiKeyLastUsedResolution = time.Minute
+
+type APIKeyService struct {
+	db          *gorm.DB
+	permissions util.PermissionResolver
+	logger      *zap.Logger
+}
+
+var _ APIKeyServiceInterface = (*APIKeyService)(nil)
+var _ util.APIKeyAuthenticator = (*APIKeyService)(nil)
+
+func NewAPIKeyService(db *gorm.DB, permissions util.PermissionResolver, logger *zap.Logger) *APIKeyService {
+	return &APIKeyService{
+		db:          db,
+		permissions: permissions,
+		logger:      logger,
+	}
+}
+
+// CreateAPIKey stores a new key for the user and returns the plaintext key,
+// which is only available at creation. Scopes must be a subset of the
+// owner's own permissions.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, apiKey *models.APIKey) (string, error) {
+	granted, err := s.permissions.Permissions(ctx, apiKey.UserID)
+	if err != nil {
+		return "", err
+	}
+	held := map[models.Permission]bool{}
+	for _, perm := range granted {
+		held[perm] = true
+	}
+	for _, scope := range apiKey.Scopes {
+		if !held[scope] {
+			return "", fmt.Errorf("%w: %s", ErrScopeNotHeld, scope)
+		}
+	}
+
+	prefix, secret, err := generateAPIKey()
+	if err != nil {
+		s.logger.Error("Failed to generate API key", zap.Error(err))
+		return "", err
+	}
+	key := util.APIKeyPrefix + prefix + "_" + secret
+
+	apiKey.Prefix = prefix
+	apiKey.KeyHash = util.HashToken(key)
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(apiKey).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditActionAPIKeyCreate, "api_key", apiKey.ID, nil, apiKey)
+	})
+	if err != nil {
+		s.logger.Error("Failed to create API key", zap.Error(err))
+		return "", err
+	}
+
+	s.logger.Info("API key created", zap.Uint("user_id", apiKey.UserID), zap.String("prefix", prefix))
+	return key, nil
+}
+
+func (s *APIKeyService) ListAPIKeys(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := s.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&keys).Error; err != nil {
+		s.logger.Error("Failed to fetch API keys", zap.Error(err))
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, userID, id uint) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.APIKey{}).
+			Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+			Update("revoked_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAPIKeyNotFound
+		}
+		return recordAudit(ctx, tx, models.AuditActionAPIKeyRevoke, "api_key", id, nil, nil)
+	})
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return err
+	}
+	if err != nil {
+		s.logger.Error("Failed to revoke API key", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("API key revoked", zap.Uint("user_id", userID), zap.Uint("api_key_id", id))
+	return nil
+}
+
+// AuthenticateAPIKey implements util.APIKeyAuthenticator
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*util.APIKeyPrincipal, error) {
+	prefix, ok := parseAPIKeyPrefix(key)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var apiKey models.APIKey
+	if err := s.db.WithContext(ctx).Where("prefix = ?", prefix).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		s.logger.Error("Failed to fetch API key", zap.Error(err))
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(util.HashToken(key))) != 1 || !apiKey.Active() {
+		return nil, ErrInvalidAPIKey
+	}
+
+	var user models.User
+	if err := s.db.WithContext(ctx).First(&user, apiKey.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		s.logger.Error("Failed to fetch API key owner", zap.Error(err))
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", apiKey.ID, now.Add(-apiKeyLastUsedResolution)).
+		Update("last_used_at", now).Error; err != nil {
+		s.logger.Warn("Failed to record API key use", zap.Error(err))
+	}
+
+	return &util.APIKeyPrincipal{
+		KeyID:          apiKey.ID,
+		UserID:         user.ID,
+		UserType:       string(user.UserType),
+		OrganizationID: user.OrganizationID,
+		Scopes:         apiKey.Scopes,
+	}, nil
+}
+
+// generateAPIKey returns an 8 character lookup prefix and a 256-bit secret
+func generateAPIKey() (prefix, secret string, err error) {
+	p := make([]byte, 4)
+	if _, err := rand.Read(p); err != nil {
+		return "", "", err
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(p), base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// parseAPIKeyPrefix extracts the prefix from a key of the form sl_<prefix>_<secret>
+func parseAPIKeyPrefix(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, util.APIKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != 8 || secret == "" {
+		return "", false
+	}
+	return prefix, true
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"synergylabs/models"
+	"synergylabs/util"
+	"testing"
+	"time"
+)
+
+func TestParseAPIKeyPrefix(t *testing.T) {
+	tests := []struct {
+		key    string
+		prefix string
+		ok     bool
+	}{
+		{"sl_0a1b2c3d_c2VjcmV0", "0a1b2c3d", true},
+		{"sl_0a1b2c3d_secret_with_underscores", "0a1b2c3d", true},
+		{"", "", false},
+		{"0a1b2c3d_secret", "", false},
+		{"pk_0a1b2c3d_secret", "", false},
+		{"sl_0a1b2c3d", "", false},
+		{"sl_0a1b2c3d_", "", false},
+		{"sl_0a1b2c_secret", "", false},
+		{"sl_0a1b2c3d4_secret", "", false},
+		{"sl__secret", "", false},
+	}
+	for _, tt := range tests {
+		prefix, ok := parseAPIKeyPrefix(tt.key)
+		if prefix != tt.prefix || ok != tt.ok {
+			t.Errorf("parseAPIKeyPrefix(%q) = %q, %v, want %q, %v", tt.key, prefix, ok, tt.prefix, tt.ok)
+		}
+	}
+}
+
+func TestAPIKeyActive(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		key    models.APIKey
+		active bool
+	}{
+		{"no expiry", models.APIKey{}, true},
+		{"expires later", models.APIKey{ExpiresAt: &future}, true},
+		{"expired", models.APIKey{ExpiresAt: &past}, false},
+		{"revoked", models.APIKey{RevokedAt: &past}, false},
+		{"revoked before expiry", models.APIKey{RevokedAt: &past, ExpiresAt: &future}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.key.Active(); got != tt.active {
+			t.Errorf("%s: Active = %v, want %v", tt.name, got, tt.active)
+		}
+	}
+}
+
+func TestCreateAPIKeyRejectsScopesNotHeld(t *testing.T) {
+	permissions := testPermissions{1: {models.PermJobRead, models.PermJobCreate}}
+	s := NewAPIKeyService(nil, permissions, testLogger())
+
+	for _, scopes := range [][]models.Permission{
+		{models.PermJobDelete},
+		{models.PermJobRead, models.PermRoleManage},
+	} {
+		_, err := s.CreateAPIKey(context.Background(), &models.APIKey{UserID: 1, Name: "ci", Scopes: scopes})
+		if !errors.Is(err, ErrScopeNotHeld) {
+			t.Errorf("scopes %v: error = %v, want %v", scopes, err, ErrScopeNotHeld)
+		}
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	user := models.User{Name: "Robot", Email: testEmail("apikey"), UserType: models.UserTypeAdmin}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	s := NewAPIKeyService(db, testPermissions{user.ID: {models.PermJobRead}}, testLogger())
+
+	create := func(expiresAt *time.Time) (string, *models.APIKey) {
+		t.Helper()
+		apiKey := &models.APIKey{UserID: user.ID, Name: "ci", Scopes: []models.Permission{models.PermJobRead}, ExpiresAt: expiresAt}
+		key, err := s.CreateAPIKey(ctx, apiKey)
+		if err != nil {
+			t.Fatalf("CreateAPIKey: %v", err)
+		}
+		return key, apiKey
+	}
+
+	key, apiKey := create(nil)
+	principal, err := s.AuthenticateAPIKey(ctx, key)
+	if err != nil {
+		t.Fatalf("AuthenticateAPIKey: %v", err)
+	}
+	if principal.KeyID != apiKey.ID || principal.UserID != user.ID || len(principal.Scopes) != 1 || principal.Scopes[0] != models.PermJobRead {
+		t.Errorf("principal = %+v, want key %d of user %d scoped to %s", principal, apiKey.ID, user.ID, models.PermJobRead)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	expired, _ := create(&past)
+	revoked, revokedKey := create(nil)
+	if err := s.RevokeAPIKey(ctx, user.ID, revokedKey.ID); err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+	// Same prefix, so the key is found, but the secret does not match its hash
+	tampered := util.APIKeyPrefix + apiKey.Prefix + "_not-the-secret"
+
+	for _, tt := range []struct {
+		name string
+		key  string
+	}{
+		{"malformed key", "not-an-api-key"},
+		{"unknown prefix", "sl_ffffffff_secret"},
+		{"wrong secret for the prefix", tampered},
+		{"expired key", expired},
+		{"revoked key", revoked},
+	} {
+		if _, err := s.AuthenticateAPIKey(ctx, tt.key); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, ErrInvalidAPIKey)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"synergylabs/models"
+	"synergylabs/util"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// auditChainLock seeds the advisory locks that serialize appends to each audit hash chain
+const auditChainLock = 7_301_920_551
+
+// auditVerifyBatchSize is how many events VerifyChain loads at a time
+const auditVerifyBatchSize = 1000
+
+// auditIgnoredFields are bookkeeping fields left out of change sets
+var auditIgnoredFields = map[string]bool{
+	"ID":        true,
+	"CreatedAt": true,
+	"UpdatedAt": true,
+	"DeletedAt": true,
+}
+
+// auditRedactedFields are recorded as changed without their values
+var auditRedactedFields = map[string]bool{
+	"password_hash": true,
+	"key_hash":      true,
+	"token_hash":    true,
+}
+
+type AuditService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+var _ AuditServiceInterface = (*AuditService)(nil)
+
+func NewAuditService(db *gorm.DB, logger *zap.Logger) *AuditService {
+	return &AuditService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Record appends an event that is not part of a data change
+func (s *AuditService) Record(ctx context.Context, event *models.AuditEvent) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return appendAuditEvent(ctx, tx, event)
+	})
+	if err != nil {
+		s.logger.Error("Failed to record audit event", zap.String("action", event.Action), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ListEvents returns audit events matching the filters, newest first
+func (s *AuditService) ListEvents(ctx context.Context, filters AuditFilters) (*PaginatedResponse, error) {
+	query := s.db.WithContext(ctx).Model(&models.AuditEvent{}).Scopes(tenantScope(ctx, "audit_events.organization_id = ?"))
+	if filters.ActorID != 0 {
+		query = query.Where("actor_id = ?", filters.ActorID)
+	}
+	if filters.Action != "" {
+		query = query.Where("action = ?", filters.Action)
+	}
+	if filters.EntityType != "" {
+		query = query.Where("entity_type = ?", filters.EntityType)
+	}
+	if filters.EntityID != "" {
+		query = query.Where("entity_id = ?", filters.EntityID)
+	}
+	if !filters.From.IsZero() {
+		query = query.Where("created_at >= ?", filters.From)
+	}
+	if !filters.To.IsZero() {
+		query = query.Where("created_at < ?", filters.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		s.logger.Error("Failed to count audit events", zap.Error(err))
+		return nil, err
+	}
+
+	var events []models.AuditEvent
+	offset := (filters.Page - 1) * filters.PageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(filters.PageSize).Find(&events).Error; err != nil {
+		s.logger.Error("Failed to fetch audit events", zap.Error(err))
+		return nil, err
+	}
+
+	response := newPaginatedResponse(events, total, filters.Page, filters.PageSize)
+	return &response, nil
+}
+
+// VerifyChain recomputes every hash chain from its first event and reports
+// the first event whose content or link no longer matches. Events written
+// before the log was hash-chained are skipped; any unhashed event after the
+// first hashed one breaks the chain.
+func (s *AuditService) VerifyChain(ctx context.Context) (*AuditVerification, error) {
+	db := s.db.WithContext(ctx)
+	result := &AuditVerification{Valid: true}
+
+	var first models.AuditEvent
+	err := db.Select("id").Where("hash <> ''").Order("id").Take(&first).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := db.Model(&models.AuditEvent{}).Count(&result.Skipped).Error; err != nil {
+			s.logger.Error("Failed to count audit events", zap.Error(err))
+			return nil, err
+		}
+		return result, nil
+	}
+	if err != nil {
+		s.logger.Error("Failed to fetch audit events", zap.Error(err))
+		return nil, err
+	}
+	if err := db.Model(&models.AuditEvent{}).Where("id < ?", first.ID).Count(&result.Skipped).Error; err != nil {
+		s.logger.Error("Failed to count audit events", zap.Error(err))
+		return nil, err
+	}
+
+	var chains []string
+	if err := db.Model(&models.AuditEvent{}).
+		Where("id >= ?", first.ID).
+		Distinct("chain").
+		Order("chain").
+		Pluck("chain", &chains).Error; err != nil {
+		s.logger.Error("Failed to list audit chains", zap.Error(err))
+		return nil, err
+	}
+	for _, chain := range chains {
+		if err := s.verifyChain(ctx, chain, first.ID, result); err != nil {
+			return nil, err
+		}
+		if !result.Valid {
+			return result, nil
+		}
+		result.Chains++
+	}
+	return result, nil
+}
+
+// verifyChain checks the events of one chain from fromID onwards
+func (s *AuditService) verifyChain(ctx context.Context, chain string, fromID uint, result *AuditVerification) error {
+	prevHash := ""
+	lastID := fromID - 1
+
+	for {
+		var events []models.AuditEvent
+		if err := s.db.WithContext(ctx).
+			Where("chain = ? AND id > ?", chain, lastID).
+			Order("id").
+			Limit(auditVerifyBatchSize).
+			Find(&events).Error; err != nil {
+			s.logger.Error("Failed to fetch audit events", zap.Error(err))
+			return err
+		}
+
+		for i := range events {
+			event := &events[i]
+			hash, err := event.ComputeHash()
+			if err != nil {
+				return err
+			}
+			if event.PrevHash != prevHash || event.Hash != hash {
+				result.Valid = false
+				result.BrokenAtID = event.ID
+				result.BrokenChain = chain
+				securityEvent(s.logger, "audit_chain_broken", zap.Uint("audit_event_id", event.ID), zap.String("chain", chain))
+				return nil
+			}
+			prevHash = event.Hash
+			lastID = event.ID
+			result.Checked++
+		}
+
+		if len(events) < auditVerifyBatchSize {
+			return nil
+		}
+	}
+}
+
+// recordAudit appends an event describing a change to an entity inside the
+// transaction that makes the change. before is nil for creations and after
+// is nil for deletions.
+func recordAudit(ctx context.Context, tx *gorm.DB, action, entityType string, entityID uint, before, after interface{}) error {
+	changes, err := auditChanges(before, after)
+	if err != nil {
+		return err
+	}
+	return appendAuditEvent(ctx, tx, &models.AuditEvent{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   strconv.FormatUint(uint64(entityID), 10),
+		Changes:    changes,
+	})
+}
+
+// userAuditEvent describes an action a user took on their own account
+func userAuditEvent(action string, userID uint) *models.AuditEvent {
+	return &models.AuditEvent{
+		ActorID:    &userID,
+		Action:     action,
+		EntityType: "user",
+		EntityID:   strconv.FormatUint(uint64(userID), 10),
+	}
+}
+
+// auditChain names the hash chain an event is appended to. Each organization
+// and entity type has its own chain, so writes to unrelated data do not wait
+// on each other's lock.
+func auditChain(event *models.AuditEvent) string {
+	scope := "platform"
+	if event.OrganizationID != nil {
+		scope = "organization:" + strconv.FormatUint(uint64(*event.OrganizationID), 10)
+	}
+	return scope + "/" + event.EntityType
+}
+
+// appendAuditEvent links event to the end of its hash chain and inserts it.
+// tx must be a transaction: the chain's lock is held until it commits.
+func appendAuditEvent(ctx context.Context, tx *gorm.DB, event *models.AuditEvent) error {
+	request := util.RequestInfoFromContext(ctx)
+	if event.ActorID == nil && request.ActorID != 0 {
+		actorID := request.ActorID
+		event.ActorID = &actorID
+	}
+	if event.OrganizationID == nil {
+		if orgID, ok := util.OrganizationFromContext(ctx); ok {
+			event.OrganizationID = &orgID
+		}
+	}
+	if event.RequestID == "" {
+		event.RequestID = request.ID
+	}
+	if event.IP == "" {
+		event.IP = request.IP
+	}
+
+	event.Chain = auditChain(event)
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtextextended(?, ?))", event.Chain, auditChainLock).Error; err != nil {
+		return err
+	}
+
+	var last models.AuditEvent
+	err := tx.Select("hash").Where("chain = ?", event.Chain).Order("id DESC").Take(&last).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	// Postgres keeps microseconds, so truncate to hash what will be read back
+	event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	event.PrevHash = last.Hash
+	if event.Hash, err = event.ComputeHash(); err != nil {
+		return err
+	}
+	return tx.Create(event).Error
+}
+
+// auditChanges lists the fields that differ between two versions of an entity
+func auditChanges(before, after interface{}) (map[string]models.AuditChange, error) {
+	from, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]models.AuditChange{}
+	for field := range from {
+		if _, ok := to[field]; !ok {
+			to[field] = nil
+		}
+	}
+	for field, value := range to {
+		old := from[field]
+		if reflect.DeepEqual(old, value) {
+			continue
+		}
+		if auditRedactedFields[field] {
+			changes[field] = models.AuditChange{From: "[redacted]", To: "[redacted]"}
+			continue
+		}
+		changes[field] = models.AuditChange{From: old, To: value}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return changes, nil
+}
+
+// auditFields flattens an entity into its JSON fields, skipping bookkeeping
+// fields and nested associations
+func auditFields(entity interface{}) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if v := reflect.ValueOf(entity); !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	for field, value := range fields {
+		if auditIgnoredFields[field] || isAssociation(value) {
+			delete(fields, field)
+		} else if list, ok := value.([]interface{}); ok && len(list) == 0 {
+			fields[field] = nil
+		}
+	}
+	return fields, nil
+}
+
+func isAssociation(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		_, hasID := v["ID"]
+		return hasID
+	case []interface{}:
+		if len(v) == 0 {
+			return false
+		}
+		item, ok := v[0].(map[string]interface{})
+		if !ok {
+			return false
+		}
+		_, hasID := item["ID"]
+		return hasID
+	}
+	return false
+}
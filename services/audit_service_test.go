@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"synergylabs/models"
+	"synergylabs/util"
+	"testing"
+	"time"
+)
+
+func TestAuditChain(t *testing.T) {
+	orgID := uint(7)
+	tests := []struct {
+		event models.AuditEvent
+		want  string
+	}{
+		{models.AuditEvent{EntityType: "job", OrganizationID: &orgID}, "organization:7/job"},
+		{models.AuditEvent{EntityType: "user", OrganizationID: &orgID}, "organization:7/user"},
+		{models.AuditEvent{EntityType: "job"}, "platform/job"},
+		{models.AuditEvent{}, "platform/"},
+	}
+	for _, tt := range tests {
+		if got := auditChain(&tt.event); got != tt.want {
+			t.Errorf("auditChain(%+v) = %q, want %q", tt.event, got, tt.want)
+		}
+	}
+}
+
+func TestAuditHashCoversChain(t *testing.T) {
+	event := models.AuditEvent{Action: models.AuditActionJobCreate, EntityType: "job", EntityID: "1", CreatedAt: time.Now()}
+	legacy, err := event.ComputeHash()
+	if err != nil {
+		t.Fatalf("ComputeHash: %v", err)
+	}
+	event.Chain = "platform/job"
+	chained, err := event.ComputeHash()
+	if err != nil {
+		t.Fatalf("ComputeHash: %v", err)
+	}
+	if legacy == chained {
+		t.Error("moving an event to another chain does not change its hash")
+	}
+}
+
+func TestAuditChainsLinkPerOrganization(t *testing.T) {
+	// The forged event below must not outlive the test, so everything happens in a transaction
+	db := testDB(t).Begin()
+	defer db.Rollback()
+	s := NewAuditService(db, testLogger())
+
+	var orgs [2]models.Organization
+	for i := range orgs {
+		slug := testSSODomain()
+		orgs[i] = models.Organization{Name: slug, Slug: slug}
+		if err := db.Create(&orgs[i]).Error; err != nil {
+			t.Fatalf("creating organization: %v", err)
+		}
+	}
+
+	// Events alternate between organizations but link only within their own chain
+	var events []*models.AuditEvent
+	for i := 0; i < 4; i++ {
+		ctx := util.ContextWithOrganization(context.Background(), orgs[i%2].ID)
+		event := &models.AuditEvent{Action: models.AuditActionJobCreate, EntityType: "job", EntityID: "1"}
+		if err := s.Record(ctx, event); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+		events = append(events, event)
+	}
+	for i := 2; i < 4; i++ {
+		if events[i].PrevHash != events[i-2].Hash {
+			t.Errorf("event %d links to %q, want the previous event of its organization %q", i, events[i].PrevHash, events[i-2].Hash)
+		}
+	}
+
+	result, err := s.VerifyChain(context.Background())
+	if err != nil {
+		t.Fatalf("VerifyChain: %v", err)
+	}
+	if !result.Valid || result.Chains < 2 {
+		t.Errorf("VerifyChain = %+v, want a valid result covering both chains", result)
+	}
+
+	// An unhashed event slipped in after hashing started breaks its chain
+	forged := models.AuditEvent{Action: models.AuditActionJobDelete, EntityType: "job", EntityID: "1", Chain: events[3].Chain, CreatedAt: time.Now()}
+	if err := db.Omit("Hash").Create(&forged).Error; err != nil {
+		t.Fatalf("inserting forged event: %v", err)
+	}
+	result, err = s.VerifyChain(context.Background())
+	if err != nil {
+		t.Fatalf("VerifyChain: %v", err)
+	}
+	if result.Valid || result.BrokenAtID != forged.ID {
+		t.Errorf("VerifyChain = %+v, want broken at %d", result, forged.ID)
+	}
+}
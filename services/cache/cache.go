@@ -3,11 +3,41 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
+// ErrNotFound is returned by Get when the key does not exist
+var ErrNotFound = errors.New("cache: key not found")
+
+// compareAndSwapScript replaces the value at KEYS[1] only if it still holds ARGV[1]
+var compareAndSwapScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+	return 1
+end
+return 0
+`)
+
+// deleteIfEqualsScript deletes KEYS[1] only if it still holds ARGV[1]
+var deleteIfEqualsScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// incrementScript increments KEYS[1] and sets its expiration when the counter is new
+var incrementScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n
+`)
+
 type Cache struct {
 	client *redis.Client
 }
@@ -31,6 +61,21 @@ func (c *Cache) Set(ctx context.Context, key string, value interface{}, expirati
 
 func (c *Cache) Get(ctx context.Context, key string, dest interface{}) error {
 	val, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(val, dest)
+}
+
+// Take reads a key and deletes it in one step, so the value can only be consumed once
+func (c *Cache) Take(ctx context.Context, key string, dest interface{}) error {
+	val, err := c.client.GetDel(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return ErrNotFound
+	}
 	if err != nil {
 		return err
 	}
@@ -40,3 +85,69 @@ func (c *Cache) Get(ctx context.Context, key string, dest interface{}) error {
 func (c *Cache) Delete(ctx context.Context, key string) error {
 	return c.client.Del(ctx, key).Err()
 }
+
+// Exists reports whether the key is present
+func (c *Cache) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := c.client.Exists(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// CompareAndSwap atomically replaces the value at key with newValue if it currently equals oldValue
+func (c *Cache) CompareAndSwap(ctx context.Context, key string, oldValue, newValue interface{}, expiration time.Duration) (bool, error) {
+	oldJSON, err := json.Marshal(oldValue)
+	if err != nil {
+		return false, err
+	}
+	newJSON, err := json.Marshal(newValue)
+	if err != nil {
+		return false, err
+	}
+	swapped, err := compareAndSwapScript.Run(ctx, c.client, []string{key}, oldJSON, newJSON, expiration.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return swapped == 1, nil
+}
+
+// DeleteIfEquals deletes key only if it currently holds value, so a lock is
+// only released by its holder
+func (c *Cache) DeleteIfEquals(ctx context.Context, key string, value interface{}) (bool, error) {
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := deleteIfEqualsScript.Run(ctx, c.client, []string{key}, valueJSON).Int()
+	if err != nil {
+		return false, err
+	}
+	return deleted == 1, nil
+}
+
+// SetNX stores value only if the key does not exist yet and reports whether it was stored
+func (c *Cache) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	json, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	return c.client.SetNX(ctx, key, json, expiration).Result()
+}
+
+// Increment adds one to the counter at key, starting its expiration when the counter is created
+func (c *Cache) Increment(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	return incrementScript.Run(ctx, c.client, []string{key}, expiration.Milliseconds()).Int64()
+}
+
+// TTL returns the remaining time to live of key, or zero when it has none
+func (c *Cache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := c.client.PTTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+	"synergylabs/util"
+)
+
+var (
+	ErrEmailExists         = errors.New("email already exists")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrAccountLocked       = errors.New("too many failed login attempts")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrInvalidToken        = errors.New("invalid or expired token")
+	ErrEmailNotVerified    = errors.New("email address has not been verified")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrNotApplicant        = errors.New("only applicant accounts can be impersonated")
+	ErrProfileNotFound     = errors.New("profile not found")
+	ErrNoPassword          = errors.New("account has no password and signs in through single sign-on")
+	ErrPasswordTooShort    = errors.New("password is too short")
+
+	ErrInvalidMFACode     = errors.New("invalid authentication code")
+	ErrMFAAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled     = errors.New("two-factor authentication is not enrolled")
+	ErrMFARequired        = errors.New("two-factor authentication is required for this account")
+	ErrMFATooManyAttempts = errors.New("too many authentication attempts")
+
+	ErrJobNotFound             = errors.New("job not found")
+	ErrNotJobOwner             = errors.New("only the job's poster or hiring manager can change it")
+	ErrJobVersionConflict      = errors.New("job has been changed since it was read")
+	ErrJobFieldNotUpdatable    = errors.New("field cannot be updated")
+	ErrInvalidHiringManager    = errors.New("hiring manager must be a staff member of the job's organization")
+	ErrInvalidPoster           = errors.New("poster must be a staff member of the job's organization")
+	ErrInvalidJobStatus        = errors.New("invalid job status")
+	ErrJobTransitionNotAllowed = errors.New("job status change not allowed")
+	ErrInvalidJobSchedule      = errors.New("invalid job schedule")
+	ErrJobNotOpen              = errors.New("job is not accepting applications")
+	ErrInvalidJobAttribute     = errors.New("invalid job attribute")
+	ErrInvalidJobFilter        = errors.New("invalid job filter")
+	ErrInvalidCursor           = errors.New("invalid cursor")
+	ErrJobExternalRefExists    = errors.New("another job already has this external reference")
+	ErrAlreadyApplied          = errors.New("already applied to this job")
+
+	ErrInvalidScreeningQuestion = errors.New("invalid screening question")
+	ErrInvalidScreeningAnswers  = errors.New("invalid screening answers")
+
+	ErrInvalidJobImport = errors.New("invalid job import")
+	ErrInvalidJobRecord = errors.New("invalid job record")
+
+	ErrJobTemplateNotFound      = errors.New("job template not found")
+	ErrJobTemplateExists        = errors.New("a job template with this name already exists")
+	ErrInvalidTemplateVariables = errors.New("template variables do not match the template")
+
+	ErrOrganizationNotFound    = errors.New("organization not found")
+	ErrOrganizationExists      = errors.New("organization slug already exists")
+	ErrUserInOtherOrganization = errors.New("user already belongs to another organization")
+	ErrEmailDomainNotAllowed   = errors.New("email domain is not allowed in this organization")
+	ErrNotStaff                = errors.New("only staff accounts can join an organization")
+	ErrPlatformAdminRequired   = errors.New("only platform administrators can do this")
+
+	ErrRoleNotFound         = errors.New("role not found")
+	ErrRoleExists           = errors.New("role already exists")
+	ErrSystemRole           = errors.New("built-in roles cannot be changed")
+	ErrUnknownPermission    = errors.New("unknown permission")
+	ErrLastSuperAdmin       = errors.New("cannot remove the last super admin")
+	ErrRoleUserTypeMismatch = errors.New("role does not apply to this type of account")
+	ErrRoleNotGrantable     = errors.New("role grants permissions you do not hold")
+
+	ErrInvalidAPIKey  = util.ErrInvalidAPIKey
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	ErrScopeNotHeld   = errors.New("API key scope exceeds the owner's permissions")
+
+	ErrSSOFailed           = errors.New("single sign-on failed")
+	ErrInvalidSSOState     = errors.New("invalid or expired single sign-on state")
+	ErrSSOEmailNotVerified = errors.New("identity provider did not return a verified email")
+	ErrSSOAccountNotFound  = errors.New("no account is available for this identity")
+	ErrInvalidSSODomain    = errors.New("invalid single sign-on provisioning domain")
+	ErrSSODomainTaken      = errors.New("single sign-on provisioning domain belongs to another organization")
+	ErrSuperAdminSSORole   = errors.New("super_admin cannot be the single sign-on default role")
+
+	ErrInvalidInvitation  = errors.New("invalid invitation token")
+	ErrInvitationNotFound = errors.New("invitation not found")
+	ErrInvitationAccepted = errors.New("invitation has already been accepted")
+	ErrInvitationRevoked  = errors.New("invitation has been revoked")
+	ErrInvitationExpired  = errors.New("invitation has expired")
+)
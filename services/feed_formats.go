@@ -0,0 +1,475 @@
+package services
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"synergylabs/models"
+	"time"
+	"unicode"
+)
+
+// FeedFormat is a public representation of published jobs
+type FeedFormat string
+
+const (
+	// FeedJSON is the public JSON view of a job or list of jobs
+	FeedJSON FeedFormat = "json"
+	// FeedJSONLD is a schema.org JobPosting for search engines
+	FeedJSONLD FeedFormat = "jsonld"
+	FeedAtom   FeedFormat = "atom"
+	FeedRSS    FeedFormat = "rss"
+	// FeedXML is the XML format job aggregators crawl
+	FeedXML FeedFormat = "xml"
+)
+
+// ContentType is the media type documents in the format are served with
+func (f FeedFormat) ContentType() string {
+	switch f {
+	case FeedJSONLD:
+		return "application/ld+json"
+	case FeedAtom:
+		return "application/atom+xml; charset=utf-8"
+	case FeedRSS:
+		return "application/rss+xml; charset=utf-8"
+	case FeedXML:
+		return "application/xml; charset=utf-8"
+	}
+	return "application/json; charset=utf-8"
+}
+
+// mediaType is the content type without parameters
+func (f FeedFormat) mediaType() string {
+	mediaType, _, _ := strings.Cut(f.ContentType(), ";")
+	return mediaType
+}
+
+// feedMeta describes a feed as a whole
+type feedMeta struct {
+	Title   string
+	SelfURL string
+	SiteURL string
+	Updated time.Time
+}
+
+// renderFeed renders a list of jobs in a format
+func renderFeed(format FeedFormat, meta feedMeta, jobs []PublicJob) ([]byte, error) {
+	switch format {
+	case FeedJSON:
+		return json.Marshal(map[string]interface{}{"data": jobs})
+	case FeedAtom:
+		return marshalXML(atomFeedOf(meta, jobs))
+	case FeedRSS:
+		return marshalXML(rssFeedOf(meta, jobs))
+	case FeedXML:
+		return marshalXML(aggregatorFeedOf(meta, jobs))
+	}
+	return nil, fmt.Errorf("no feed in format %q", format)
+}
+
+// renderJob renders a single job in a format
+func renderJob(format FeedFormat, job PublicJob) ([]byte, error) {
+	switch format {
+	case FeedJSON:
+		return json.Marshal(job)
+	case FeedJSONLD:
+		return json.Marshal(jobPostingOf(job))
+	}
+	return nil, fmt.Errorf("no job document in format %q", format)
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// salaryText describes a salary range such as "50000-70000 EUR per year"
+func salaryText(job PublicJob) string {
+	if job.SalaryMin == nil && job.SalaryMax == nil {
+		return ""
+	}
+	var amount string
+	switch {
+	case job.SalaryMin != nil && job.SalaryMax != nil && *job.SalaryMin != *job.SalaryMax:
+		amount = fmt.Sprintf("%d-%d", *job.SalaryMin, *job.SalaryMax)
+	case job.SalaryMin != nil:
+		amount = strconv.FormatInt(*job.SalaryMin, 10)
+	default:
+		amount = strconv.FormatInt(*job.SalaryMax, 10)
+	}
+	return fmt.Sprintf("%s %s per %s", amount, job.SalaryCurrency, strings.ToLower(string(job.SalaryPeriod)))
+}
+
+// jobCategories are the labels feeds file a job under
+func jobCategories(job PublicJob) []string {
+	var categories []string
+	for _, category := range []string{job.Department, string(job.EmploymentType), string(job.Seniority), string(job.RemotePolicy)} {
+		if category != "" {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+// jobAuthor names who a job is from in feeds that require an author
+func jobAuthor(meta feedMeta, job PublicJob) string {
+	if job.CompanyName != "" {
+		return job.CompanyName
+	}
+	return meta.Title
+}
+
+// Atom (RFC 4287)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Link       atomLink       `xml:"link"`
+	Author     atomPerson     `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomText       `xml:"content"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+func atomFeedOf(meta feedMeta, jobs []PublicJob) *atomFeed {
+	feed := &atomFeed{
+		Title:   meta.Title,
+		ID:      meta.SelfURL,
+		Updated: meta.Updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Type: FeedAtom.mediaType(), Href: meta.SelfURL},
+			{Rel: "alternate", Type: "text/html", Href: meta.SiteURL},
+		},
+		Entries: make([]atomEntry, len(jobs)),
+	}
+	for i, job := range jobs {
+		entry := atomEntry{
+			Title:     job.Title,
+			ID:        job.URL,
+			Published: job.DatePosted.UTC().Format(time.RFC3339),
+			Updated:   job.UpdatedAt.UTC().Format(time.RFC3339),
+			Link:      atomLink{Rel: "alternate", Type: "text/html", Href: job.URL},
+			Author:    atomPerson{Name: jobAuthor(meta, job)},
+			Content:   atomText{Type: "text", Text: job.Description},
+		}
+		for _, category := range jobCategories(job) {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		feed.Entries[i] = entry
+	}
+	return feed
+}
+
+// RSS 2.0
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func rssFeedOf(meta feedMeta, jobs []PublicJob) *rssFeed {
+	feed := &rssFeed{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         meta.Title,
+			Link:          meta.SiteURL,
+			Description:   meta.Title,
+			LastBuildDate: meta.Updated.UTC().Format(time.RFC1123Z),
+			AtomLink:      atomLink{Rel: "self", Type: FeedRSS.mediaType(), Href: meta.SelfURL},
+			Items:         make([]rssItem, len(jobs)),
+		},
+	}
+	for i, job := range jobs {
+		feed.Channel.Items[i] = rssItem{
+			Title:       job.Title,
+			Link:        job.URL,
+			GUID:        rssGUID{IsPermaLink: true, Value: job.URL},
+			PubDate:     job.DatePosted.UTC().Format(time.RFC1123Z),
+			Categories:  jobCategories(job),
+			Description: job.Description,
+		}
+	}
+	return feed
+}
+
+// Aggregator XML, the <source>/<job> layout job boards such as Indeed crawl
+
+// aggregatorDate is the date layout aggregator feeds use, the same as HTTP's
+const aggregatorDate = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+type aggregatorFeed struct {
+	XMLName       xml.Name        `xml:"source"`
+	Publisher     string          `xml:"publisher"`
+	PublisherURL  string          `xml:"publisherurl"`
+	LastBuildDate string          `xml:"lastBuildDate"`
+	Jobs          []aggregatorJob `xml:"job"`
+}
+
+type aggregatorJob struct {
+	Title           cdata  `xml:"title"`
+	Date            cdata  `xml:"date"`
+	ReferenceNumber cdata  `xml:"referencenumber"`
+	URL             cdata  `xml:"url"`
+	Company         cdata  `xml:"company"`
+	City            cdata  `xml:"city"`
+	Country         cdata  `xml:"country"`
+	Description     cdata  `xml:"description"`
+	Salary          *cdata `xml:"salary"`
+	JobType         *cdata `xml:"jobtype"`
+	Category        *cdata `xml:"category"`
+	Experience      *cdata `xml:"experience"`
+	RemoteType      *cdata `xml:"remotetype"`
+	ExpirationDate  *cdata `xml:"expirationdate"`
+}
+
+type cdata struct {
+	Text string `xml:",cdata"`
+}
+
+// MarshalXML replaces characters XML does not allow, such as control
+// characters, which encoding/xml only does outside CDATA sections. One of them
+// in a job would make the whole feed unreadable.
+func (c cdata) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	text := strings.Map(func(r rune) rune {
+		if xmlChar(r) {
+			return r
+		}
+		return unicode.ReplacementChar
+	}, c.Text)
+	return e.EncodeElement(struct {
+		Text string `xml:",cdata"`
+	}{text}, start)
+}
+
+// xmlChar reports whether r is allowed in an XML document (the Char production of XML 1.0)
+func xmlChar(r rune) bool {
+	return r == 0x09 || r == 0x0A || r == 0x0D ||
+		r >= 0x20 && r <= 0xD7FF ||
+		r >= 0xE000 && r <= 0xFFFD ||
+		r >= 0x10000 && r <= 0x10FFFF
+}
+
+// optionalCDATA leaves empty values out of the document
+func optionalCDATA(text string) *cdata {
+	if text == "" {
+		return nil
+	}
+	return &cdata{Text: text}
+}
+
+func aggregatorFeedOf(meta feedMeta, jobs []PublicJob) *aggregatorFeed {
+	feed := &aggregatorFeed{
+		Publisher:     meta.Title,
+		PublisherURL:  meta.SiteURL,
+		LastBuildDate: meta.Updated.UTC().Format(aggregatorDate),
+		Jobs:          make([]aggregatorJob, len(jobs)),
+	}
+	for i, job := range jobs {
+		entry := aggregatorJob{
+			Title:           cdata{job.Title},
+			Date:            cdata{job.DatePosted.UTC().Format(aggregatorDate)},
+			ReferenceNumber: cdata{strconv.FormatUint(uint64(job.ID), 10)},
+			URL:             cdata{job.URL},
+			Company:         cdata{job.CompanyName},
+			City:            cdata{job.LocationCity},
+			Country:         cdata{job.LocationCountry},
+			Description:     cdata{job.Description},
+			Salary:          optionalCDATA(salaryText(job)),
+			Category:        optionalCDATA(job.Department),
+			Experience:      optionalCDATA(strings.ToLower(string(job.Seniority))),
+			RemoteType:      optionalCDATA(strings.ToLower(string(job.RemotePolicy))),
+		}
+		// FULL_TIME becomes fulltime, and so on
+		entry.JobType = optionalCDATA(strings.ToLower(strings.ReplaceAll(string(job.EmploymentType), "_", "")))
+		if job.ClosesAt != nil {
+			entry.ExpirationDate = &cdata{job.ClosesAt.UTC().Format(aggregatorDate)}
+		}
+		feed.Jobs[i] = entry
+	}
+	return feed
+}
+
+// schema.org JobPosting (https://schema.org/JobPosting) as JSON-LD
+
+type jobPostingLD struct {
+	Context                       string           `json:"@context"`
+	Type                          string           `json:"@type"`
+	Title                         string           `json:"title"`
+	Description                   string           `json:"description"`
+	Identifier                    ldPropertyValue  `json:"identifier"`
+	URL                           string           `json:"url"`
+	DatePosted                    string           `json:"datePosted"`
+	ValidThrough                  string           `json:"validThrough,omitempty"`
+	EmploymentType                string           `json:"employmentType,omitempty"`
+	HiringOrganization            ldOrganization   `json:"hiringOrganization"`
+	JobLocation                   *ldPlace         `json:"jobLocation,omitempty"`
+	JobLocationType               string           `json:"jobLocationType,omitempty"`
+	ApplicantLocationRequirements *ldCountry       `json:"applicantLocationRequirements,omitempty"`
+	BaseSalary                    *ldMonetaryValue `json:"baseSalary,omitempty"`
+	OccupationalCategory          string           `json:"occupationalCategory,omitempty"`
+}
+
+type ldPropertyValue struct {
+	Type  string `json:"@type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type ldOrganization struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+type ldPlace struct {
+	Type    string            `json:"@type"`
+	Address ldPostalAddress   `json:"address"`
+	Geo     *ldGeoCoordinates `json:"geo,omitempty"`
+}
+
+type ldPostalAddress struct {
+	Type            string `json:"@type"`
+	AddressLocality string `json:"addressLocality,omitempty"`
+	AddressCountry  string `json:"addressCountry,omitempty"`
+}
+
+type ldGeoCoordinates struct {
+	Type      string  `json:"@type"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type ldCountry struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+type ldMonetaryValue struct {
+	Type     string              `json:"@type"`
+	Currency string              `json:"currency"`
+	Value    ldQuantitativeValue `json:"value"`
+}
+
+type ldQuantitativeValue struct {
+	Type     string `json:"@type"`
+	MinValue *int64 `json:"minValue,omitempty"`
+	MaxValue *int64 `json:"maxValue,omitempty"`
+	UnitText string `json:"unitText"`
+}
+
+// schemaEmploymentTypes maps employment types to schema.org's names
+var schemaEmploymentTypes = map[models.EmploymentType]string{
+	models.EmploymentFullTime:   "FULL_TIME",
+	models.EmploymentPartTime:   "PART_TIME",
+	models.EmploymentContract:   "CONTRACTOR",
+	models.EmploymentTemporary:  "TEMPORARY",
+	models.EmploymentInternship: "INTERN",
+}
+
+func jobPostingOf(job PublicJob) *jobPostingLD {
+	posting := &jobPostingLD{
+		Context: "https://schema.org/",
+		Type:    "JobPosting",
+		Title:   job.Title,
+		// Search engines expect HTML; descriptions are plain text
+		Description:          strings.ReplaceAll(html.EscapeString(job.Description), "\n", "<br>"),
+		Identifier:           ldPropertyValue{Type: "PropertyValue", Name: job.CompanyName, Value: strconv.FormatUint(uint64(job.ID), 10)},
+		URL:                  job.URL,
+		DatePosted:           job.DatePosted.UTC().Format(time.RFC3339),
+		EmploymentType:       schemaEmploymentTypes[job.EmploymentType],
+		HiringOrganization:   ldOrganization{Type: "Organization", Name: job.CompanyName},
+		OccupationalCategory: job.Department,
+	}
+	if job.ClosesAt != nil {
+		posting.ValidThrough = job.ClosesAt.UTC().Format(time.RFC3339)
+	}
+
+	if job.RemotePolicy == models.RemotePolicyRemote {
+		posting.JobLocationType = "TELECOMMUTE"
+		if job.LocationCountry != "" {
+			posting.ApplicantLocationRequirements = &ldCountry{Type: "Country", Name: job.LocationCountry}
+		}
+	} else if job.LocationCity != "" || job.LocationCountry != "" {
+		posting.JobLocation = &ldPlace{
+			Type:    "Place",
+			Address: ldPostalAddress{Type: "PostalAddress", AddressLocality: job.LocationCity, AddressCountry: job.LocationCountry},
+		}
+		if job.Latitude != nil && job.Longitude != nil {
+			posting.JobLocation.Geo = &ldGeoCoordinates{Type: "GeoCoordinates", Latitude: *job.Latitude, Longitude: *job.Longitude}
+		}
+	}
+
+	if job.SalaryCurrency != "" && (job.SalaryMin != nil || job.SalaryMax != nil) {
+		posting.BaseSalary = &ldMonetaryValue{
+			Type:     "MonetaryAmount",
+			Currency: job.SalaryCurrency,
+			Value: ldQuantitativeValue{
+				Type:     "QuantitativeValue",
+				MinValue: job.SalaryMin,
+				MaxValue: job.SalaryMax,
+				UnitText: string(job.SalaryPeriod),
+			},
+		}
+	}
+	return posting
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"html"
+	"io"
+	"strings"
+	"synergylabs/models"
+	"testing"
+	"time"
+)
+
+// hostileText breaks documents that paste text in without escaping it
+const hostileText = `Engineer </title></item><script>alert("x")</script> & <![CDATA[ ]]> 'R&D' é`
+
+func hostileJob() PublicJob {
+	min, max := int64(50000), int64(70000)
+	return PublicJob{
+		ID:              7,
+		URL:             "https://jobs.example.com/jobs/7?ref=a&b=<c>",
+		Title:           hostileText,
+		Description:     hostileText + "\nSecond line",
+		CompanyName:     `Smith & "Sons" <Ltd>`,
+		DatePosted:      time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC),
+		UpdatedAt:       time.Date(2026, 10, 2, 9, 0, 0, 0, time.UTC),
+		LocationCity:    "Zürich",
+		LocationCountry: "CH",
+		EmploymentType:  models.EmploymentFullTime,
+		Department:      "R&D <Platform>",
+		SalaryMin:       &min,
+		SalaryMax:       &max,
+		SalaryCurrency:  "CHF",
+		SalaryPeriod:    models.SalaryPerYear,
+	}
+}
+
+func TestFeedsEscapeText(t *testing.T) {
+	meta := feedMeta{Title: "Jobs at <Smith & Sons>", SelfURL: "https://api.example.com/feeds/jobs.atom?organization=a&b", SiteURL: "https://jobs.example.com/jobs", Updated: time.Now()}
+	job := hostileJob()
+
+	// Each document must parse back to exactly the text that went in
+	tests := []struct {
+		format FeedFormat
+		decode func(body []byte) (title, description, link string, err error)
+	}{
+		{FeedAtom, func(body []byte) (string, string, string, error) {
+			var feed atomFeed
+			err := xml.Unmarshal(body, &feed)
+			if err != nil || len(feed.Entries) != 1 {
+				return "", "", "", err
+			}
+			return feed.Entries[0].Title, feed.Entries[0].Content.Text, feed.Entries[0].Link.Href, nil
+		}},
+		{FeedRSS, func(body []byte) (string, string, string, error) {
+			var feed rssFeed
+			err := xml.Unmarshal(body, &feed)
+			if err != nil || len(feed.Channel.Items) != 1 {
+				return "", "", "", err
+			}
+			return feed.Channel.Items[0].Title, feed.Channel.Items[0].Description, feed.Channel.Items[0].Link, nil
+		}},
+		{FeedXML, func(body []byte) (string, string, string, error) {
+			var feed aggregatorFeed
+			err := xml.Unmarshal(body, &feed)
+			if err != nil || len(feed.Jobs) != 1 {
+				return "", "", "", err
+			}
+			return feed.Jobs[0].Title.Text, feed.Jobs[0].Description.Text, feed.Jobs[0].URL.Text, nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			body, err := renderFeed(tt.format, meta, []PublicJob{job})
+			if err != nil {
+				t.Fatalf("renderFeed: %v", err)
+			}
+			title, description, link, err := tt.decode(body)
+			if err != nil {
+				t.Fatalf("parsing the document: %v\n%s", err, body)
+			}
+			if title != job.Title || description != job.Description || link != job.URL {
+				t.Errorf("parsed title %q, description %q, link %q; want what was rendered", title, description, link)
+			}
+		})
+	}
+}
+
+func TestFeedsReplaceCharactersXMLForbids(t *testing.T) {
+	job := hostileJob()
+	job.Title = "Night\x00shift\x0b lead\uFFFE"
+	job.Description = "Tabs\tand\r\nnewlines stay\x1b"
+
+	for _, format := range []FeedFormat{FeedAtom, FeedRSS, FeedXML} {
+		t.Run(string(format), func(t *testing.T) {
+			body, err := renderFeed(format, feedMeta{Title: "Jobs", Updated: time.Now()}, []PublicJob{job})
+			if err != nil {
+				t.Fatalf("renderFeed: %v", err)
+			}
+			// A crawler's strict parser must still read the whole feed
+			decoder := xml.NewDecoder(bytes.NewReader(body))
+			var text strings.Builder
+			for {
+				token, err := decoder.Token()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("feed is not well-formed XML: %v\n%s", err, body)
+				}
+				if data, ok := token.(xml.CharData); ok {
+					text.Write(data)
+				}
+			}
+			if !strings.Contains(text.String(), "Night\uFFFDshift\uFFFD lead\uFFFD") || !strings.Contains(text.String(), "Tabs\tand") {
+				t.Errorf("feed text = %q, want forbidden characters replaced and the rest kept", text.String())
+			}
+		})
+	}
+}
+
+func TestJobPostingEscapesHTML(t *testing.T) {
+	job := hostileJob()
+	body, err := renderJob(FeedJSONLD, job)
+	if err != nil {
+		t.Fatalf("renderJob: %v", err)
+	}
+	// JSON-LD is embedded in a <script> element, so it must not be able to close it
+	if bytes.ContainsAny(body, "<>") {
+		t.Errorf("JSON-LD contains raw angle brackets:\n%s", body)
+	}
+
+	var posting jobPostingLD
+	if err := json.Unmarshal(body, &posting); err != nil {
+		t.Fatalf("decoding JSON-LD: %v", err)
+	}
+	if posting.Title != job.Title {
+		t.Errorf("title = %q, want %q", posting.Title, job.Title)
+	}
+	// The description is HTML, so the text in it is escaped and lines are breaks
+	if want := html.EscapeString(hostileText) + "<br>Second line"; posting.Description != want {
+		t.Errorf("description = %q, want %q", posting.Description, want)
+	}
+	if posting.HiringOrganization.Name != job.CompanyName || posting.BaseSalary == nil || *posting.BaseSalary.Value.MinValue != 50000 {
+		t.Errorf("posting = %+v", posting)
+	}
+	if posting.JobLocation == nil || posting.JobLocation.Address.AddressLocality != "Zürich" || posting.EmploymentType != "FULL_TIME" {
+		t.Errorf("location %+v, employment type %q", posting.JobLocation, posting.EmploymentType)
+	}
+}
+
+func TestAggregatorFeedFields(t *testing.T) {
+	closes := time.Date(2026, 12, 31, 17, 0, 0, 0, time.UTC)
+	job := hostileJob()
+	job.ClosesAt = &closes
+	job.RemotePolicy = models.RemotePolicyRemote
+
+	body, err := renderFeed(FeedXML, feedMeta{Title: "Jobs", Updated: time.Now()}, []PublicJob{job})
+	if err != nil {
+		t.Fatalf("renderFeed: %v", err)
+	}
+	var feed aggregatorFeed
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		t.Fatalf("parsing the feed: %v", err)
+	}
+	got := feed.Jobs[0]
+	for name, field := range map[string]struct{ got, want string }{
+		"date":            {got.Date.Text, "Thu, 01 Oct 2026 09:00:00 GMT"},
+		"referencenumber": {got.ReferenceNumber.Text, "7"},
+		"salary":          {got.Salary.Text, "50000-70000 CHF per year"},
+		"jobtype":         {got.JobType.Text, "fulltime"},
+		"remotetype":      {got.RemoteType.Text, "remote"},
+		"expirationdate":  {got.ExpirationDate.Text, "Thu, 31 Dec 2026 17:00:00 GMT"},
+	} {
+		if field.got != field.want {
+			t.Errorf("%s = %q, want %q", name, field.got, field.want)
+		}
+	}
+	// Empty values are left out rather than sent as empty elements
+	if got.Experience != nil || bytes.Contains(body, []byte("<experience>")) {
+		t.Errorf("experience = %+v, want none", got.Experience)
+	}
+}
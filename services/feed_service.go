@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"synergylabs/models"
+	"synergylabs/services/cache"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxFeedJobs bounds the newest jobs a public listing or feed includes
+	maxFeedJobs = 500
+
+	// publicDocumentTTL bounds how long a rendered document is served. Any
+	// change to a job moves documents to a new generation sooner.
+	publicDocumentTTL = 5 * time.Minute
+)
+
+// FeedService renders published jobs for people and crawlers that are not
+// logged in. Documents are cached in Redis under the job listing generation.
+type FeedService struct {
+	db     *gorm.DB
+	cache  *cache.Cache
+	jobs   *JobService
+	logger *zap.Logger
+	// baseURL is the front-end address job pages live under, at /jobs/:id
+	baseURL string
+	// publicURL is the address of this API, for the feeds' links to themselves
+	publicURL string
+	title     string
+}
+
+var _ FeedServiceInterface = (*FeedService)(nil)
+
+func NewFeedService(db *gorm.DB, cache *cache.Cache, jobs *JobService, logger *zap.Logger, baseURL, publicURL, title string) *FeedService {
+	return &FeedService{
+		db:        db,
+		cache:     cache,
+		jobs:      jobs,
+		logger:    logger,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+		title:     title,
+	}
+}
+
+// JobsFeed renders the newest published jobs, optionally only those of the
+// organization with the given slug. selfPath is the path the feed is served at.
+func (s *FeedService) JobsFeed(ctx context.Context, format FeedFormat, organization, selfPath string) (*PublicDocument, error) {
+	key := fmt.Sprintf("%s:%d:%s:%s", PublicJobsCacheKey, s.jobs.listingGeneration(ctx), format, organization)
+	return s.cached(ctx, key, format, func() ([]byte, time.Time, error) {
+		query := s.db.WithContext(ctx).Scopes(openJobScope(time.Now()))
+		meta := feedMeta{Title: s.title, SiteURL: s.baseURL + "/jobs", SelfURL: s.publicURL + selfPath}
+		if organization != "" {
+			var org models.Organization
+			if err := s.db.WithContext(ctx).Where("slug = ?", organization).First(&org).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, time.Time{}, ErrOrganizationNotFound
+				}
+				return nil, time.Time{}, err
+			}
+			query = query.Where("jobs.organization_id = ?", org.ID)
+			meta.Title = org.Name + " jobs"
+			meta.SelfURL += "?organization=" + url.QueryEscape(org.Slug)
+			if org.Settings.CareersPageURL != "" {
+				meta.SiteURL = org.Settings.CareersPageURL
+			}
+		}
+
+		var jobs []models.Job
+		if err := query.Order("jobs.posted_on DESC, jobs.id DESC").Limit(maxFeedJobs).Find(&jobs).Error; err != nil {
+			return nil, time.Time{}, err
+		}
+		public := make([]PublicJob, len(jobs))
+		for i := range jobs {
+			public[i] = s.publicJob(&jobs[i])
+			if public[i].UpdatedAt.After(meta.Updated) {
+				meta.Updated = public[i].UpdatedAt
+			}
+		}
+		if meta.Updated.IsZero() {
+			meta.Updated = time.Now()
+		}
+		body, err := renderFeed(format, meta, public)
+		return body, meta.Updated, err
+	})
+}
+
+// JobDocument renders one published job as public JSON or a schema.org JobPosting
+func (s *FeedService) JobDocument(ctx context.Context, id uint, format FeedFormat) (*PublicDocument, error) {
+	key := fmt.Sprintf("%s:%d:job:%d:%s", PublicJobsCacheKey, s.jobs.listingGeneration(ctx), id, format)
+	return s.cached(ctx, key, format, func() ([]byte, time.Time, error) {
+		var job models.Job
+		if err := s.db.WithContext(ctx).Scopes(openJobScope(time.Now())).First(&job, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, time.Time{}, ErrJobNotFound
+			}
+			return nil, time.Time{}, err
+		}
+		body, err := renderJob(format, s.publicJob(&job))
+		return body, job.UpdatedAt, err
+	})
+}
+
+// cached returns the document under key, rendering and caching it on a miss.
+// render returns the body and when its content last changed.
+func (s *FeedService) cached(ctx context.Context, key string, format FeedFormat, render func() ([]byte, time.Time, error)) (*PublicDocument, error) {
+	var doc PublicDocument
+	if err := s.cache.Get(ctx, key, &doc); err == nil {
+		return &doc, nil
+	}
+
+	body, modified, err := render()
+	if err != nil {
+		if !errors.Is(err, ErrJobNotFound) && !errors.Is(err, ErrOrganizationNotFound) {
+			s.logger.Error("Failed to render public job document", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	sum := sha256.Sum256(body)
+	doc = PublicDocument{
+		ContentType: format.ContentType(),
+		Body:        body,
+		ETag:        `"` + hex.EncodeToString(sum[:16]) + `"`,
+		// HTTP dates have whole seconds
+		LastModified: modified.UTC().Truncate(time.Second),
+	}
+	if err := s.cache.Set(ctx, key, doc, publicDocumentTTL); err != nil {
+		s.logger.Warn("Failed to cache public job document", zap.Error(err))
+	}
+	return &doc, nil
+}
+
+// publicJob leaves out everything about a job that is not public
+func (s *FeedService) publicJob(job *models.Job) PublicJob {
+	// Scheduled jobs went public when publish_at passed, not when they were created
+	posted := job.PostedOn
+	if job.PublishAt != nil && job.PublishAt.After(posted) {
+		posted = *job.PublishAt
+	}
+	return PublicJob{
+		ID:              job.ID,
+		URL:             fmt.Sprintf("%s/jobs/%d", s.baseURL, job.ID),
+		Title:           job.Title,
+		Description:     job.Description,
+		CompanyName:     job.CompanyName,
+		DatePosted:      posted,
+		UpdatedAt:       job.UpdatedAt,
+		ClosesAt:        job.ClosesAt,
+		LocationCity:    job.LocationCity,
+		LocationCountry: job.LocationCountry,
+		Latitude:        job.Latitude,
+		Longitude:       job.Longitude,
+		RemotePolicy:    job.RemotePolicy,
+		EmploymentType:  job.EmploymentType,
+		Seniority:       job.Seniority,
+		Department:      job.Department,
+		SalaryMin:       job.SalaryMin,
+		SalaryMax:       job.SalaryMax,
+		SalaryCurrency:  job.SalaryCurrency,
+		SalaryPeriod:    job.SalaryPeriod,
+	}
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"synergylabs/models"
+	"testing"
+	"time"
+)
+
+func TestJobsFeedListsOnlyOpenJobsOfTheOrganization(t *testing.T) {
+	conn, c := testDB(t), testCache(t)
+	jobs := NewJobService(conn, c, testLogger())
+	s := NewFeedService(conn, c, jobs, testLogger(), "https://jobs.example.com", "https://api.example.com", "Jobs")
+	ctx := context.Background()
+
+	var orgs [2]models.Organization
+	for i := range orgs {
+		slug := testSSODomain()
+		orgs[i] = models.Organization{Name: slug, Slug: slug}
+		if err := conn.Create(&orgs[i]).Error; err != nil {
+			t.Fatalf("creating organization: %v", err)
+		}
+	}
+	poster := createTestStaff(t, jobs, "feeds")[0]
+
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	listed := []models.Job{
+		{Title: "Published", Status: models.JobStatusPublished},
+		{Title: "Scheduled draft that went live", Status: models.JobStatusDraft, PublishAt: &past},
+	}
+	unlisted := []models.Job{
+		{Title: "Draft", Status: models.JobStatusDraft},
+		{Title: "Scheduled draft", Status: models.JobStatusDraft, PublishAt: &future},
+		{Title: "Closed", Status: models.JobStatusClosed},
+		{Title: "Paused", Status: models.JobStatusPaused},
+		{Title: "Past its closing date", Status: models.JobStatusPublished, ClosesAt: &past},
+		{Title: "Other organization", Status: models.JobStatusPublished, OrganizationID: &orgs[1].ID},
+	}
+	for _, group := range [][]models.Job{listed, unlisted} {
+		for i := range group {
+			group[i].Description = "Feed test"
+			group[i].PostedByID = poster.ID
+			if group[i].OrganizationID == nil {
+				group[i].OrganizationID = &orgs[0].ID
+			}
+			if err := conn.Create(&group[i]).Error; err != nil {
+				t.Fatalf("creating %s job: %v", group[i].Title, err)
+			}
+		}
+	}
+
+	doc, err := s.JobsFeed(ctx, FeedJSON, orgs[0].Slug, "/feeds/jobs.json")
+	if err != nil {
+		t.Fatalf("JobsFeed: %v", err)
+	}
+	var feed struct {
+		Data []PublicJob `json:"data"`
+	}
+	if err := json.Unmarshal(doc.Body, &feed); err != nil {
+		t.Fatalf("decoding feed: %v", err)
+	}
+	titles := map[string]bool{}
+	for _, job := range feed.Data {
+		titles[job.Title] = true
+	}
+	for _, job := range listed {
+		if !titles[job.Title] {
+			t.Errorf("%q is missing from the feed", job.Title)
+		}
+	}
+	for _, job := range unlisted {
+		if titles[job.Title] {
+			t.Errorf("%q is in the feed", job.Title)
+		}
+	}
+	if len(feed.Data) != len(listed) {
+		t.Errorf("feed has %d jobs, want %d", len(feed.Data), len(listed))
+	}
+
+	// Validators come from the content, so the same feed keeps them
+	newest := listed[0].UpdatedAt
+	if listed[1].UpdatedAt.After(newest) {
+		newest = listed[1].UpdatedAt
+	}
+	if !doc.LastModified.Equal(newest.UTC().Truncate(time.Second)) {
+		t.Errorf("LastModified = %s, want %s", doc.LastModified, newest.UTC().Truncate(time.Second))
+	}
+	again, err := s.JobsFeed(ctx, FeedJSON, orgs[0].Slug, "/feeds/jobs.json")
+	if err != nil {
+		t.Fatalf("JobsFeed: %v", err)
+	}
+	if doc.ETag == "" || again.ETag != doc.ETag {
+		t.Errorf("ETag = %s then %s, want the same", doc.ETag, again.ETag)
+	}
+	atom, err := s.JobsFeed(ctx, FeedAtom, orgs[0].Slug, "/feeds/jobs.atom")
+	if err != nil {
+		t.Fatalf("JobsFeed: %v", err)
+	}
+	if atom.ETag == doc.ETag || atom.ContentType != FeedAtom.ContentType() {
+		t.Errorf("Atom feed has ETag %s and type %s", atom.ETag, atom.ContentType)
+	}
+
+	if _, err := s.JobsFeed(ctx, FeedJSON, testSSODomain(), "/feeds/jobs.json"); !errors.Is(err, ErrOrganizationNotFound) {
+		t.Errorf("JobsFeed for an unknown organization = %v, want ErrOrganizationNotFound", err)
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"synergylabs/models"
+	"synergylabs/util"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ImpersonationService struct {
+	db     *gorm.DB
+	audit  *AuditService
+	logger *zap.Logger
+}
+
+var _ ImpersonationServiceInterface = (*ImpersonationService)(nil)
+
+func NewImpersonationService(db *gorm.DB, audit *AuditService, logger *zap.Logger) *ImpersonationService {
+	return &ImpersonationService{
+		db:     db,
+		audit:  audit,
+		logger: logger,
+	}
+}
+
+// Impersonate issues adminID a read-only access token acting as an applicant.
+// The token has no refresh token and expires with a normal access token.
+func (s *ImpersonationService) Impersonate(ctx context.Context, adminID, userID uint) (*ImpersonationToken, error) {
+	var user models.User
+	if err := s.db.WithContext(ctx).Scopes(applicantTenantScope(ctx)).First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		s.logger.Error("Failed to fetch user to impersonate", zap.Error(err))
+		return nil, err
+	}
+	if user.UserType != models.UserTypeApplicant {
+		return nil, ErrNotApplicant
+	}
+
+	token, err := util.GenerateToken(user.ID, string(user.UserType), util.WithImpersonator(adminID))
+	if err != nil {
+		s.logger.Error("Failed to generate impersonation token", zap.Error(err))
+		return nil, err
+	}
+
+	request := util.RequestInfoFromContext(ctx)
+	if err := s.audit.Record(ctx, &models.AuditEvent{
+		ActorID:       &adminID,
+		SubjectUserID: &user.ID,
+		Action:        models.AuditActionImpersonationStart,
+		EntityType:    "user",
+		EntityID:      strconv.FormatUint(uint64(user.ID), 10),
+		RequestID:     request.ID,
+		IP:            request.IP,
+	}); err != nil {
+		// Impersonation is only allowed when it can be audited
+		return nil, err
+	}
+
+	securityEvent(s.logger, "impersonation_started", zap.Uint("admin_id", adminID), zap.Uint("user_id", user.ID))
+	return &ImpersonationToken{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(util.AccessTokenTTL.Seconds()),
+		UserID:      user.ID,
+	}, nil
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"synergylabs/models"
+	"synergylabs/util"
+	"testing"
+)
+
+func TestImpersonateOnlyApplicantsOfTheTenant(t *testing.T) {
+	conn := testDB(t)
+	testKeyring(t)
+	s := NewImpersonationService(conn, NewAuditService(conn, testLogger()), testLogger())
+
+	var orgs [2]models.Organization
+	for i := range orgs {
+		slug := testSSODomain()
+		orgs[i] = models.Organization{Name: slug, Slug: slug}
+		if err := conn.Create(&orgs[i]).Error; err != nil {
+			t.Fatalf("creating organization: %v", err)
+		}
+	}
+	admin := models.User{Name: "admin", Email: testEmail("admin"), UserType: models.UserTypeAdmin, OrganizationID: &orgs[0].ID}
+	applicant := models.User{Name: "applicant", Email: testEmail("applicant"), UserType: models.UserTypeApplicant}
+	for _, user := range []*models.User{&admin, &applicant} {
+		if err := conn.Create(user).Error; err != nil {
+			t.Fatalf("creating %s: %v", user.Name, err)
+		}
+	}
+	job := models.Job{Title: "Impersonated", Description: "Applied to", PostedByID: admin.ID, OrganizationID: &orgs[0].ID}
+	if err := conn.Create(&job).Error; err != nil {
+		t.Fatalf("creating job: %v", err)
+	}
+	if err := conn.Create(&models.JobApplication{JobID: job.ID, UserID: applicant.ID}).Error; err != nil {
+		t.Fatalf("creating application: %v", err)
+	}
+
+	own := util.ContextWithOrganization(context.Background(), orgs[0].ID)
+	token, err := s.Impersonate(own, admin.ID, applicant.ID)
+	if err != nil {
+		t.Fatalf("Impersonate: %v", err)
+	}
+	claims, err := util.ValidateToken(token.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if claims.UserId != applicant.ID || claims.ImpersonatorID != admin.ID {
+		t.Errorf("token for user %d by %d, want %d by %d", claims.UserId, claims.ImpersonatorID, applicant.ID, admin.ID)
+	}
+	var started int64
+	if err := conn.Model(&models.AuditEvent{}).
+		Where("action = ? AND actor_id = ? AND subject_user_id = ? AND entity_id = ?",
+			models.AuditActionImpersonationStart, admin.ID, applicant.ID, strconv.FormatUint(uint64(applicant.ID), 10)).
+		Count(&started).Error; err != nil {
+		t.Fatalf("counting audit events: %v", err)
+	}
+	if started != 1 {
+		t.Errorf("%d impersonation start events, want 1", started)
+	}
+
+	// Applicants of another organization's jobs do not exist for the caller
+	other := util.ContextWithOrganization(context.Background(), orgs[1].ID)
+	if _, err := s.Impersonate(other, admin.ID, applicant.ID); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Impersonate from another organization = %v, want ErrUserNotFound", err)
+	}
+	if _, err := s.Impersonate(platformAdminContext(), admin.ID, admin.ID); !errors.Is(err, ErrNotApplicant) {
+		t.Errorf("Impersonate a staff member = %v, want ErrNotApplicant", err)
+	}
+}
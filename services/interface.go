@@ -2,29 +2,147 @@ package services
 
 import (
 	"context"
+	"io"
 	"mime/multipart"
 	"synergylabs/models"
+	"synergylabs/util"
+	"time"
 )
 
 type UserServiceInterface interface {
 	CreateUser(ctx context.Context, user *models.User) error
-	ValidateLogin(ctx context.Context, email, password string) (*models.User, error)
-	GetAllApplicants(ctx context.Context, page, pageSize int) (*PaginatedResponse, error)
+	ValidateLogin(ctx context.Context, email, password, ip string) (*models.User, error)
+	UnlockAccount(ctx context.Context, id uint) error
+	GetAllApplicants(ctx context.Context, req PageRequest) (*PaginatedResponse, error)
 	GetApplicantWithProfile(ctx context.Context, id uint) (*models.User, error)
+	GetUser(ctx context.Context, id uint) (*models.User, error)
 	UpdateUser(ctx context.Context, user *models.User) error
 	DeleteUser(ctx context.Context, id uint) error
+	GetAccount(ctx context.Context, id uint) (*models.User, error)
+	UpdateAccount(ctx context.Context, id uint, update AccountUpdate) (*models.User, error)
+	UpdateProfile(ctx context.Context, id uint, update ProfileUpdate) (*models.Profile, error)
+	ChangePassword(ctx context.Context, id uint, currentPassword, newPassword, ip string) error
+	CloseAccount(ctx context.Context, id uint, password, ip string) error
 }
 
 type JobServiceInterface interface {
 	CreateJob(ctx context.Context, job *models.Job) error
 	GetJobs(ctx context.Context, filters JobFilters) (*PaginatedResponse, error)
 	GetJobWithApplicants(ctx context.Context, id uint) (*models.Job, error)
-	ApplyToJob(ctx context.Context, jobID, userID uint) error
-	UpdateJob(ctx context.Context, job *models.Job) error
-	DeleteJob(ctx context.Context, id uint) error
+	JobOwner(ctx context.Context, id uint) (*models.Job, error)
+	ApplyToJob(ctx context.Context, jobID, userID uint, answers []ApplicationAnswer) (*models.JobApplication, error)
+	UpdateJob(ctx context.Context, id uint, changes *models.Job, fields []string, pre JobPrecondition) (*models.Job, error)
+	DeleteJob(ctx context.Context, id uint, pre JobPrecondition) error
+	TransitionJob(ctx context.Context, id uint, to models.JobStatus, pre JobPrecondition) (*models.Job, error)
+	ListJobTransitions(ctx context.Context, id uint) ([]models.JobStatusTransition, error)
+	ListScreeningQuestions(ctx context.Context, jobID uint) ([]models.ScreeningQuestion, error)
+	ApplicantScreeningQuestions(ctx context.Context, jobID uint) ([]models.ScreeningQuestion, error)
+	ReplaceScreeningQuestions(ctx context.Context, jobID uint, questions []models.ScreeningQuestion, pre JobPrecondition) ([]models.ScreeningQuestion, error)
+	ListJobApplications(ctx context.Context, jobID uint, status models.ApplicationStatus) ([]models.JobApplication, error)
+	AssignUnownedJobs(ctx context.Context, posterID uint) (int, error)
+	CloneJob(ctx context.Context, id, posterID uint) (*models.Job, error)
+	ImportJobs(ctx context.Context, r io.Reader, opts JobImportOptions) (*JobImportReport, error)
+	ExportJobs(ctx context.Context, w io.Writer, format JobDataFormat, status models.JobStatus) (int, error)
+	ApplyScheduledTransitions(ctx context.Context) (int, error)
+	WarmJobsCache(ctx context.Context) (int, error)
+}
+
+type FeedServiceInterface interface {
+	JobsFeed(ctx context.Context, format FeedFormat, organization, selfPath string) (*PublicDocument, error)
+	JobDocument(ctx context.Context, id uint, format FeedFormat) (*PublicDocument, error)
+}
+
+type JobTemplateServiceInterface interface {
+	ListTemplates(ctx context.Context) ([]JobTemplateView, error)
+	GetTemplate(ctx context.Context, id uint) (*JobTemplateView, error)
+	CreateTemplate(ctx context.Context, template *models.JobTemplate) error
+	UpdateTemplate(ctx context.Context, template *models.JobTemplate) error
+	DeleteTemplate(ctx context.Context, id uint) error
+	RenderTemplate(ctx context.Context, id uint, vars map[string]string) (*models.Job, error)
 }
 
 type ResumeServiceInterface interface {
 	ProcessResume(ctx context.Context, file *multipart.FileHeader, userID uint) error
 	GetResumeData(ctx context.Context, userID uint) (*models.Profile, error)
 }
+
+type TokenServiceInterface interface {
+	IssueTokens(ctx context.Context, user *models.User, client ClientInfo) (*TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error)
+	Revoke(ctx context.Context, claims *util.Claims) error
+	RevokeFamily(ctx context.Context, family string) error
+	Logout(ctx context.Context, claims *util.Claims) error
+	ListSessions(ctx context.Context, userID uint, currentFamily string) ([]SessionView, error)
+	RevokeSession(ctx context.Context, userID, id uint) error
+	RevokeAllSessions(ctx context.Context, userID uint) error
+	RevokeOtherSessions(ctx context.Context, userID uint, currentFamily string) error
+	PurgeExpiredSessions(ctx context.Context, before time.Time) (int64, error)
+}
+
+type InvitationServiceInterface interface {
+	CreateInvitation(ctx context.Context, invitation *models.Invitation) (string, error)
+	ListInvitations(ctx context.Context, page, pageSize int) (*PaginatedResponse, error)
+	RevokeInvitation(ctx context.Context, id uint) error
+	AcceptInvitation(ctx context.Context, token string, user *models.User) error
+	PurgeExpiredInvitations(ctx context.Context, before time.Time) (int64, error)
+}
+
+type AccountServiceInterface interface {
+	RequestPasswordReset(ctx context.Context, email string)
+	ResetPassword(ctx context.Context, token, newPassword string) (uint, error)
+	SendEmailVerification(ctx context.Context, user *models.User) error
+	ResendEmailVerification(ctx context.Context, email string)
+	VerifyEmail(ctx context.Context, token string) error
+	PurgeExpiredTokens(ctx context.Context, before time.Time) (int64, error)
+}
+
+type MFAServiceInterface interface {
+	Required(user *models.User) bool
+	BeginEnrollment(ctx context.Context, userID uint) (*MFAEnrollment, error)
+	ConfirmEnrollment(ctx context.Context, userID uint, code string) ([]string, error)
+	Verify(ctx context.Context, userID uint, attemptKey, code, recoveryCode string) error
+	Disable(ctx context.Context, userID uint, code string) error
+}
+
+type RBACServiceInterface interface {
+	Permissions(ctx context.Context, userID uint) ([]models.Permission, error)
+	ListRoles(ctx context.Context) ([]models.Role, error)
+	CreateRole(ctx context.Context, role *models.Role) error
+	UpdateRole(ctx context.Context, role *models.Role) error
+	DeleteRole(ctx context.Context, id uint) error
+	GetUserRoles(ctx context.Context, userID uint) ([]models.Role, error)
+	AssignRole(ctx context.Context, userID, roleID uint) error
+	RemoveRole(ctx context.Context, userID, roleID uint) error
+}
+
+type OrganizationServiceInterface interface {
+	CreateOrganization(ctx context.Context, org *models.Organization) error
+	ListOrganizations(ctx context.Context, page, pageSize int) (*PaginatedResponse, error)
+	GetOrganization(ctx context.Context, id uint) (*models.Organization, error)
+	UpdateSettings(ctx context.Context, id uint, settings models.OrganizationSettings) (*models.Organization, error)
+	ListMembers(ctx context.Context, id uint) ([]models.User, error)
+	AddMember(ctx context.Context, id, userID uint) error
+	RemoveMember(ctx context.Context, id, userID uint) error
+}
+
+type APIKeyServiceInterface interface {
+	CreateAPIKey(ctx context.Context, apiKey *models.APIKey) (string, error)
+	ListAPIKeys(ctx context.Context, userID uint) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, id uint) error
+	AuthenticateAPIKey(ctx context.Context, key string) (*util.APIKeyPrincipal, error)
+}
+
+type SSOServiceInterface interface {
+	BeginLogin(ctx context.Context) (string, string, error)
+	CompleteLogin(ctx context.Context, code, state, browserState string) (*models.User, error)
+}
+
+type AuditServiceInterface interface {
+	Record(ctx context.Context, event *models.AuditEvent) error
+	ListEvents(ctx context.Context, filters AuditFilters) (*PaginatedResponse, error)
+	VerifyChain(ctx context.Context) (*AuditVerification, error)
+}
+
+type ImpersonationServiceInterface interface {
+	Impersonate(ctx context.Context, adminID, userID uint) (*ImpersonationToken, error)
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"synergylabs/models"
+	"synergylabs/util"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DefaultInvitationTTL = 72 * time.Hour
+	MaxInvitationTTL     = 30 * 24 * time.Hour
+)
+
+type InvitationService struct {
+	db          *gorm.DB
+	permissions util.PermissionResolver
+	logger      *zap.Logger
+}
+
+var _ InvitationServiceInterface = (*InvitationService)(nil)
+
+// NewInvitationService creates the invitation service. permissions resolves
+// what inviters hold, since they cannot invite into a role granting more.
+func NewInvitationService(db *gorm.DB, permissions util.PermissionResolver, logger *zap.Logger) *InvitationService {
+	return &InvitationService{
+		db:          db,
+		permissions: permissions,
+		logger:      logger,
+	}
+}
+
+// CreateInvitation records an invitation and returns it with its single-use token.
+// The token is only returned here; the database keeps its hash. The inviter
+// must hold every permission of the role the invitation is for.
+func (s *InvitationService) CreateInvitation(ctx context.Context, invitation *models.Invitation) (string, error) {
+	invitation.Email = normalizeEmail(invitation.Email)
+
+	var role models.Role
+	if err := s.db.WithContext(ctx).Where("name = ?", invitation.Role).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrRoleNotFound
+		}
+		s.logger.Error("Failed to check invitation role", zap.Error(err))
+		return "", err
+	}
+	if err := checkGrantable(ctx, s.permissions, invitation.InvitedByID, &role); err != nil {
+		return "", err
+	}
+
+	// Organization members can only invite into their own organization, and
+	// only platform administrators choose another
+	if orgID, ok := util.OrganizationFromContext(ctx); ok {
+		invitation.OrganizationID = &orgID
+	} else if invitation.OrganizationID != nil && !util.SeesAllOrganizations(ctx) {
+		return "", ErrPlatformAdminRequired
+	}
+	if invitation.OrganizationID != nil {
+		var org models.Organization
+		if err := s.db.WithContext(ctx).First(&org, *invitation.OrganizationID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return "", ErrOrganizationNotFound
+			}
+			s.logger.Error("Failed to fetch organization", zap.Error(err))
+			return "", err
+		}
+		if models.UserTypeForRole(invitation.Role) != models.UserTypeAdmin {
+			return "", ErrNotStaff
+		}
+		if !org.Settings.AllowsEmail(invitation.Email) {
+			return "", ErrEmailDomainNotAllowed
+		}
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.User{}).
+		Scopes(emailScope(invitation.Email)).
+		Count(&count).Error; err != nil {
+		s.logger.Error("Failed to check existing user", zap.Error(err))
+		return "", err
+	}
+	if count > 0 {
+		return "", ErrEmailExists
+	}
+
+	var token string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The token embeds the invitation ID, so the row is created first
+		if err := tx.Create(invitation).Error; err != nil {
+			return err
+		}
+
+		var err error
+		token, err = util.GenerateInviteToken(invitation.ID, invitation.ExpiresAt)
+		if err != nil {
+			return err
+		}
+
+		invitation.TokenHash = util.HashToken(token)
+		if err := tx.Model(invitation).Update("token_hash", invitation.TokenHash).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditActionInvitationCreate, "invitation", invitation.ID, nil, invitation)
+	})
+	if err != nil {
+		s.logger.Error("Failed to create invitation", zap.Error(err))
+		return "", err
+	}
+
+	s.logger.Info("Invitation created",
+		zap.Uint("invitation_id", invitation.ID),
+		zap.Uint("invited_by_id", invitation.InvitedByID),
+		zap.String("role", invitation.Role),
+	)
+
+	return token, nil
+}
+
+func (s *InvitationService) ListInvitations(ctx context.Context, page, pageSize int) (*PaginatedResponse, error) {
+	var total int64
+	if err := s.db.WithContext(ctx).Model(&models.Invitation{}).Scopes(invitationTenantScope(ctx)).Count(&total).Error; err != nil {
+		s.logger.Error("Failed to count invitations", zap.Error(err))
+		return nil, err
+	}
+
+	var invitations []models.Invitation
+	if err := s.db.WithContext(ctx).
+		Scopes(invitationTenantScope(ctx)).
+		Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&invitations).Error; err != nil {
+		s.logger.Error("Failed to fetch invitations", zap.Error(err))
+		return nil, err
+	}
+
+	data := make([]InvitationView, 0, len(invitations))
+	for i := range invitations {
+		data = append(data, InvitationView{Invitation: invitations[i], Status: invitations[i].Status()})
+	}
+
+	response := newPaginatedResponse(data, total, page, pageSize)
+	return &response, nil
+}
+
+func (s *InvitationService) RevokeInvitation(ctx context.Context, id uint) error {
+	var invitation models.Invitation
+	if err := s.db.WithContext(ctx).Scopes(invitationTenantScope(ctx)).First(&invitation, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvitationNotFound
+		}
+		s.logger.Error("Failed to fetch invitation", zap.Error(err))
+		return err
+	}
+
+	if invitation.AcceptedAt != nil {
+		return ErrInvitationAccepted
+	}
+	if invitation.RevokedAt != nil {
+		return nil
+	}
+
+	before := invitation
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&invitation).Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditActionInvitationRevoke, "invitation", invitation.ID, &before, &invitation)
+	})
+	if err != nil {
+		s.logger.Error("Failed to revoke invitation", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Invitation revoked", zap.Uint("invitation_id", id))
+	return nil
+}
+
+// AcceptInvitation consumes an invite token and creates the invited account
+func (s *InvitationService) AcceptInvitation(ctx context.Context, token string, user *models.User) error {
+	claims, err := util.ValidateInviteToken(token)
+	if err != nil {
+		return ErrInvalidInvitation
+	}
+	if err := validatePassword(user.PasswordHash); err != nil {
+		return err
+	}
+
+	hashedPassword, err := hashPassword(user.PasswordHash)
+	if err != nil {
+		s.logger.Error("Failed to hash password", zap.Error(err))
+		return err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var invitation models.Invitation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&invitation, claims.InviteID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidInvitation
+			}
+			return err
+		}
+
+		if invitation.TokenHash != util.HashToken(token) {
+			return ErrInvalidInvitation
+		}
+		switch invitation.Status() {
+		case models.InvitationStatusAccepted:
+			return ErrInvitationAccepted
+		case models.InvitationStatusRevoked:
+			return ErrInvitationRevoked
+		case models.InvitationStatusExpired:
+			return ErrInvitationExpired
+		}
+
+		// The invite token was delivered to this address, so it counts as verified
+		now := time.Now()
+		user.Email = normalizeEmail(invitation.Email)
+		user.UserType = models.UserTypeForRole(invitation.Role)
+		user.PasswordHash = hashedPassword
+		user.EmailVerifiedAt = &now
+		user.OrganizationID = invitation.OrganizationID
+		if err := tx.Create(user).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrEmailExists
+			}
+			return err
+		}
+		if err := assignRoleByName(tx, user, invitation.Role); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&invitation).Updates(models.Invitation{
+			AcceptedAt:     &now,
+			AcceptedUserID: &user.ID,
+		}).Error; err != nil {
+			return err
+		}
+		return appendAuditEvent(ctx, tx, &models.AuditEvent{
+			ActorID:        &user.ID,
+			OrganizationID: invitation.OrganizationID,
+			Action:         models.AuditActionInvitationAccept,
+			EntityType:     "invitation",
+			EntityID:       strconv.FormatUint(uint64(invitation.ID), 10),
+			Metadata:       map[string]interface{}{"user_id": user.ID, "role": invitation.Role},
+		})
+	})
+	if err != nil {
+		s.logger.Warn("Failed to accept invitation", zap.Uint("invitation_id", claims.InviteID), zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Invitation accepted",
+		zap.Uint("invitation_id", claims.InviteID),
+		zap.Uint("user_id", user.ID),
+	)
+	return nil
+}
+
+// PurgeExpiredInvitations deletes invitations that were never accepted and
+// expired or were revoked before the cutoff. Accepted invitations are kept as
+// the record of how a member joined.
+func (s *InvitationService) PurgeExpiredInvitations(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Unscoped().
+		Where("accepted_at IS NULL AND (expires_at < ? OR revoked_at < ?)", before, before).
+		Delete(&models.Invitation{})
+	if result.Error != nil {
+		s.logger.Error("Failed to purge expired invitations", zap.Error(result.Error))
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"synergylabs/models"
+	"synergylabs/util"
+	"testing"
+	"time"
+)
+
+func newTestInvitation(t *testing.T, s *InvitationService, role string) (*models.Invitation, string) {
+	t.Helper()
+	invitation := &models.Invitation{
+		Email:     testEmail("invitee"),
+		Role:      role,
+		ExpiresAt: time.Now().Add(DefaultInvitationTTL),
+	}
+	token, err := s.CreateInvitation(context.Background(), invitation)
+	if err != nil {
+		t.Fatalf("CreateInvitation: %v", err)
+	}
+	return invitation, token
+}
+
+func TestInvitationCreateAndAccept(t *testing.T) {
+	conn := testDB(t)
+	testKeyring(t)
+	s := NewInvitationService(conn, testPermissions{}, testLogger())
+
+	invitation, token := newTestInvitation(t, s, models.RoleRecruiter)
+	if token == "" || invitation.TokenHash == "" || invitation.TokenHash == token {
+		t.Fatalf("expected a token stored only as a hash, got token %q hash %q", token, invitation.TokenHash)
+	}
+
+	user := &models.User{Name: "invitee", PasswordHash: "correct horse battery staple"}
+	if err := s.AcceptInvitation(context.Background(), token, user); err != nil {
+		t.Fatalf("AcceptInvitation: %v", err)
+	}
+	if user.Email != invitation.Email || user.UserType != models.UserTypeAdmin || user.EmailVerifiedAt == nil {
+		t.Errorf("accepted user = %+v, want a verified staff account for %s", user, invitation.Email)
+	}
+
+	var roles []models.Role
+	if err := conn.Model(user).Association("Roles").Find(&roles); err != nil {
+		t.Fatalf("loading roles: %v", err)
+	}
+	if len(roles) != 1 || roles[0].Name != models.RoleRecruiter {
+		t.Errorf("roles = %v, want [%s]", roles, models.RoleRecruiter)
+	}
+
+	var stored models.Invitation
+	if err := conn.First(&stored, invitation.ID).Error; err != nil {
+		t.Fatalf("loading invitation: %v", err)
+	}
+	if stored.Status() != models.InvitationStatusAccepted || stored.AcceptedUserID == nil || *stored.AcceptedUserID != user.ID {
+		t.Errorf("invitation after accepting = %+v", stored)
+	}
+}
+
+func TestInvitationTokenIsSingleUse(t *testing.T) {
+	conn := testDB(t)
+	testKeyring(t)
+	s := NewInvitationService(conn, testPermissions{}, testLogger())
+
+	_, token := newTestInvitation(t, s, models.RoleInterviewer)
+	if err := s.AcceptInvitation(context.Background(), token, &models.User{Name: "first", PasswordHash: "password-one"}); err != nil {
+		t.Fatalf("first AcceptInvitation: %v", err)
+	}
+	err := s.AcceptInvitation(context.Background(), token, &models.User{Name: "second", PasswordHash: "password-two"})
+	if !errors.Is(err, ErrInvitationAccepted) {
+		t.Fatalf("second AcceptInvitation error = %v, want %v", err, ErrInvitationAccepted)
+	}
+}
+
+func TestInvitationCreateRejects(t *testing.T) {
+	conn := testDB(t)
+	testKeyring(t)
+	s := NewInvitationService(conn, testPermissions{}, testLogger())
+
+	existing := &models.User{Name: "existing", Email: testEmail("existing"), UserType: models.UserTypeAdmin}
+	if err := conn.Create(existing).Error; err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		invitation models.Invitation
+		want       error
+	}{
+		{"unknown role", models.Invitation{Email: testEmail("unknown-role"), Role: "wizard"}, ErrRoleNotFound},
+		{"existing account", models.Invitation{Email: existing.Email, Role: models.RoleRecruiter}, ErrEmailExists},
+		{"existing account in another case", models.Invitation{Email: strings.ToUpper(existing.Email), Role: models.RoleRecruiter}, ErrEmailExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.invitation.ExpiresAt = time.Now().Add(time.Hour)
+			if _, err := s.CreateInvitation(context.Background(), &tt.invitation); !errors.Is(err, tt.want) {
+				t.Errorf("CreateInvitation error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvitationExpiry(t *testing.T) {
+	conn := testDB(t)
+	testKeyring(t)
+	s := NewInvitationService(conn, testPermissions{}, testLogger())
+
+	invitation, token := newTestInvitation(t, s, models.RoleRecruiter)
+	// The token itself is still valid; the invitation row decides
+	if err := conn.Model(invitation).Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("expiring invitation: %v", err)
+	}
+	err := s.AcceptInvitation(context.Background(), token, &models.User{Name: "late", PasswordHash: "password"})
+	if !errors.Is(err, ErrInvitationExpired) {
+		t.Fatalf("AcceptInvitation error = %v, want %v", err, ErrInvitationExpired)
+	}
+
+	// An invitation created already expired gets a token that fails validation
+	expired := &models.Invitation{Email: testEmail("expired"), Role: models.RoleRecruiter, ExpiresAt: time.Now().Add(-time.Hour)}
+	token, err = s.CreateInvitation(context.Background(), expired)
+	if err != nil {
+		t.Fatalf("CreateInvitation: %v", err)
+	}
+	err = s.AcceptInvitation(context.Background(), token, &models.User{Name: "late", PasswordHash: "password"})
+	if !errors.Is(err, ErrInvalidInvitation) {
+		t.Fatalf("AcceptInvitation error = %v, want %v", err, ErrInvalidInvitation)
+	}
+}
+
+func TestInvitationRevoke(t *testing.T) {
+	conn := testDB(t)
+	testKeyring(t)
+	s := NewInvitationService(conn, testPermissions{}, testLogger())
+
+	invitation, token := newTestInvitation(t, s, models.RoleRecruiter)
+	if err := s.RevokeInvitation(platformAdminContext(), invitation.ID); err != nil {
+		t.Fatalf("RevokeInvitation: %v", err)
+	}
+	// Revoking twice is not an error
+	if err := s.RevokeInvitation(platformAdminContext(), invitation.ID); err != nil {
+		t.Fatalf("second RevokeInvitation: %v", err)
+	}
+	err := s.AcceptInvitation(context.Background(), token, &models.User{Name: "revoked", PasswordHash: "password"})
+	if !errors.Is(err, ErrInvitationRevoked) {
+		t.Fatalf("AcceptInvitation error = %v, want %v", err, ErrInvitationRevoked)
+	}
+
+	accepted, token := newTestInvitation(t, s, models.RoleRecruiter)
+	if err := s.AcceptInvitation(context.Background(), token, &models.User{Name: "accepted", PasswordHash: "password"}); err != nil {
+		t.Fatalf("AcceptInvitation: %v", err)
+	}
+	if err := s.RevokeInvitation(platformAdminContext(), accepted.ID); !errors.Is(err, ErrInvitationAccepted) {
+		t.Fatalf("RevokeInvitation of an accepted invitation error = %v, want %v", err, ErrInvitationAccepted)
+	}
+
+	if err := s.RevokeInvitation(platformAdminContext(), 0); !errors.Is(err, ErrInvitationNotFound) {
+		t.Fatalf("RevokeInvitation of a missing invitation error = %v, want %v", err, ErrInvitationNotFound)
+	}
+}
+
+func TestInvitationTamperedToken(t *testing.T) {
+	conn := testDB(t)
+	testKeyring(t)
+	s := NewInvitationService(conn, testPermissions{}, testLogger())
+
+	_, token := newTestInvitation(t, s, models.RoleRecruiter)
+	err := s.AcceptInvitation(context.Background(), token+"x", &models.User{Name: "tampered", PasswordHash: "password"})
+	if !errors.Is(err, ErrInvalidInvitation) {
+		t.Fatalf("AcceptInvitation error = %v, want %v", err, ErrInvalidInvitation)
+	}
+}
+
+func TestInviteTokenValidation(t *testing.T) {
+	testKeyring(t)
+
+	token, err := util.GenerateInviteToken(42, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GenerateInviteToken: %v", err)
+	}
+	claims, err := util.ValidateInviteToken(token)
+	if err != nil || claims.InviteID != 42 {
+		t.Fatalf("ValidateInviteToken = %+v, %v; want invitation 42", claims, err)
+	}
+	if _, err := util.ValidateInviteToken(token[:len(token)-2]); err == nil {
+		t.Error("a truncated token was accepted")
+	}
+
+	expired, err := util.GenerateInviteToken(42, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("GenerateInviteToken: %v", err)
+	}
+	if _, err := util.ValidateInviteToken(expired); err == nil {
+		t.Error("an expired token was accepted")
+	}
+
+	// Access tokens are signed with the same key but are not invitations
+	access, err := util.GenerateToken(42, string(models.UserTypeAdmin))
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	if _, err := util.ValidateInviteToken(access); err == nil {
+		t.Error("an access token was accepted as an invitation")
+	}
+}
+
+func TestInvitationRoleMustBeGrantable(t *testing.T) {
+	conn := testDB(t)
+	testKeyring(t)
+
+	var recruiter models.Role
+	if err := conn.Where("name = ?", models.RoleRecruiter).First(&recruiter).Error; err != nil {
+		t.Fatalf("loading recruiter role: %v", err)
+	}
+	const inviterID = 7
+	s := NewInvitationService(conn, testPermissions{inviterID: append(recruiter.Permissions, models.PermUserInvite)}, testLogger())
+
+	for _, role := range []string{models.RoleSuperAdmin, models.RoleHiringManager} {
+		invitation := &models.Invitation{Email: testEmail("escalation"), Role: role, InvitedByID: inviterID, ExpiresAt: time.Now().Add(time.Hour)}
+		_, err := s.CreateInvitation(context.Background(), invitation)
+		// A hiring manager's job:read:own is not among a recruiter's permissions
+		if !errors.Is(err, ErrRoleNotGrantable) {
+			t.Errorf("inviting a %s: error = %v, want %v", role, err, ErrRoleNotGrantable)
+		}
+	}
+
+	for _, role := range []string{models.RoleRecruiter, models.RoleInterviewer} {
+		invitation := &models.Invitation{Email: testEmail("grantable"), Role: role, InvitedByID: inviterID, ExpiresAt: time.Now().Add(time.Hour)}
+		if _, err := s.CreateInvitation(context.Background(), invitation); err != nil {
+			t.Errorf("inviting a %s: %v", role, err)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"synergylabs/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const earthRadiusKm = 6371.0
+
+// kmPerDegree is the length of one degree of latitude
+const kmPerDegree = 111.045
+
+// JobSortFields are the keys GetJobs can sort by
+var JobSortFields = []string{"relevance", "posted_on", "title", "applications", "salary", "seniority", "distance"}
+
+// defaultJobSort lists the newest jobs first, and defaultSearchSort the best matches
+const (
+	defaultJobSort    = "-posted_on"
+	defaultSearchSort = "-relevance"
+)
+
+var (
+	countryCodePattern  = regexp.MustCompile(`^[A-Z]{2}$`)
+	currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// distanceSQL is the great-circle distance in km from a point (?, ?) to a
+// job's coordinates. LEAST guards acos against rounding just above 1.
+const distanceSQL = `(? * acos(LEAST(1.0,
+	cos(radians(?)) * cos(radians(jobs.latitude)) * cos(radians(jobs.longitude) - radians(?)) +
+	sin(radians(?)) * sin(radians(jobs.latitude)))))`
+
+func distanceVars(p *GeoPoint) []interface{} {
+	return []interface{}{earthRadiusKm, p.Latitude, p.Longitude, p.Latitude}
+}
+
+// validateJobFilters rejects filters that cannot be applied
+func validateJobFilters(filters JobFilters) error {
+	for _, p := range filters.RemotePolicies {
+		if !p.Valid() {
+			return fmt.Errorf("%w: unknown remote policy %q", ErrInvalidJobFilter, p)
+		}
+	}
+	for _, t := range filters.EmploymentTypes {
+		if !t.Valid() {
+			return fmt.Errorf("%w: unknown employment type %q", ErrInvalidJobFilter, t)
+		}
+	}
+	for _, level := range filters.Seniorities {
+		if !level.Valid() {
+			return fmt.Errorf("%w: unknown seniority %q", ErrInvalidJobFilter, level)
+		}
+	}
+	if filters.Country != "" && !countryCodePattern.MatchString(filters.Country) {
+		return fmt.Errorf("%w: country must be an ISO 3166-1 alpha-2 code", ErrInvalidJobFilter)
+	}
+
+	if filters.SalaryMin != nil || filters.SalaryMax != nil {
+		if !currencyCodePattern.MatchString(filters.SalaryCurrency) || !filters.SalaryPeriod.Valid() {
+			return fmt.Errorf("%w: salary filters need a currency and a period", ErrInvalidJobFilter)
+		}
+		if filters.SalaryMin != nil && filters.SalaryMax != nil && *filters.SalaryMin > *filters.SalaryMax {
+			return fmt.Errorf("%w: salary_min is greater than salary_max", ErrInvalidJobFilter)
+		}
+	}
+
+	if filters.Near != nil {
+		if err := validateCoordinates(filters.Near.Latitude, filters.Near.Longitude); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidJobFilter, err)
+		}
+		if filters.RadiusKm <= 0 {
+			return fmt.Errorf("%w: radius_km must be positive", ErrInvalidJobFilter)
+		}
+	} else if filters.RadiusKm != 0 {
+		return fmt.Errorf("%w: radius_km needs a point to search around", ErrInvalidJobFilter)
+	}
+
+	if _, err := jobOrder(filters); err != nil {
+		return err
+	}
+	return nil
+}
+
+// jobFilterScope applies the attribute, salary and radius filters
+func jobFilterScope(filters JobFilters) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filters.City != "" {
+			db = db.Where("lower(jobs.location_city) = lower(?)", filters.City)
+		}
+		if filters.Country != "" {
+			db = db.Where("jobs.location_country = ?", filters.Country)
+		}
+		if len(filters.RemotePolicies) > 0 {
+			db = db.Where("jobs.remote_policy IN ?", filters.RemotePolicies)
+		}
+		if len(filters.EmploymentTypes) > 0 {
+			db = db.Where("jobs.employment_type IN ?", filters.EmploymentTypes)
+		}
+		if len(filters.Seniorities) > 0 {
+			db = db.Where("jobs.seniority IN ?", filters.Seniorities)
+		}
+		if filters.Department != "" {
+			db = db.Where("lower(jobs.department) = lower(?)", filters.Department)
+		}
+
+		// A job's range is [salary_min, salary_max]; a job with only one bound is treated as that exact amount
+		if filters.SalaryMin != nil || filters.SalaryMax != nil {
+			db = db.Where("jobs.salary_currency = ? AND jobs.salary_period = ?", filters.SalaryCurrency, filters.SalaryPeriod)
+			if filters.SalaryMin != nil {
+				db = db.Where("COALESCE(jobs.salary_max, jobs.salary_min) >= ?", *filters.SalaryMin)
+			}
+			if filters.SalaryMax != nil {
+				db = db.Where("COALESCE(jobs.salary_min, jobs.salary_max) <= ?", *filters.SalaryMax)
+			}
+		}
+
+		if filters.Near != nil {
+			db = db.Scopes(radiusScope(*filters.Near, filters.RadiusKm))
+		}
+		return db
+	}
+}
+
+// radiusScope matches jobs within radiusKm of p. A bounding box on the indexed
+// coordinates narrows the rows before the exact distance is computed.
+func radiusScope(p GeoPoint, radiusKm float64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("jobs.latitude IS NOT NULL AND jobs.longitude IS NOT NULL")
+
+		latDelta := radiusKm / kmPerDegree
+		db = db.Where("jobs.latitude BETWEEN ? AND ?", p.Latitude-latDelta, p.Latitude+latDelta)
+
+		// Near the poles or across the antimeridian the longitude box does not hold
+		if math.Abs(p.Latitude)+latDelta < 90 {
+			lngDelta := radiusKm / (kmPerDegree * math.Cos(p.Latitude*math.Pi/180))
+			if p.Longitude-lngDelta >= -180 && p.Longitude+lngDelta <= 180 {
+				db = db.Where("jobs.longitude BETWEEN ? AND ?", p.Longitude-lngDelta, p.Longitude+lngDelta)
+			}
+		}
+
+		return db.Where(distanceSQL+" <= ?", append(distanceVars(&p), radiusKm)...)
+	}
+}
+
+// jobSort returns the field filters.Sort orders by and whether it is descending
+func jobSort(filters JobFilters) (field string, desc bool) {
+	sort := filters.Sort
+	if sort == "" {
+		sort = defaultJobSort
+		if filters.Query != "" {
+			sort = defaultSearchSort
+		}
+	}
+	return strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")
+}
+
+// jobKeyset returns the keyset for sorts on a plain column, which cursors can
+// resume; computed sorts such as salary or distance are only paged by number
+func jobKeyset(filters JobFilters) *keyset[models.Job] {
+	field, desc := jobSort(filters)
+	keys := &keyset[models.Job]{
+		idColumn: "jobs.id",
+		desc:     desc,
+		idOf:     func(job *models.Job) uint { return job.ID },
+	}
+	switch field {
+	case "posted_on":
+		keys.column = "jobs.posted_on"
+		keys.keyOf = func(job *models.Job) interface{} { return job.PostedOn }
+		keys.parseKey = parseKeyAs[time.Time]
+	case "title":
+		keys.column = "jobs.title"
+		keys.keyOf = func(job *models.Job) interface{} { return job.Title }
+		keys.parseKey = parseKeyAs[string]
+	case "applications":
+		keys.column = "jobs.total_applications"
+		keys.keyOf = func(job *models.Job) interface{} { return job.TotalApplications }
+		keys.parseKey = parseKeyAs[int]
+	default:
+		return nil
+	}
+	return keys
+}
+
+// jobOrder builds the ORDER BY for filters.Sort, breaking ties by ID so pages are stable
+func jobOrder(filters JobFilters) (clause.OrderBy, error) {
+	field, desc := jobSort(filters)
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+
+	var expr clause.Expr
+	switch field {
+	case "relevance":
+		if filters.Query == "" {
+			return clause.OrderBy{}, fmt.Errorf("%w: sorting by relevance needs a search query", ErrInvalidJobFilter)
+		}
+		expr = clause.Expr{SQL: rankSQL + " " + direction, Vars: []interface{}{filters.Query}}
+	case "posted_on":
+		expr = clause.Expr{SQL: "jobs.posted_on " + direction}
+	case "title":
+		expr = clause.Expr{SQL: "jobs.title " + direction}
+	case "applications":
+		expr = clause.Expr{SQL: "jobs.total_applications " + direction}
+	case "salary":
+		expr = clause.Expr{SQL: "COALESCE(jobs.salary_max, jobs.salary_min) " + direction + " NULLS LAST"}
+	case "seniority":
+		expr = clause.Expr{SQL: seniorityRankSQL() + " " + direction + " NULLS LAST"}
+	case "distance":
+		if filters.Near == nil {
+			return clause.OrderBy{}, fmt.Errorf("%w: sorting by distance needs a point to measure from", ErrInvalidJobFilter)
+		}
+		expr = clause.Expr{SQL: distanceSQL + " " + direction, Vars: distanceVars(filters.Near)}
+	default:
+		return clause.OrderBy{}, fmt.Errorf("%w: cannot sort by %q", ErrInvalidJobFilter, field)
+	}
+
+	expr.SQL += ", jobs.id " + direction
+	return clause.OrderBy{Expression: expr}, nil
+}
+
+// seniorityRankSQL orders seniority levels from junior to senior instead of alphabetically
+func seniorityRankSQL() string {
+	var b strings.Builder
+	b.WriteString("CASE jobs.seniority")
+	for i, level := range models.Seniorities {
+		fmt.Fprintf(&b, " WHEN '%s' THEN %d", level, i)
+	}
+	b.WriteString(" END")
+	return b.String()
+}
+
+// validateJobAttributes checks the structured fields of a job
+func validateJobAttributes(job *models.Job) error {
+	switch {
+	case job.RemotePolicy != "" && !job.RemotePolicy.Valid():
+		return fmt.Errorf("%w: unknown remote policy %q", ErrInvalidJobAttribute, job.RemotePolicy)
+	case job.EmploymentType != "" && !job.EmploymentType.Valid():
+		return fmt.Errorf("%w: unknown employment type %q", ErrInvalidJobAttribute, job.EmploymentType)
+	case job.Seniority != "" && !job.Seniority.Valid():
+		return fmt.Errorf("%w: unknown seniority %q", ErrInvalidJobAttribute, job.Seniority)
+	case job.LocationCountry != "" && !countryCodePattern.MatchString(job.LocationCountry):
+		return fmt.Errorf("%w: location_country must be an ISO 3166-1 alpha-2 code such as DE", ErrInvalidJobAttribute)
+	}
+
+	if (job.Latitude == nil) != (job.Longitude == nil) {
+		return fmt.Errorf("%w: latitude and longitude must be set together", ErrInvalidJobAttribute)
+	}
+	if job.Latitude != nil {
+		if err := validateCoordinates(*job.Latitude, *job.Longitude); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidJobAttribute, err)
+		}
+	}
+
+	if job.SalaryMin == nil && job.SalaryMax == nil {
+		return nil
+	}
+	switch {
+	case (job.SalaryMin != nil && *job.SalaryMin < 0) || (job.SalaryMax != nil && *job.SalaryMax < 0):
+		return fmt.Errorf("%w: salary cannot be negative", ErrInvalidJobAttribute)
+	case job.SalaryMin != nil && job.SalaryMax != nil && *job.SalaryMin > *job.SalaryMax:
+		return fmt.Errorf("%w: salary_min is greater than salary_max", ErrInvalidJobAttribute)
+	case !currencyCodePattern.MatchString(job.SalaryCurrency):
+		return fmt.Errorf("%w: salary_currency must be an ISO 4217 code such as EUR", ErrInvalidJobAttribute)
+	case !job.SalaryPeriod.Valid():
+		return fmt.Errorf("%w: salary_period must be HOUR, DAY, MONTH or YEAR", ErrInvalidJobAttribute)
+	}
+	return nil
+}
+
+func validateCoordinates(latitude, longitude float64) error {
+	if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+		return fmt.Errorf("coordinates out of range")
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"synergylabs/models"
+	"testing"
+)
+
+func TestValidateJobFilters(t *testing.T) {
+	amount := func(n int64) *int64 { return &n }
+	berlin := &GeoPoint{Latitude: 52.52, Longitude: 13.405}
+
+	tests := []struct {
+		name    string
+		filters JobFilters
+		valid   bool
+	}{
+		{"no filters", JobFilters{}, true},
+		{"remote policies", JobFilters{RemotePolicies: []models.RemotePolicy{models.RemotePolicyRemote, models.RemotePolicyHybrid}}, true},
+		{"unknown remote policy", JobFilters{RemotePolicies: []models.RemotePolicy{"SPACE"}}, false},
+		{"seniority", JobFilters{Seniorities: []models.Seniority{models.SenioritySenior}}, true},
+		{"unknown seniority", JobFilters{Seniorities: []models.Seniority{models.SenioritySenior, "WIZARD"}}, false},
+		{"unknown employment type", JobFilters{EmploymentTypes: []models.EmploymentType{"GIG"}}, false},
+		{"country code", JobFilters{Country: "DE"}, true},
+		{"country name", JobFilters{Country: "Germany"}, false},
+
+		{"salary minimum only", JobFilters{SalaryMin: amount(50000), SalaryCurrency: "EUR", SalaryPeriod: models.SalaryPerYear}, true},
+		{"salary maximum only", JobFilters{SalaryMax: amount(50000), SalaryCurrency: "EUR", SalaryPeriod: models.SalaryPerYear}, true},
+		{"salary of one amount", JobFilters{SalaryMin: amount(50000), SalaryMax: amount(50000), SalaryCurrency: "EUR", SalaryPeriod: models.SalaryPerYear}, true},
+		{"salary minimum above maximum", JobFilters{SalaryMin: amount(50001), SalaryMax: amount(50000), SalaryCurrency: "EUR", SalaryPeriod: models.SalaryPerYear}, false},
+		{"salary without currency", JobFilters{SalaryMin: amount(50000), SalaryPeriod: models.SalaryPerYear}, false},
+		{"salary with an unknown currency format", JobFilters{SalaryMin: amount(50000), SalaryCurrency: "euro", SalaryPeriod: models.SalaryPerYear}, false},
+		{"salary without period", JobFilters{SalaryMax: amount(50000), SalaryCurrency: "EUR"}, false},
+		{"currency without an amount", JobFilters{SalaryCurrency: "EUR"}, true},
+
+		{"radius", JobFilters{Near: berlin, RadiusKm: 25}, true},
+		{"point without radius", JobFilters{Near: berlin}, false},
+		{"negative radius", JobFilters{Near: berlin, RadiusKm: -1}, false},
+		{"radius without point", JobFilters{RadiusKm: 25}, false},
+		{"latitude out of range", JobFilters{Near: &GeoPoint{Latitude: 90.1}, RadiusKm: 25}, false},
+		{"longitude out of range", JobFilters{Near: &GeoPoint{Longitude: -180.1}, RadiusKm: 25}, false},
+		{"distance sort", JobFilters{Near: berlin, RadiusKm: 25, Sort: "distance"}, true},
+		{"distance sort without point", JobFilters{Sort: "distance"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateJobFilters(tt.filters)
+			if tt.valid && err != nil {
+				t.Errorf("validateJobFilters: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidJobFilter) {
+				t.Errorf("validateJobFilters = %v, want ErrInvalidJobFilter", err)
+			}
+		})
+	}
+}
+
+func TestJobFilterScope(t *testing.T) {
+	s := NewJobService(testDB(t), nil, testLogger())
+	poster := createTestStaff(t, s, "filters")[0]
+	marker := testEmail("filters")
+
+	coordinates := func(lat, lng float64) (*float64, *float64) { return &lat, &lng }
+	amount := func(n int64) *int64 { return &n }
+	jobs := []models.Job{
+		// Potsdam is 27 km from Berlin and Hamburg 255 km
+		{Title: "Berlin", RemotePolicy: models.RemotePolicyOnsite, Seniority: models.SenioritySenior,
+			SalaryMin: amount(60000), SalaryMax: amount(80000), SalaryCurrency: "EUR", SalaryPeriod: models.SalaryPerYear},
+		{Title: "Potsdam", RemotePolicy: models.RemotePolicyHybrid, Seniority: models.SeniorityMid,
+			SalaryMin: amount(50000), SalaryCurrency: "EUR", SalaryPeriod: models.SalaryPerYear},
+		{Title: "Hamburg", RemotePolicy: models.RemotePolicyRemote, Seniority: models.SeniorityJunior,
+			SalaryMax: amount(40000), SalaryCurrency: "EUR", SalaryPeriod: models.SalaryPerYear},
+		{Title: "Zurich", SalaryMin: amount(60000), SalaryMax: amount(80000), SalaryCurrency: "CHF", SalaryPeriod: models.SalaryPerYear},
+		{Title: "Monthly", SalaryMin: amount(5000), SalaryMax: amount(6000), SalaryCurrency: "EUR", SalaryPeriod: models.SalaryPerMonth},
+		{Title: "Anywhere"},
+	}
+	jobs[0].Latitude, jobs[0].Longitude = coordinates(52.52, 13.405)
+	jobs[1].Latitude, jobs[1].Longitude = coordinates(52.391, 13.064)
+	jobs[2].Latitude, jobs[2].Longitude = coordinates(53.551, 9.994)
+	jobs[3].Latitude, jobs[3].Longitude = coordinates(47.377, 8.541)
+	for i := range jobs {
+		jobs[i].Description = marker
+		jobs[i].PostedByID = poster.ID
+		if err := s.db.Create(&jobs[i]).Error; err != nil {
+			t.Fatalf("creating %s job: %v", jobs[i].Title, err)
+		}
+	}
+
+	berlin := &GeoPoint{Latitude: 52.52, Longitude: 13.405}
+	yearly := func(currency string, min, max *int64) JobFilters {
+		return JobFilters{SalaryMin: min, SalaryMax: max, SalaryCurrency: currency, SalaryPeriod: models.SalaryPerYear}
+	}
+	tests := []struct {
+		name    string
+		filters JobFilters
+		want    []string
+	}{
+		{"no filters", JobFilters{}, []string{"Anywhere", "Berlin", "Hamburg", "Monthly", "Potsdam", "Zurich"}},
+		{"within 10 km", JobFilters{Near: berlin, RadiusKm: 10}, []string{"Berlin"}},
+		{"within 30 km", JobFilters{Near: berlin, RadiusKm: 30}, []string{"Berlin", "Potsdam"}},
+		{"within 300 km", JobFilters{Near: berlin, RadiusKm: 300}, []string{"Berlin", "Hamburg", "Potsdam"}},
+		{"within 1000 km", JobFilters{Near: berlin, RadiusKm: 1000}, []string{"Berlin", "Hamburg", "Potsdam", "Zurich"}},
+
+		// Ranges overlap when they touch; a job with one bound is that exact amount
+		{"minimum at the top of a range", yearly("EUR", amount(80000), nil), []string{"Berlin"}},
+		{"minimum above every range", yearly("EUR", amount(80001), nil), nil},
+		{"maximum at an open-ended minimum", yearly("EUR", nil, amount(50000)), []string{"Hamburg", "Potsdam"}},
+		{"maximum below an open-ended minimum", yearly("EUR", nil, amount(49999)), []string{"Hamburg"}},
+		{"minimum at an open-ended maximum", yearly("EUR", amount(40000), nil), []string{"Berlin", "Hamburg", "Potsdam"}},
+		{"range around one amount", yearly("EUR", amount(45000), amount(55000)), []string{"Potsdam"}},
+		{"range inside a job's range", yearly("EUR", amount(65000), amount(70000)), []string{"Berlin"}},
+		{"wide range", yearly("EUR", amount(0), amount(1000000)), []string{"Berlin", "Hamburg", "Potsdam"}},
+		{"other currency", yearly("CHF", amount(0), nil), []string{"Zurich"}},
+		{"currency without jobs", yearly("USD", amount(0), nil), nil},
+		{"other period", JobFilters{SalaryMin: amount(5500), SalaryCurrency: "EUR", SalaryPeriod: models.SalaryPerMonth}, []string{"Monthly"}},
+
+		{"remote policies", JobFilters{RemotePolicies: []models.RemotePolicy{models.RemotePolicyRemote, models.RemotePolicyHybrid}}, []string{"Hamburg", "Potsdam"}},
+		{"seniority", JobFilters{Seniorities: []models.Seniority{models.SenioritySenior}}, []string{"Berlin"}},
+		{"seniorities", JobFilters{Seniorities: []models.Seniority{models.SeniorityJunior, models.SeniorityMid}}, []string{"Hamburg", "Potsdam"}},
+		{"combined", JobFilters{Near: berlin, RadiusKm: 300, RemotePolicies: []models.RemotePolicy{models.RemotePolicyOnsite, models.RemotePolicyRemote}, SalaryMax: amount(45000), SalaryCurrency: "EUR", SalaryPeriod: models.SalaryPerYear}, []string{"Hamburg"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateJobFilters(tt.filters); err != nil {
+				t.Fatalf("validateJobFilters: %v", err)
+			}
+			var titles []string
+			if err := s.db.Model(&models.Job{}).Where("jobs.description = ?", marker).
+				Scopes(jobFilterScope(tt.filters)).Order("jobs.title").Pluck("jobs.title", &titles).Error; err != nil {
+				t.Fatalf("querying jobs: %v", err)
+			}
+			if (len(titles) > 0 || len(tt.want) > 0) && !reflect.DeepEqual(titles, tt.want) {
+				t.Errorf("jobs = %q, want %q", titles, tt.want)
+			}
+		})
+	}
+}
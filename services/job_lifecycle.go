@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"synergylabs/models"
+	"synergylabs/services/cache"
+	"synergylabs/util"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobStatusAll is the JobFilters status that lists jobs in every status
+const JobStatusAll = "all"
+
+// TransitionJob moves a job to another status, if the transition is allowed
+func (s *JobService) TransitionJob(ctx context.Context, id uint, to models.JobStatus, pre JobPrecondition) (*models.Job, error) {
+	if !to.Valid() {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidJobStatus, to)
+	}
+
+	var job *models.Job
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if job, err = s.loadJobForWrite(ctx, tx, id, pre); err != nil {
+			return err
+		}
+		var actorID *uint
+		if request := util.RequestInfoFromContext(ctx); request.ActorID != 0 {
+			actorID = &request.ActorID
+		}
+		return transitionJob(ctx, tx, job, to, actorID, models.JobTransitionManual)
+	})
+	if err != nil {
+		if !isJobWriteRefusal(err) {
+			s.logger.Error("Failed to change job status", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	s.invalidateJob(ctx, id)
+	s.logger.Info("Job status changed", zap.Uint("job_id", id), zap.String("status", string(to)))
+	return job, nil
+}
+
+// ListJobTransitions returns a job's status history, oldest first
+func (s *JobService) ListJobTransitions(ctx context.Context, id uint) ([]models.JobStatusTransition, error) {
+	var job models.Job
+	if err := s.db.WithContext(ctx).Scopes(jobTenantScope(ctx)).Select("id").First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		s.logger.Error("Failed to fetch job", zap.Error(err))
+		return nil, err
+	}
+
+	var transitions []models.JobStatusTransition
+	if err := s.db.WithContext(ctx).Where("job_id = ?", id).Order("id").Find(&transitions).Error; err != nil {
+		s.logger.Error("Failed to fetch job status transitions", zap.Error(err))
+		return nil, err
+	}
+	return transitions, nil
+}
+
+// ApplyScheduledTransitions publishes drafts whose PublishAt has passed and
+// closes open or paused jobs whose ClosesAt has passed. Drafts whose ClosesAt
+// has passed too stay drafts, since publishing them is refused. It returns the
+// number of jobs changed.
+func (s *JobService) ApplyScheduledTransitions(ctx context.Context) (int, error) {
+	now := time.Now()
+	changed := 0
+
+	steps := []struct {
+		to     models.JobStatus
+		reason string
+		due    func(*gorm.DB) *gorm.DB
+	}{
+		{models.JobStatusPublished, models.JobTransitionScheduledPublish, func(db *gorm.DB) *gorm.DB {
+			return db.Where("status = ? AND publish_at <= ? AND (closes_at IS NULL OR closes_at > ?)", models.JobStatusDraft, now, now)
+		}},
+		{models.JobStatusClosed, models.JobTransitionScheduledClose, func(db *gorm.DB) *gorm.DB {
+			return db.Where("status IN ? AND closes_at <= ?", []models.JobStatus{models.JobStatusPublished, models.JobStatusPaused}, now)
+		}},
+	}
+
+	for _, step := range steps {
+		var ids []uint
+		if err := s.db.WithContext(ctx).Model(&models.Job{}).Scopes(step.due).Pluck("id", &ids).Error; err != nil {
+			s.logger.Error("Failed to find due job transitions", zap.Error(err))
+			return changed, err
+		}
+
+		for _, id := range ids {
+			applied := false
+			err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				// Another instance may have applied the transition since the IDs were read
+				var job models.Job
+				if err := tx.Scopes(step.due).Clauses(clause.Locking{Strength: "UPDATE"}).First(&job, id).Error; err != nil {
+					if errors.Is(err, gorm.ErrRecordNotFound) {
+						return nil
+					}
+					return err
+				}
+				applied = true
+				return transitionJob(ctx, tx, &job, step.to, nil, step.reason)
+			})
+			if err != nil {
+				s.logger.Error("Failed to apply scheduled job transition", zap.Uint("job_id", id), zap.Error(err))
+				continue
+			}
+			if applied {
+				changed++
+				s.invalidateJob(ctx, id)
+				s.logger.Info("Scheduled job transition applied", zap.Uint("job_id", id), zap.String("status", string(step.to)))
+			}
+		}
+	}
+	return changed, nil
+}
+
+// WarmJobsCache fills the cache for the first page of the default job
+// listing, the page most requests ask for, and returns the number of jobs on it
+func (s *JobService) WarmJobsCache(ctx context.Context) (int, error) {
+	response, err := s.GetJobs(ctx, JobFilters{PageRequest: PageRequest{Page: 1, PageSize: DefaultPageSize}})
+	if err != nil {
+		return 0, err
+	}
+	// A listing that was already cached comes back decoded from JSON
+	switch jobs := response.Data.(type) {
+	case []models.Job:
+		return len(jobs), nil
+	case []interface{}:
+		return len(jobs), nil
+	}
+	return 0, nil
+}
+
+func (s *JobService) invalidateJob(ctx context.Context, id uint) {
+	s.cache.Delete(ctx, fmt.Sprintf("%s:%d", JobsCacheKey, id))
+	s.invalidateJobListings(ctx)
+}
+
+// jobsGenerationTTL outlives every cached listing by far, so a generation
+// count that expired and restarted cannot collide with a cached one
+const jobsGenerationTTL = 30 * 24 * time.Hour
+
+// invalidateJobListings moves job listings to a new cache generation. Listings
+// are cached under one key per filter combination, too many to delete.
+func (s *JobService) invalidateJobListings(ctx context.Context) {
+	if _, err := s.cache.Increment(ctx, string(JobsGenerationCacheKey), jobsGenerationTTL); err != nil {
+		s.logger.Warn("Failed to invalidate cached job listings", zap.Error(err))
+	}
+}
+
+// listingGeneration returns the cache generation job listings are cached under
+func (s *JobService) listingGeneration(ctx context.Context) int64 {
+	var generation int64
+	if err := s.cache.Get(ctx, string(JobsGenerationCacheKey), &generation); err != nil && !errors.Is(err, cache.ErrNotFound) {
+		s.logger.Warn("Failed to read job listing cache generation", zap.Error(err))
+	}
+	return generation
+}
+
+// transitionJob changes the status of a job locked by tx and records who changed it
+func transitionJob(ctx context.Context, tx *gorm.DB, job *models.Job, to models.JobStatus, actorID *uint, reason string) error {
+	from := job.Status
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s to %s", ErrJobTransitionNotAllowed, from, to)
+	}
+	now := time.Now()
+	if to == models.JobStatusPublished && job.ClosesAt != nil && !now.Before(*job.ClosesAt) {
+		return fmt.Errorf("%w: the closing date has passed", ErrInvalidJobSchedule)
+	}
+
+	if err := tx.Model(job).Updates(map[string]interface{}{
+		"status":               to,
+		"status_changed_at":    now,
+		"status_changed_by_id": actorID,
+		"version":              gorm.Expr("version + 1"),
+	}).Error; err != nil {
+		return err
+	}
+	job.Status = to
+	job.StatusChangedAt = &now
+	job.StatusChangedByID = actorID
+	job.Version++
+
+	if err := tx.Create(&models.JobStatusTransition{
+		JobID:      job.ID,
+		FromStatus: from,
+		ToStatus:   to,
+		ActorID:    actorID,
+		Reason:     reason,
+	}).Error; err != nil {
+		return err
+	}
+	return appendAuditEvent(ctx, tx, &models.AuditEvent{
+		ActorID:        actorID,
+		OrganizationID: job.OrganizationID,
+		Action:         models.AuditActionJobStatus,
+		EntityType:     "job",
+		EntityID:       strconv.FormatUint(uint64(job.ID), 10),
+		Changes:        map[string]models.AuditChange{"status": {From: from, To: to}},
+		Metadata:       map[string]interface{}{"reason": reason},
+	})
+}
+
+// openJobScope limits job queries to jobs accepting applications at now; it
+// matches Job.AcceptsApplications. Drafts only count while their scheduled
+// publish is due but not yet applied: validateDraftPublishAt keeps drafts from
+// being saved with a past publish_at.
+func openJobScope(now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where("jobs.status = ? OR (jobs.status = ? AND jobs.publish_at <= ?)", models.JobStatusPublished, models.JobStatusDraft, now).
+			Where("jobs.closes_at IS NULL OR jobs.closes_at > ?", now)
+	}
+}
+
+// validateDraftPublishAt requires the publish_at given to a draft to be in the
+// future. Drafts whose publish_at has passed count as open until the schedule
+// publishes them, so a draft given a past date would be published at once.
+func validateDraftPublishAt(job *models.Job, now time.Time) error {
+	if job.Status == models.JobStatusDraft && job.PublishAt != nil && !job.PublishAt.After(now) {
+		return fmt.Errorf("%w: a draft's publish_at must be in the future; publish the job instead", ErrInvalidJobSchedule)
+	}
+	return nil
+}
+
+// sameTime reports whether two optional times are both unset or equal
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// validateJobSchedule requires a job to close after it is published
+func validateJobSchedule(job *models.Job) error {
+	if job.PublishAt != nil && job.ClosesAt != nil && !job.ClosesAt.After(*job.PublishAt) {
+		return fmt.Errorf("%w: closes_at must be after publish_at", ErrInvalidJobSchedule)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"synergylabs/models"
+	"testing"
+	"time"
+)
+
+func TestPrepareNewJobStatus(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		statuses  []models.JobStatus
+		status    models.JobStatus
+		publishAt *time.Time
+		want      models.JobStatus
+		err       error
+	}{
+		{"no status", newJobStatuses, "", nil, models.JobStatusPublished, nil},
+		{"no status, scheduled", newJobStatuses, "", &future, models.JobStatusDraft, nil},
+		{"no status, publish date passed", newJobStatuses, "", &past, models.JobStatusPublished, nil},
+		{"draft", newJobStatuses, models.JobStatusDraft, nil, models.JobStatusDraft, nil},
+		{"scheduled draft", newJobStatuses, models.JobStatusDraft, &future, models.JobStatusDraft, nil},
+		{"draft with a past publish date", newJobStatuses, models.JobStatusDraft, &past, "", ErrInvalidJobSchedule},
+		{"published", newJobStatuses, models.JobStatusPublished, nil, models.JobStatusPublished, nil},
+		{"paused", newJobStatuses, models.JobStatusPaused, nil, "", ErrInvalidJobStatus},
+		{"closed", newJobStatuses, models.JobStatusClosed, nil, "", ErrInvalidJobStatus},
+		{"imported closed", importedJobStatuses, models.JobStatusClosed, nil, models.JobStatusClosed, nil},
+		{"imported archived", importedJobStatuses, models.JobStatusArchived, nil, models.JobStatusArchived, nil},
+		{"imported paused", importedJobStatuses, models.JobStatusPaused, nil, "", ErrInvalidJobStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &models.Job{Title: "Title", Description: "Description", Status: tt.status, PublishAt: tt.publishAt}
+			err := prepareNewJob(context.Background(), job, tt.statuses)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("got %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("prepareNewJob: %v", err)
+			}
+			if job.Status != tt.want {
+				t.Errorf("status = %s, want %s", job.Status, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameTime(t *testing.T) {
+	a := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	b := a.In(time.FixedZone("CET", 3600))
+	c := a.Add(time.Second)
+	tests := []struct {
+		x, y *time.Time
+		want bool
+	}{
+		{nil, nil, true},
+		{&a, nil, false},
+		{nil, &a, false},
+		{&a, &b, true},
+		{&a, &c, false},
+	}
+	for _, tt := range tests {
+		if got := sameTime(tt.x, tt.y); got != tt.want {
+			t.Errorf("sameTime(%v, %v) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestApplyScheduledTransitions(t *testing.T) {
+	s := NewJobService(testDB(t), testCache(t), testLogger())
+	poster := createTestStaff(t, s, "scheduler")[0]
+	past, earlier, future := time.Now().Add(-time.Hour), time.Now().Add(-2*time.Hour), time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		status    models.JobStatus
+		publishAt *time.Time
+		closesAt  *time.Time
+		want      models.JobStatus
+	}{
+		{"draft due", models.JobStatusDraft, &past, nil, models.JobStatusPublished},
+		{"draft due before closing", models.JobStatusDraft, &past, &future, models.JobStatusPublished},
+		{"draft due after closing", models.JobStatusDraft, &past, &earlier, models.JobStatusDraft},
+		{"draft not due", models.JobStatusDraft, &future, nil, models.JobStatusDraft},
+		{"published past closing", models.JobStatusPublished, nil, &past, models.JobStatusClosed},
+		{"paused past closing", models.JobStatusPaused, nil, &past, models.JobStatusClosed},
+		{"published before closing", models.JobStatusPublished, nil, &future, models.JobStatusPublished},
+	}
+	jobs := make([]models.Job, len(tests))
+	for i, tt := range tests {
+		jobs[i] = models.Job{Title: tt.name, Description: "Scheduled", PostedByID: poster.ID, Status: tt.status, PublishAt: tt.publishAt, ClosesAt: tt.closesAt}
+		if err := s.db.Create(&jobs[i]).Error; err != nil {
+			t.Fatalf("creating %s: %v", tt.name, err)
+		}
+	}
+
+	if _, err := s.ApplyScheduledTransitions(context.Background()); err != nil {
+		t.Fatalf("ApplyScheduledTransitions: %v", err)
+	}
+	for i, tt := range tests {
+		var job models.Job
+		if err := s.db.First(&job, jobs[i].ID).Error; err != nil {
+			t.Fatalf("reloading %s: %v", tt.name, err)
+		}
+		if job.Status != tt.want {
+			t.Errorf("%s: status = %s, want %s", tt.name, job.Status, tt.want)
+		}
+	}
+}
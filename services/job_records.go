@@ -0,0 +1,483 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"synergylabs/models"
+	"time"
+)
+
+// JobDataFormat is a file format jobs are imported and exported in
+type JobDataFormat string
+
+const (
+	JobFormatCSV   JobDataFormat = "csv"
+	JobFormatJSONL JobDataFormat = "jsonl"
+)
+
+// ParseJobDataFormat accepts a format name or one of its content types
+func ParseJobDataFormat(value string) (JobDataFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "csv", "text/csv":
+		return JobFormatCSV, nil
+	case "jsonl", "ndjson", "application/jsonl", "application/x-ndjson":
+		return JobFormatJSONL, nil
+	}
+	return "", fmt.Errorf("%w: unknown format %q, use csv or jsonl", ErrInvalidJobImport, value)
+}
+
+// ContentType is the media type files in the format are served with
+func (f JobDataFormat) ContentType() string {
+	if f == JobFormatJSONL {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// JobRecord is one job in an import or export file. The CSV columns and JSON
+// keys are the same. ID, posted_on and total_applications are only exported;
+// imports accept and ignore them so an export can be imported again.
+type JobRecord struct {
+	ID                uint                  `json:"id,omitempty"`
+	ExternalRef       string                `json:"external_ref"`
+	Title             string                `json:"title"`
+	Description       string                `json:"description"`
+	CompanyName       string                `json:"company_name,omitempty"`
+	Status            models.JobStatus      `json:"status,omitempty"`
+	PublishAt         *time.Time            `json:"publish_at,omitempty"`
+	ClosesAt          *time.Time            `json:"closes_at,omitempty"`
+	HiringManagerID   *uint                 `json:"hiring_manager_id,omitempty"`
+	LocationCity      string                `json:"location_city,omitempty"`
+	LocationCountry   string                `json:"location_country,omitempty"`
+	Latitude          *float64              `json:"latitude,omitempty"`
+	Longitude         *float64              `json:"longitude,omitempty"`
+	RemotePolicy      models.RemotePolicy   `json:"remote_policy,omitempty"`
+	EmploymentType    models.EmploymentType `json:"employment_type,omitempty"`
+	Seniority         models.Seniority      `json:"seniority,omitempty"`
+	Department        string                `json:"department,omitempty"`
+	SalaryMin         *int64                `json:"salary_min,omitempty"`
+	SalaryMax         *int64                `json:"salary_max,omitempty"`
+	SalaryCurrency    string                `json:"salary_currency,omitempty"`
+	SalaryPeriod      models.SalaryPeriod   `json:"salary_period,omitempty"`
+	PostedOn          *time.Time            `json:"posted_on,omitempty"`
+	TotalApplications *int                  `json:"total_applications,omitempty"`
+
+	// fields are the columns of the CSV header or the keys of the JSON
+	// object; an update only writes these
+	fields []string
+}
+
+// defaultExternalRefPrefix starts the reference jobs without an external_ref
+// are exported with, followed by their ID
+const defaultExternalRefPrefix = "job-"
+
+// defaultExternalRef is the reference a job without one is exported with
+func defaultExternalRef(id uint) string {
+	return defaultExternalRefPrefix + strconv.FormatUint(uint64(id), 10)
+}
+
+// parseDefaultExternalRef returns the job ID in a reference made by
+// defaultExternalRef
+func parseDefaultExternalRef(ref string) (uint, bool) {
+	digits, ok := strings.CutPrefix(ref, defaultExternalRefPrefix)
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(digits, 10, 32)
+	if err != nil || id == 0 || defaultExternalRef(uint(id)) != ref {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// jobRecordColumns are the CSV columns in export order. Numeric columns are
+// passed to the JSON decoder as numbers, the others as strings.
+var jobRecordColumns = []struct {
+	name    string
+	numeric bool
+}{
+	{"id", true},
+	{"external_ref", false},
+	{"title", false},
+	{"description", false},
+	{"company_name", false},
+	{"status", false},
+	{"publish_at", false},
+	{"closes_at", false},
+	{"hiring_manager_id", true},
+	{"location_city", false},
+	{"location_country", false},
+	{"latitude", true},
+	{"longitude", true},
+	{"remote_policy", false},
+	{"employment_type", false},
+	{"seniority", false},
+	{"department", false},
+	{"salary_min", true},
+	{"salary_max", true},
+	{"salary_currency", false},
+	{"salary_period", false},
+	{"posted_on", false},
+	{"total_applications", true},
+}
+
+// newJobRecord describes a job for export
+func newJobRecord(job *models.Job) *JobRecord {
+	record := &JobRecord{
+		ID:                job.ID,
+		Title:             job.Title,
+		Description:       job.Description,
+		CompanyName:       job.CompanyName,
+		Status:            job.Status,
+		PublishAt:         job.PublishAt,
+		ClosesAt:          job.ClosesAt,
+		HiringManagerID:   job.HiringManagerID,
+		LocationCity:      job.LocationCity,
+		LocationCountry:   job.LocationCountry,
+		Latitude:          job.Latitude,
+		Longitude:         job.Longitude,
+		RemotePolicy:      job.RemotePolicy,
+		EmploymentType:    job.EmploymentType,
+		Seniority:         job.Seniority,
+		Department:        job.Department,
+		SalaryMin:         job.SalaryMin,
+		SalaryMax:         job.SalaryMax,
+		SalaryCurrency:    job.SalaryCurrency,
+		SalaryPeriod:      job.SalaryPeriod,
+		PostedOn:          &job.PostedOn,
+		TotalApplications: &job.TotalApplications,
+	}
+	// Jobs created through the API may have no reference; they get a stable
+	// one so the export can be imported again
+	record.ExternalRef = defaultExternalRef(job.ID)
+	if job.ExternalRef != nil {
+		record.ExternalRef = *job.ExternalRef
+	}
+	return record
+}
+
+// job returns the imported job the record describes
+func (r *JobRecord) job() *models.Job {
+	ref := r.ExternalRef
+	return &models.Job{
+		ExternalRef:     &ref,
+		Title:           r.Title,
+		Description:     r.Description,
+		CompanyName:     r.CompanyName,
+		Status:          r.Status,
+		PublishAt:       r.PublishAt,
+		ClosesAt:        r.ClosesAt,
+		HiringManagerID: r.HiringManagerID,
+		LocationCity:    r.LocationCity,
+		LocationCountry: r.LocationCountry,
+		Latitude:        r.Latitude,
+		Longitude:       r.Longitude,
+		RemotePolicy:    r.RemotePolicy,
+		EmploymentType:  r.EmploymentType,
+		Seniority:       r.Seniority,
+		Department:      r.Department,
+		SalaryMin:       r.SalaryMin,
+		SalaryMax:       r.SalaryMax,
+		SalaryCurrency:  r.SalaryCurrency,
+		SalaryPeriod:    r.SalaryPeriod,
+	}
+}
+
+// validate checks the fields every imported job needs
+func (r *JobRecord) validate() error {
+	r.ExternalRef = strings.TrimSpace(r.ExternalRef)
+	switch {
+	case r.ExternalRef == "":
+		return fmt.Errorf("%w: external_ref is required", ErrInvalidJobRecord)
+	case len(r.ExternalRef) > 100:
+		return fmt.Errorf("%w: external_ref is longer than 100 characters", ErrInvalidJobRecord)
+	case r.Title == "" || r.Description == "":
+		return fmt.Errorf("%w: title and description are required", ErrInvalidJobRecord)
+	}
+	return nil
+}
+
+// jobRecordReader reads job records from an import file
+type jobRecordReader interface {
+	// Read returns the next record and the line it starts on. Errors wrapping
+	// ErrInvalidJobRecord only affect that record; io.EOF ends the file and
+	// any other error the whole import.
+	Read() (*JobRecord, int, error)
+}
+
+// jobRecordWriter writes job records to an export file
+type jobRecordWriter interface {
+	Write(record *JobRecord) error
+	Flush() error
+}
+
+func newJobRecordReader(r io.Reader, format JobDataFormat) (jobRecordReader, error) {
+	switch format {
+	case JobFormatCSV:
+		return newCSVJobReader(r)
+	case JobFormatJSONL:
+		return &jsonlJobReader{lines: newLineReader(r)}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidJobImport, format)
+}
+
+func newJobRecordWriter(w io.Writer, format JobDataFormat) (jobRecordWriter, error) {
+	switch format {
+	case JobFormatCSV:
+		writer := &csvJobWriter{csv: csv.NewWriter(w)}
+		return writer, writer.writeHeader()
+	case JobFormatJSONL:
+		return &jsonlJobWriter{encoder: json.NewEncoder(w)}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidJobImport, format)
+}
+
+type csvJobReader struct {
+	csv     *csv.Reader
+	columns []string
+}
+
+// newCSVJobReader reads the header, which names the columns present in any order
+func newCSVJobReader(r io.Reader) (*csvJobReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidJobImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJobImport, err)
+	}
+
+	seen := map[string]bool{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if jobRecordColumn(name) < 0 {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidJobImport, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: column %q appears twice", ErrInvalidJobImport, name)
+		}
+		seen[name] = true
+		header[i] = name
+	}
+	for _, required := range []string{"external_ref", "title", "description"} {
+		if !seen[required] {
+			return nil, fmt.Errorf("%w: missing column %q", ErrInvalidJobImport, required)
+		}
+	}
+	return &csvJobReader{csv: reader, columns: header}, nil
+}
+
+func (r *csvJobReader) Read() (*JobRecord, int, error) {
+	row, err := r.csv.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if !errors.As(err, &parseErr) {
+			return nil, 0, err
+		}
+		if errors.Is(err, csv.ErrFieldCount) {
+			return nil, parseErr.StartLine, fmt.Errorf("%w: expected %d fields", ErrInvalidJobRecord, len(r.columns))
+		}
+		return nil, parseErr.StartLine, fmt.Errorf("%w: %v", ErrInvalidJobImport, err)
+	}
+	line, _ := r.csv.FieldPos(0)
+
+	// Cells become a JSON object so they are decoded like a JSON Lines record
+	fields := make(map[string]interface{}, len(row))
+	for i, value := range row {
+		if value == "" {
+			continue
+		}
+		name := r.columns[i]
+		if jobRecordColumns[jobRecordColumn(name)].numeric {
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return nil, line, fmt.Errorf("%w: %s must be a number", ErrInvalidJobRecord, name)
+			}
+			fields[name] = json.Number(value)
+		} else {
+			fields[name] = unescapeCSVFormula(value)
+		}
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, line, fmt.Errorf("%w: %v", ErrInvalidJobRecord, err)
+	}
+	record, err := decodeJobRecord(data)
+	if err != nil {
+		return nil, line, err
+	}
+	// Empty cells are left out of the object but still clear their column
+	record.fields = r.columns
+	return record, line, nil
+}
+
+// jobRecordColumn returns the index of a CSV column, or -1
+func jobRecordColumn(name string) int {
+	for i, column := range jobRecordColumns {
+		if column.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+type jsonlJobReader struct {
+	lines *lineReader
+}
+
+func (r *jsonlJobReader) Read() (*JobRecord, int, error) {
+	for {
+		line, err := r.lines.next()
+		if err != nil {
+			if err != io.EOF {
+				err = fmt.Errorf("%w: %v", ErrInvalidJobImport, err)
+			}
+			return nil, r.lines.number, err
+		}
+		// Blank lines, such as a trailing one, are skipped
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		record, err := decodeJobRecord(line)
+		return record, r.lines.number, err
+	}
+}
+
+// decodeJobRecord decodes one JSON object, rejecting keys that are not columns
+func decodeJobRecord(data []byte) (*JobRecord, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var record JobRecord
+	if err := decoder.Decode(&record); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJobRecord, err)
+	}
+
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJobRecord, err)
+	}
+	for key := range keys {
+		record.fields = append(record.fields, key)
+	}
+	return &record, nil
+}
+
+// maxJobRecordLine bounds one line of a JSON Lines import
+const maxJobRecordLine = 1 << 20
+
+// lineReader splits input into lines and counts them
+type lineReader struct {
+	reader *bufio.Reader
+	number int
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{reader: bufio.NewReaderSize(r, maxJobRecordLine)}
+}
+
+func (l *lineReader) next() ([]byte, error) {
+	line, err := l.reader.ReadSlice('\n')
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return nil, fmt.Errorf("line %d is longer than %d bytes", l.number+1, maxJobRecordLine)
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.number++
+	return line, nil
+}
+
+type csvJobWriter struct {
+	csv *csv.Writer
+}
+
+func (w *csvJobWriter) writeHeader() error {
+	header := make([]string, len(jobRecordColumns))
+	for i, column := range jobRecordColumns {
+		header[i] = column.name
+	}
+	return w.csv.Write(header)
+}
+
+// Write encodes the record as JSON first, so a cell holds the same text as
+// the JSON Lines value
+func (w *csvJobWriter) Write(record *JobRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil {
+		return err
+	}
+
+	row := make([]string, len(jobRecordColumns))
+	for i, column := range jobRecordColumns {
+		value, ok := fields[column.name]
+		if !ok {
+			continue
+		}
+		row[i] = fmt.Sprint(value)
+		if !column.numeric {
+			row[i] = escapeCSVFormula(row[i])
+		}
+	}
+	return w.csv.Write(row)
+}
+
+// csvFormulaStart are the characters spreadsheets start a formula with
+const csvFormulaStart = "=+-@"
+
+// isCSVFormula reports whether a text cell, ignoring the apostrophes that
+// escape it, would be taken for a formula
+func isCSVFormula(value string) bool {
+	value = strings.TrimLeft(value, "'")
+	return value != "" && strings.ContainsRune(csvFormulaStart, rune(value[0]))
+}
+
+// escapeCSVFormula prefixes text a spreadsheet would evaluate with an
+// apostrophe, which makes it show the text instead
+func escapeCSVFormula(value string) string {
+	if isCSVFormula(value) {
+		return "'" + value
+	}
+	return value
+}
+
+// unescapeCSVFormula removes the apostrophe escapeCSVFormula adds
+func unescapeCSVFormula(value string) string {
+	if isCSVFormula(value) {
+		return strings.TrimPrefix(value, "'")
+	}
+	return value
+}
+
+func (w *csvJobWriter) Flush() error {
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+type jsonlJobWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *jsonlJobWriter) Write(record *JobRecord) error {
+	return w.encoder.Encode(record)
+}
+
+func (w *jsonlJobWriter) Flush() error {
+	return nil
+}
@@ -0,0 +1,416 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"synergylabs/models"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	maxScreeningQuestions    = 50
+	maxScreeningPromptLength = 500
+	maxScreeningOptions      = 50
+	maxScreeningOptionLength = 200
+	maxScreeningTextAnswer   = 5000
+)
+
+// ListScreeningQuestions returns a job's screening questions in the order they
+// are asked, with their knockout rules
+func (s *JobService) ListScreeningQuestions(ctx context.Context, jobID uint) ([]models.ScreeningQuestion, error) {
+	var job models.Job
+	if err := s.db.WithContext(ctx).Scopes(jobTenantScope(ctx)).Select("id").First(&job, jobID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		s.logger.Error("Failed to fetch job", zap.Error(err))
+		return nil, err
+	}
+
+	questions, err := screeningQuestions(s.db.WithContext(ctx), jobID)
+	if err != nil {
+		s.logger.Error("Failed to fetch screening questions", zap.Error(err))
+		return nil, err
+	}
+	return questions, nil
+}
+
+// ApplicantScreeningQuestions returns the questions applying to an open job
+// asks. Knockout rules are left out so applicants cannot tailor their answers.
+func (s *JobService) ApplicantScreeningQuestions(ctx context.Context, jobID uint) ([]models.ScreeningQuestion, error) {
+	var job models.Job
+	if err := s.db.WithContext(ctx).Scopes(openJobScope(time.Now())).Select("id").First(&job, jobID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		s.logger.Error("Failed to fetch job", zap.Error(err))
+		return nil, err
+	}
+
+	questions, err := screeningQuestions(s.db.WithContext(ctx), jobID)
+	if err != nil {
+		s.logger.Error("Failed to fetch screening questions", zap.Error(err))
+		return nil, err
+	}
+	for i := range questions {
+		questions[i].Knockout = nil
+	}
+	return questions, nil
+}
+
+// ReplaceScreeningQuestions sets a job's questions to questions, asked in the
+// order given. Questions with the ID of one of the job's questions update it;
+// the job's questions left out are deleted. Applications already made keep
+// their answers and status.
+func (s *JobService) ReplaceScreeningQuestions(ctx context.Context, jobID uint, questions []models.ScreeningQuestion, pre JobPrecondition) ([]models.ScreeningQuestion, error) {
+	if err := validateScreeningQuestions(questions); err != nil {
+		return nil, err
+	}
+
+	var saved []models.ScreeningQuestion
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := s.loadJobForWrite(ctx, tx, jobID, pre); err != nil {
+			return err
+		}
+		existing, err := screeningQuestions(tx, jobID)
+		if err != nil {
+			return err
+		}
+		unused := map[uint]bool{}
+		for _, question := range existing {
+			unused[question.ID] = true
+		}
+
+		for i := range questions {
+			question := &questions[i]
+			question.JobID = jobID
+			question.Position = i + 1
+			question.CreatedAt = time.Time{}
+			question.UpdatedAt = time.Time{}
+			if question.ID == 0 {
+				if err := tx.Create(question).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if !unused[question.ID] {
+				return fmt.Errorf("%w: question %d is not one of this job's, or is listed twice", ErrInvalidScreeningQuestion, question.ID)
+			}
+			delete(unused, question.ID)
+			if err := tx.Model(question).
+				Select("position", "prompt", "type", "options", "required", "knockout").
+				Updates(question).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(unused) > 0 {
+			ids := make([]uint, 0, len(unused))
+			for id := range unused {
+				ids = append(ids, id)
+			}
+			if err := tx.Where("job_id = ? AND id IN ?", jobID, ids).Delete(&models.ScreeningQuestion{}).Error; err != nil {
+				return err
+			}
+		}
+
+		if saved, err = screeningQuestions(tx, jobID); err != nil {
+			return err
+		}
+		return appendAuditEvent(ctx, tx, &models.AuditEvent{
+			Action:     models.AuditActionJobQuestions,
+			EntityType: "job",
+			EntityID:   strconv.FormatUint(uint64(jobID), 10),
+			Changes: map[string]models.AuditChange{
+				"screening_questions": {From: existing, To: saved},
+			},
+		})
+	})
+	if err != nil {
+		if !isJobWriteRefusal(err) && !errors.Is(err, ErrInvalidScreeningQuestion) {
+			s.logger.Error("Failed to save screening questions", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	s.logger.Info("Screening questions saved", zap.Uint("job_id", jobID), zap.Int("questions", len(saved)))
+	return saved, nil
+}
+
+// ListJobApplications returns a job's applications, oldest first, optionally
+// only those with the given status
+func (s *JobService) ListJobApplications(ctx context.Context, jobID uint, status models.ApplicationStatus) ([]models.JobApplication, error) {
+	var job models.Job
+	if err := s.db.WithContext(ctx).Scopes(jobTenantScope(ctx)).Select("id").First(&job, jobID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		s.logger.Error("Failed to fetch job", zap.Error(err))
+		return nil, err
+	}
+
+	query := s.db.WithContext(ctx).Preload("User").Where("job_id = ?", jobID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	applications := []models.JobApplication{}
+	if err := query.Order("created_at, user_id").Find(&applications).Error; err != nil {
+		s.logger.Error("Failed to fetch job applications", zap.Error(err))
+		return nil, err
+	}
+	return applications, nil
+}
+
+func screeningQuestions(db *gorm.DB, jobID uint) ([]models.ScreeningQuestion, error) {
+	questions := []models.ScreeningQuestion{}
+	err := db.Where("job_id = ?", jobID).Order("position, id").Find(&questions).Error
+	return questions, err
+}
+
+// copyScreeningQuestions copies the screening questions of job from to job to
+func copyScreeningQuestions(tx *gorm.DB, from, to uint) error {
+	questions, err := screeningQuestions(tx, from)
+	if err != nil || len(questions) == 0 {
+		return err
+	}
+	for i := range questions {
+		questions[i].ID = 0
+		questions[i].JobID = to
+		questions[i].CreatedAt = time.Time{}
+		questions[i].UpdatedAt = time.Time{}
+	}
+	return tx.Create(&questions).Error
+}
+
+// validateScreeningQuestions checks questions before they are saved, trimming
+// their prompts and options
+func validateScreeningQuestions(questions []models.ScreeningQuestion) error {
+	if len(questions) > maxScreeningQuestions {
+		return fmt.Errorf("%w: a job has at most %d questions", ErrInvalidScreeningQuestion, maxScreeningQuestions)
+	}
+	for i := range questions {
+		if err := validateScreeningQuestion(&questions[i]); err != nil {
+			return fmt.Errorf("%w: question %d: %v", ErrInvalidScreeningQuestion, i+1, err)
+		}
+	}
+	return nil
+}
+
+func validateScreeningQuestion(question *models.ScreeningQuestion) error {
+	question.Prompt = strings.TrimSpace(question.Prompt)
+	switch {
+	case question.Prompt == "":
+		return errors.New("prompt is required")
+	case utf8.RuneCountInString(question.Prompt) > maxScreeningPromptLength:
+		return fmt.Errorf("prompt is longer than %d characters", maxScreeningPromptLength)
+	case !question.Type.Valid():
+		return fmt.Errorf("unknown type %q", question.Type)
+	}
+
+	if !question.Type.HasOptions() {
+		if len(question.Options) > 0 {
+			return errors.New("only choice questions have options")
+		}
+	} else {
+		if len(question.Options) < 2 || len(question.Options) > maxScreeningOptions {
+			return fmt.Errorf("choice questions have between 2 and %d options", maxScreeningOptions)
+		}
+		for i, option := range question.Options {
+			option = strings.TrimSpace(option)
+			if option == "" || utf8.RuneCountInString(option) > maxScreeningOptionLength {
+				return fmt.Errorf("options must be between 1 and %d characters", maxScreeningOptionLength)
+			}
+			if slices.Contains(question.Options[:i], option) {
+				return fmt.Errorf("option %q is listed twice", option)
+			}
+			question.Options[i] = option
+		}
+	}
+
+	rule := question.Knockout
+	if rule == nil {
+		return nil
+	}
+	// An optional question could be skipped to get past its rule
+	if !question.Required {
+		return errors.New("only required questions can have a knockout rule")
+	}
+	switch question.Type {
+	case models.ScreeningYesNo:
+		if rule.Expected == nil || rule.Disqualifying != nil || rule.Min != nil || rule.Max != nil {
+			return errors.New("a yes/no knockout rule has an expected answer and nothing else")
+		}
+	case models.ScreeningSingleChoice, models.ScreeningMultipleChoice:
+		if len(rule.Disqualifying) == 0 || rule.Expected != nil || rule.Min != nil || rule.Max != nil {
+			return errors.New("a choice knockout rule has disqualifying options and nothing else")
+		}
+		for _, option := range rule.Disqualifying {
+			if !slices.Contains(question.Options, option) {
+				return fmt.Errorf("disqualifying option %q is not one of the options", option)
+			}
+		}
+		// Knockout questions are required, so with no acceptable option every applicant is rejected
+		if !slices.ContainsFunc(question.Options, func(option string) bool {
+			return !slices.Contains(rule.Disqualifying, option)
+		}) {
+			return errors.New("every option is disqualifying")
+		}
+	case models.ScreeningNumber:
+		if (rule.Min == nil && rule.Max == nil) || rule.Expected != nil || rule.Disqualifying != nil {
+			return errors.New("a number knockout rule has a min, a max or both, and nothing else")
+		}
+		if rule.Min != nil && rule.Max != nil && *rule.Min > *rule.Max {
+			return errors.New("the knockout min is more than the max")
+		}
+	default:
+		return fmt.Errorf("%s questions cannot have a knockout rule", question.Type)
+	}
+	return nil
+}
+
+// screenApplication checks answers against a job's questions and returns the
+// application they make, rejected if an answer fails a knockout rule. Every
+// problem with the answers is reported at once.
+func screenApplication(questions []models.ScreeningQuestion, answers []ApplicationAnswer) (*models.JobApplication, error) {
+	var problems []string
+	asked := map[uint]bool{}
+	for _, question := range questions {
+		asked[question.ID] = true
+	}
+	given := map[uint]json.RawMessage{}
+	for _, answer := range answers {
+		switch _, answered := given[answer.QuestionID]; {
+		case !asked[answer.QuestionID]:
+			problems = append(problems, fmt.Sprintf("question %d is not asked by this job", answer.QuestionID))
+		case answered:
+			problems = append(problems, fmt.Sprintf("question %d is answered twice", answer.QuestionID))
+		default:
+			given[answer.QuestionID] = answer.Answer
+		}
+	}
+
+	application := &models.JobApplication{Status: models.ApplicationSubmitted}
+	for i := range questions {
+		question := &questions[i]
+		raw := bytes.TrimSpace(given[question.ID])
+		if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+			if question.Required {
+				problems = append(problems, fmt.Sprintf("question %d is required", question.ID))
+			}
+			continue
+		}
+		value, err := parseScreeningAnswer(question, raw)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("question %d: %v", question.ID, err))
+			continue
+		}
+
+		answer := models.ScreeningAnswer{
+			QuestionID: question.ID,
+			Prompt:     question.Prompt,
+			Type:       question.Type,
+			Answer:     value,
+			KnockedOut: knockedOut(question.Knockout, value),
+		}
+		// The first failed rule is the reason given
+		if answer.KnockedOut && application.Status != models.ApplicationRejected {
+			application.Status = models.ApplicationRejected
+			application.RejectionReason = fmt.Sprintf("question %d: %s", question.ID, question.Prompt)
+		}
+		application.Answers = append(application.Answers, answer)
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidScreeningAnswers, strings.Join(problems, "; "))
+	}
+	return application, nil
+}
+
+// parseScreeningAnswer decodes an answer into the Go type for the question's type
+func parseScreeningAnswer(question *models.ScreeningQuestion, raw json.RawMessage) (interface{}, error) {
+	switch question.Type {
+	case models.ScreeningYesNo:
+		var answer bool
+		if err := json.Unmarshal(raw, &answer); err != nil {
+			return nil, errors.New("answer must be true or false")
+		}
+		return answer, nil
+
+	case models.ScreeningSingleChoice:
+		var answer string
+		if err := json.Unmarshal(raw, &answer); err != nil || !slices.Contains(question.Options, answer) {
+			return nil, errors.New("answer must be one of the options")
+		}
+		return answer, nil
+
+	case models.ScreeningMultipleChoice:
+		var answer []string
+		if err := json.Unmarshal(raw, &answer); err != nil {
+			return nil, errors.New("answer must be a list of options")
+		}
+		for i, option := range answer {
+			if !slices.Contains(question.Options, option) {
+				return nil, fmt.Errorf("%q is not one of the options", option)
+			}
+			if slices.Contains(answer[:i], option) {
+				return nil, fmt.Errorf("%q is chosen twice", option)
+			}
+		}
+		if len(answer) == 0 && question.Required {
+			return nil, errors.New("choose at least one option")
+		}
+		return answer, nil
+
+	case models.ScreeningNumber:
+		var answer float64
+		if err := json.Unmarshal(raw, &answer); err != nil {
+			return nil, errors.New("answer must be a number")
+		}
+		return answer, nil
+	}
+
+	var answer string
+	if err := json.Unmarshal(raw, &answer); err != nil {
+		return nil, errors.New("answer must be text")
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" && question.Required {
+		return nil, errors.New("answer is required")
+	}
+	if utf8.RuneCountInString(answer) > maxScreeningTextAnswer {
+		return nil, fmt.Errorf("answer is longer than %d characters", maxScreeningTextAnswer)
+	}
+	return answer, nil
+}
+
+// knockedOut reports whether an answer parsed by parseScreeningAnswer fails rule
+func knockedOut(rule *models.KnockoutRule, answer interface{}) bool {
+	if rule == nil {
+		return false
+	}
+	switch answer := answer.(type) {
+	case bool:
+		return rule.Expected != nil && answer != *rule.Expected
+	case string:
+		return slices.Contains(rule.Disqualifying, answer)
+	case []string:
+		for _, option := range answer {
+			if slices.Contains(rule.Disqualifying, option) {
+				return true
+			}
+		}
+	case float64:
+		return (rule.Min != nil && answer < *rule.Min) || (rule.Max != nil && answer > *rule.Max)
+	}
+	return false
+}
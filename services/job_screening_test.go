@@ -0,0 +1,180 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"synergylabs/models"
+	"testing"
+)
+
+func TestValidateScreeningQuestions(t *testing.T) {
+	yes := true
+	number := func(n float64) *float64 { return &n }
+	choice := func(kind models.ScreeningQuestionType, options []string, disqualifying ...string) models.ScreeningQuestion {
+		return models.ScreeningQuestion{
+			Prompt:   "Where can you work?",
+			Type:     kind,
+			Options:  options,
+			Required: true,
+			Knockout: &models.KnockoutRule{Disqualifying: disqualifying},
+		}
+	}
+	places := []string{"Berlin", "Munich", "Remote"}
+
+	tests := []struct {
+		name     string
+		question models.ScreeningQuestion
+		valid    bool
+	}{
+		{"text", models.ScreeningQuestion{Prompt: "Why us?", Type: models.ScreeningText}, true},
+		{"blank prompt", models.ScreeningQuestion{Prompt: "  ", Type: models.ScreeningText}, false},
+		{"unknown type", models.ScreeningQuestion{Prompt: "Why us?", Type: "ESSAY"}, false},
+		{"text with options", models.ScreeningQuestion{Prompt: "Why us?", Type: models.ScreeningText, Options: places}, false},
+		{"text with a knockout rule", models.ScreeningQuestion{Prompt: "Why us?", Type: models.ScreeningText, Required: true, Knockout: &models.KnockoutRule{Expected: &yes}}, false},
+
+		{"yes/no rule", models.ScreeningQuestion{Prompt: "Work permit?", Type: models.ScreeningYesNo, Required: true, Knockout: &models.KnockoutRule{Expected: &yes}}, true},
+		{"yes/no rule on an optional question", models.ScreeningQuestion{Prompt: "Work permit?", Type: models.ScreeningYesNo, Knockout: &models.KnockoutRule{Expected: &yes}}, false},
+		{"yes/no rule without an expected answer", models.ScreeningQuestion{Prompt: "Work permit?", Type: models.ScreeningYesNo, Required: true, Knockout: &models.KnockoutRule{}}, false},
+		{"yes/no rule with a min", models.ScreeningQuestion{Prompt: "Work permit?", Type: models.ScreeningYesNo, Required: true, Knockout: &models.KnockoutRule{Expected: &yes, Min: number(1)}}, false},
+
+		{"number min", models.ScreeningQuestion{Prompt: "Years?", Type: models.ScreeningNumber, Required: true, Knockout: &models.KnockoutRule{Min: number(3)}}, true},
+		{"number range", models.ScreeningQuestion{Prompt: "Years?", Type: models.ScreeningNumber, Required: true, Knockout: &models.KnockoutRule{Min: number(3), Max: number(3)}}, true},
+		{"number min above max", models.ScreeningQuestion{Prompt: "Years?", Type: models.ScreeningNumber, Required: true, Knockout: &models.KnockoutRule{Min: number(4), Max: number(3)}}, false},
+		{"number rule without bounds", models.ScreeningQuestion{Prompt: "Years?", Type: models.ScreeningNumber, Required: true, Knockout: &models.KnockoutRule{}}, false},
+
+		{"single choice without a rule", models.ScreeningQuestion{Prompt: "Where?", Type: models.ScreeningSingleChoice, Options: places}, true},
+		{"choice with one option", models.ScreeningQuestion{Prompt: "Where?", Type: models.ScreeningSingleChoice, Options: []string{"Berlin"}}, false},
+		{"duplicate options once trimmed", models.ScreeningQuestion{Prompt: "Where?", Type: models.ScreeningMultipleChoice, Options: []string{"Berlin ", " Berlin"}}, false},
+		{"blank option", models.ScreeningQuestion{Prompt: "Where?", Type: models.ScreeningMultipleChoice, Options: []string{"Berlin", " "}}, false},
+		{"single choice rule", choice(models.ScreeningSingleChoice, places, "Munich"), true},
+		{"multi choice rule", choice(models.ScreeningMultipleChoice, places, "Munich", "Remote"), true},
+		{"choice rule without options", choice(models.ScreeningSingleChoice, places), false},
+		{"disqualifying option that is not an option", choice(models.ScreeningSingleChoice, places, "Hamburg"), false},
+		{"single choice with every option disqualifying", choice(models.ScreeningSingleChoice, places, "Berlin", "Munich", "Remote"), false},
+		{"multi choice with every option disqualifying", choice(models.ScreeningMultipleChoice, places, "Remote", "Berlin", "Munich"), false},
+		{"disqualifying option listed twice", choice(models.ScreeningSingleChoice, places, "Munich", "Munich", "Remote"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			question := tt.question
+			question.Options = append([]string(nil), tt.question.Options...)
+			err := validateScreeningQuestions([]models.ScreeningQuestion{question})
+			if tt.valid && err != nil {
+				t.Errorf("validateScreeningQuestions: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidScreeningQuestion) {
+				t.Errorf("validateScreeningQuestions = %v, want ErrInvalidScreeningQuestion", err)
+			}
+		})
+	}
+}
+
+func TestValidateScreeningQuestionsTrims(t *testing.T) {
+	questions := []models.ScreeningQuestion{{
+		Prompt:  "  Where?  ",
+		Type:    models.ScreeningSingleChoice,
+		Options: []string{" Berlin", "Munich "},
+	}}
+	if err := validateScreeningQuestions(questions); err != nil {
+		t.Fatalf("validateScreeningQuestions: %v", err)
+	}
+	if questions[0].Prompt != "Where?" || !reflect.DeepEqual(questions[0].Options, []string{"Berlin", "Munich"}) {
+		t.Errorf("question = %q %q, want it trimmed", questions[0].Prompt, questions[0].Options)
+	}
+}
+
+func TestScreenApplication(t *testing.T) {
+	yes := true
+	number := func(n float64) *float64 { return &n }
+	questions := []models.ScreeningQuestion{
+		{ID: 1, Prompt: "Work permit?", Type: models.ScreeningYesNo, Required: true, Knockout: &models.KnockoutRule{Expected: &yes}},
+		{ID: 2, Prompt: "Years of Go?", Type: models.ScreeningNumber, Required: true, Knockout: &models.KnockoutRule{Min: number(2), Max: number(10)}},
+		{ID: 3, Prompt: "Office?", Type: models.ScreeningSingleChoice, Options: []string{"Berlin", "Munich"}, Required: true, Knockout: &models.KnockoutRule{Disqualifying: []string{"Munich"}}},
+		{ID: 4, Prompt: "Languages?", Type: models.ScreeningMultipleChoice, Options: []string{"Go", "Rust", "PHP"}, Required: true, Knockout: &models.KnockoutRule{Disqualifying: []string{"PHP"}}},
+		{ID: 5, Prompt: "Anything else?", Type: models.ScreeningText},
+	}
+	passing := map[uint]string{1: `true`, 2: `5`, 3: `"Berlin"`, 4: `["Go", "Rust"]`}
+	with := func(changes map[uint]string) []ApplicationAnswer {
+		var answers []ApplicationAnswer
+		for id := uint(1); id <= 5; id++ {
+			raw, changed := changes[id]
+			if !changed {
+				raw = passing[id]
+			}
+			if raw != "" {
+				answers = append(answers, ApplicationAnswer{QuestionID: id, Answer: json.RawMessage(raw)})
+			}
+		}
+		return answers
+	}
+
+	tests := []struct {
+		name    string
+		answers []ApplicationAnswer
+		status  models.ApplicationStatus
+		reason  string
+		invalid bool
+	}{
+		{"passing answers", with(nil), models.ApplicationSubmitted, "", false},
+		{"optional text answered", with(map[uint]string{5: `" Remote first "`}), models.ApplicationSubmitted, "", false},
+		{"unexpected no", with(map[uint]string{1: `false`}), models.ApplicationRejected, "question 1: Work permit?", false},
+		{"number at the min", with(map[uint]string{2: `2`}), models.ApplicationSubmitted, "", false},
+		{"number at the max", with(map[uint]string{2: `10`}), models.ApplicationSubmitted, "", false},
+		{"number below the min", with(map[uint]string{2: `1.5`}), models.ApplicationRejected, "question 2: Years of Go?", false},
+		{"number above the max", with(map[uint]string{2: `11`}), models.ApplicationRejected, "question 2: Years of Go?", false},
+		{"disqualifying single choice", with(map[uint]string{3: `"Munich"`}), models.ApplicationRejected, "question 3: Office?", false},
+		{"one disqualifying choice among several", with(map[uint]string{4: `["Go", "PHP"]`}), models.ApplicationRejected, "question 4: Languages?", false},
+		{"first failed rule is the reason", with(map[uint]string{2: `0`, 3: `"Munich"`}), models.ApplicationRejected, "question 2: Years of Go?", false},
+
+		{"yes/no answered with text", with(map[uint]string{1: `"yes"`}), "", "", true},
+		{"number answered with text", with(map[uint]string{2: `"five"`}), "", "", true},
+		{"single choice that is not an option", with(map[uint]string{3: `"Hamburg"`}), "", "", true},
+		{"single choice answered with a list", with(map[uint]string{3: `["Berlin"]`}), "", "", true},
+		{"multi choice answered with one option", with(map[uint]string{4: `"Go"`}), "", "", true},
+		{"multi choice chosen twice", with(map[uint]string{4: `["Go", "Go"]`}), "", "", true},
+		{"required multi choice left empty", with(map[uint]string{4: `[]`}), "", "", true},
+		{"required answer missing", with(map[uint]string{1: ""}), "", "", true},
+		{"required answer null", with(map[uint]string{2: `null`}), "", "", true},
+		{"question the job does not ask", append(with(nil), ApplicationAnswer{QuestionID: 9, Answer: json.RawMessage(`true`)}), "", "", true},
+		{"question answered twice", append(with(nil), ApplicationAnswer{QuestionID: 1, Answer: json.RawMessage(`true`)}), "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			application, err := screenApplication(questions, tt.answers)
+			if tt.invalid {
+				if !errors.Is(err, ErrInvalidScreeningAnswers) {
+					t.Errorf("screenApplication = %v, want ErrInvalidScreeningAnswers", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("screenApplication: %v", err)
+			}
+			if application.Status != tt.status || application.RejectionReason != tt.reason {
+				t.Errorf("status = %s, reason = %q, want %s, %q", application.Status, application.RejectionReason, tt.status, tt.reason)
+			}
+		})
+	}
+}
+
+func TestScreenApplicationRecordsAnswers(t *testing.T) {
+	questions := []models.ScreeningQuestion{
+		{ID: 1, Prompt: "Languages?", Type: models.ScreeningMultipleChoice, Options: []string{"Go", "PHP"}, Required: true, Knockout: &models.KnockoutRule{Disqualifying: []string{"PHP"}}},
+		{ID: 2, Prompt: "Anything else?", Type: models.ScreeningText},
+	}
+	application, err := screenApplication(questions, []ApplicationAnswer{
+		{QuestionID: 1, Answer: json.RawMessage(`["PHP"]`)},
+	})
+	if err != nil {
+		t.Fatalf("screenApplication: %v", err)
+	}
+
+	// Unanswered optional questions are left out
+	want := []models.ScreeningAnswer{
+		{QuestionID: 1, Prompt: "Languages?", Type: models.ScreeningMultipleChoice, Answer: []string{"PHP"}, KnockedOut: true},
+	}
+	if !reflect.DeepEqual(application.Answers, want) {
+		t.Errorf("answers = %+v, want %+v", application.Answers, want)
+	}
+}
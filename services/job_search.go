@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"html"
+	"strings"
+	"synergylabs/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// searchQuerySQL parses a search in web search syntax with the same text search
+// configuration db.SetupJobSearch builds jobs.search_vector with
+const searchQuerySQL = "websearch_to_tsquery('english', ?)"
+
+// rankSQL scores how well a job matches the search; title matches weigh most
+const rankSQL = "ts_rank(jobs.search_vector, " + searchQuerySQL + ")"
+
+// ts_headline copies the job's text as is, so matches are delimited with
+// control characters that markHighlight turns into <mark> once the text is
+// HTML-escaped
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+
+	titleHeadlineOptions       = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", HighlightAll=true"
+	descriptionHeadlineOptions = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", MaxFragments=2, MaxWords=35, MinWords=15"
+)
+
+var highlightReplacer = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+// maxSuggestions caps the titles offered when a search finds nothing
+const maxSuggestions = 5
+
+// jobSearchScope matches jobs containing the search terms
+func jobSearchScope(query string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("jobs.search_vector @@ "+searchQuerySQL, query)
+	}
+}
+
+// searchResults adds the rank and highlighted excerpts to one page of matched
+// jobs. Highlighting is slow, so it is only done for the page being returned.
+func (s *JobService) searchResults(ctx context.Context, jobs []models.Job, query string) ([]JobSearchResult, error) {
+	results := make([]JobSearchResult, len(jobs))
+	if len(jobs) == 0 {
+		return results, nil
+	}
+
+	ids := make([]uint, len(jobs))
+	for i, job := range jobs {
+		ids[i] = job.ID
+	}
+
+	var rows []struct {
+		ID                   uint
+		Rank                 float64
+		TitleHighlight       string
+		DescriptionHighlight string
+	}
+	if err := s.db.WithContext(ctx).Table("jobs").
+		Select(
+			"jobs.id, "+rankSQL+" AS rank, "+
+				"ts_headline('english', jobs.title, "+searchQuerySQL+", ?) AS title_highlight, "+
+				"ts_headline('english', jobs.description, "+searchQuerySQL+", ?) AS description_highlight",
+			query, query, titleHeadlineOptions, query, descriptionHeadlineOptions,
+		).
+		Where("jobs.id IN ?", ids).
+		Scan(&rows).Error; err != nil {
+		s.logger.Error("Failed to highlight job search results", zap.Error(err))
+		return nil, err
+	}
+
+	byID := make(map[uint]int, len(rows))
+	for i, row := range rows {
+		byID[row.ID] = i
+	}
+	for i, job := range jobs {
+		results[i].Job = job
+		if j, ok := byID[job.ID]; ok {
+			results[i].Rank = rows[j].Rank
+			results[i].Highlights = JobHighlights{
+				Title:       markHighlight(rows[j].TitleHighlight),
+				Description: markHighlight(rows[j].DescriptionHighlight),
+			}
+		}
+	}
+	return results, nil
+}
+
+// markHighlight escapes a headline for HTML and wraps its matches in <mark>
+func markHighlight(headline string) string {
+	return highlightReplacer.Replace(html.EscapeString(headline))
+}
+
+// suggestJobTitles returns titles of visible jobs that contain a word close to
+// the search, so a misspelled search can be retried. visible must already be
+// limited to the jobs the caller may list.
+func (s *JobService) suggestJobTitles(visible *gorm.DB, query string) []string {
+	var titles []string
+	if err := visible.
+		Where("? <% jobs.title", query).
+		Group("jobs.title").
+		Order(clause.OrderBy{Expression: clause.Expr{SQL: "max(word_similarity(?, jobs.title)) DESC, jobs.title", Vars: []interface{}{query}}}).
+		Limit(maxSuggestions).
+		Pluck("jobs.title", &titles).Error; err != nil {
+		// Suggestions are a nicety; the search result stands without them
+		s.logger.Warn("Failed to suggest job titles", zap.Error(err))
+		return nil
+	}
+	return titles
+}
@@ -0,0 +1,21 @@
+package services
+
+import "testing"
+
+func TestMarkHighlight(t *testing.T) {
+	tests := []struct {
+		headline string
+		want     string
+	}{
+		{"Senior \x02Go\x03 engineer", "Senior <mark>Go</mark> engineer"},
+		{"\x02Go\x03 & \x02Rust\x03", "<mark>Go</mark> &amp; <mark>Rust</mark>"},
+		{"<img src=x onerror=\"alert(1)\"> \x02Go\x03", "&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>Go</mark>"},
+		{"\x02<script>\x03alert(1)</script>", "<mark>&lt;script&gt;</mark>alert(1)&lt;/script&gt;"},
+		{"<mark>not ours</mark>", "&lt;mark&gt;not ours&lt;/mark&gt;"},
+	}
+	for _, tt := range tests {
+		if got := markHighlight(tt.headline); got != tt.want {
+			t.Errorf("markHighlight(%q) = %q, want %q", tt.headline, got, tt.want)
+		}
+	}
+}
@@ -2,14 +2,21 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"synergylabs/models"
 	"synergylabs/services/cache"
+	"synergylabs/util"
 	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type JobService struct {
@@ -18,6 +25,8 @@ type JobService struct {
 	logger *zap.Logger
 }
 
+var _ JobServiceInterface = (*JobService)(nil)
+
 func NewJobService(db *gorm.DB, cache *cache.Cache, logger *zap.Logger) *JobService {
 	return &JobService{
 		db:     db,
@@ -28,25 +37,49 @@ func NewJobService(db *gorm.DB, cache *cache.Cache, logger *zap.Logger) *JobServ
 
 func (s *JobService) GetJobs(ctx context.Context, filters JobFilters) (*PaginatedResponse, error) {
 
-	cacheKey := fmt.Sprintf("%s:%s:%s:%d:%d",
-		JobsCacheKey,
-		filters.Title,
-		filters.CompanyName,
-		filters.Page,
-		filters.PageSize,
-	)
+	if err := validateJobFilters(filters); err != nil {
+		return nil, err
+	}
+	order, _ := jobOrder(filters)
+
+	// Every filter is part of the key, so hash them rather than listing them
+	filterKey, err := json.Marshal(filters)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := tenantCacheKey(ctx, fmt.Sprintf("%s:%d:%x", JobsCacheKey, s.listingGeneration(ctx), sha256.Sum256(filterKey)))
 
 	var response PaginatedResponse
 
 	// Try to get from cache
-	err := s.cache.Get(ctx, cacheKey, &response)
+	err = s.cache.Get(ctx, cacheKey, &response)
 	if err == nil {
 		return &response, nil
 	}
 
-	// Build query
+	// Build query. Open jobs are public, so callers outside any organization,
+	// such as applicants, list every organization's.
 	query := s.db.WithContext(ctx).Model(&models.Job{})
+	if filters.Status != "" || hasTenantAccess(ctx) {
+		query = query.Scopes(jobTenantScope(ctx))
+	}
+
+	switch filters.Status {
+	case "":
+		query = query.Scopes(openJobScope(time.Now()))
+	case JobStatusAll:
+	default:
+		query = query.Where("status = ?", filters.Status)
+	}
 
+	// Suggestions are drawn from the jobs the caller could see, before any other filter.
+	// After Session, each chained call copies the statement, so visible keeps these conditions only.
+	query = query.Session(&gorm.Session{})
+	visible := query
+
+	if filters.Query != "" {
+		query = query.Scopes(jobSearchScope(filters.Query))
+	}
 	if filters.Title != "" {
 		query = query.Where("title ILIKE ?", "%"+filters.Title+"%")
 	}
@@ -56,36 +89,36 @@ func (s *JobService) GetJobs(ctx context.Context, filters JobFilters) (*Paginate
 	if !filters.PostedAfter.IsZero() {
 		query = query.Where("posted_on >= ?", filters.PostedAfter)
 	}
+	query = query.Scopes(jobFilterScope(filters))
 
-	// Get total count
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		s.logger.Error("Failed to count jobs", zap.Error(err))
+	// Cursors only resume the listing they were issued for, whatever page they came from
+	listingFilters := filters
+	listingFilters.PageRequest = PageRequest{}
+	listing, err := listingID(string(JobsCacheKey), listingFilters)
+	if err != nil {
 		return nil, err
 	}
 
-	// Get paginated jobs
-	var jobs []models.Job
-	if err := query.
-		Preload("PostedBy"). // Eager load related data
-		Offset((filters.Page - 1) * filters.PageSize).
-		Limit(filters.PageSize).
-		Find(&jobs).Error; err != nil {
-		s.logger.Error("Failed to fetch jobs", zap.Error(err))
+	jobs, page, err := paginate(query, filters.PageRequest, order, jobKeyset(filters), listing,
+		func(db *gorm.DB) *gorm.DB { return db.Preload("PostedBy") })
+	if err != nil {
+		if !errors.Is(err, ErrInvalidCursor) {
+			s.logger.Error("Failed to fetch jobs", zap.Error(err))
+		}
 		return nil, err
 	}
-
-	totalPages := 1
-	if filters.PageSize > 0 {
-		totalPages = int(total)/filters.PageSize + 1
-	}
-
-	response = PaginatedResponse{
-		Data:       jobs,
-		Total:      total,
-		Page:       filters.Page,
-		PageSize:   filters.PageSize,
-		TotalPages: totalPages,
+	response = page
+	response.Data = jobs
+
+	if filters.Query != "" {
+		results, err := s.searchResults(ctx, jobs, filters.Query)
+		if err != nil {
+			return nil, err
+		}
+		response.Data = results
+		if len(results) == 0 && !page.HasPrev {
+			response.Suggestions = s.suggestJobTitles(visible, filters.Query)
+		}
 	}
 
 	// Cache the response
@@ -96,13 +129,30 @@ func (s *JobService) GetJobs(ctx context.Context, filters JobFilters) (*Paginate
 	return &response, nil
 }
 
-func (s *JobService) ApplyToJob(ctx context.Context, jobID, userID uint) error {
+// ApplyToJob records an application with the applicant's answers to the job's
+// screening questions. An answer that fails a knockout rule still records the
+// application, as rejected.
+func (s *JobService) ApplyToJob(ctx context.Context, jobID, userID uint, answers []ApplicationAnswer) (*models.JobApplication, error) {
 
 	// Start transaction
 	tx := s.db.WithContext(ctx).Begin()
 	if tx.Error != nil {
 		s.logger.Error("Failed to begin transaction", zap.Error(tx.Error))
-		return tx.Error
+		return nil, tx.Error
+	}
+
+	var job models.Job
+	if err := tx.First(&job, jobID).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		s.logger.Error("Failed to fetch job", zap.Error(err))
+		return nil, err
+	}
+	if !job.AcceptsApplications(time.Now()) {
+		tx.Rollback()
+		return nil, ErrJobNotOpen
 	}
 
 	// Check if already applied
@@ -114,64 +164,205 @@ func (s *JobService) ApplyToJob(ctx context.Context, jobID, userID uint) error {
 		Count(&count).Error; err != nil {
 		tx.Rollback()
 		s.logger.Error("Failed to check existing application", zap.Error(err))
-		return err
+		return nil, err
 	}
 
 	if count > 0 {
 		tx.Rollback()
-		return errors.New("already applied to this job")
+		return nil, ErrAlreadyApplied
+	}
+
+	questions, err := screeningQuestions(tx, jobID)
+	if err != nil {
+		tx.Rollback()
+		s.logger.Error("Failed to fetch screening questions", zap.Error(err))
+		return nil, err
+	}
+	application, err := screenApplication(questions, answers)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
 	}
 
 	// Apply to job
-	if err := tx.Model(&models.Job{}).
-		Where("id = ?", jobID).
-		Association("Applicants").
-		Append(&models.User{Model: gorm.Model{ID: userID}}); err != nil {
+	application.JobID = jobID
+	application.UserID = userID
+	if err := tx.Omit(clause.Associations).Create(application).Error; err != nil {
 		tx.Rollback()
+		// A concurrent request for the same application got there first
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrAlreadyApplied
+		}
 		s.logger.Error("Failed to apply to job", zap.Error(err))
-		return err
+		return nil, err
 	}
 
-	// Update total applications count
-	if err := tx.Model(&models.Job{}).
-		Where("id = ?", jobID).
-		UpdateColumn("total_applications", gorm.Expr("total_applications + ?", 1)).
-		Error; err != nil {
+	// Update total applications count; rejected applications are not counted
+	if application.Status == models.ApplicationSubmitted {
+		if err := tx.Model(&models.Job{}).
+			Where("id = ?", jobID).
+			UpdateColumn("total_applications", gorm.Expr("total_applications + ?", 1)).
+			Error; err != nil {
+			tx.Rollback()
+			s.logger.Error("Failed to update applications count", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	if err := appendAuditEvent(ctx, tx, &models.AuditEvent{
+		Action:     models.AuditActionApplication,
+		EntityType: "job",
+		EntityID:   strconv.FormatUint(uint64(jobID), 10),
+		Metadata:   map[string]interface{}{"user_id": userID, "status": application.Status},
+	}); err != nil {
 		tx.Rollback()
-		s.logger.Error("Failed to update applications count", zap.Error(err))
-		return err
+		s.logger.Error("Failed to audit job application", zap.Error(err))
+		return nil, err
 	}
 
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
 		s.logger.Error("Failed to commit transaction", zap.Error(err))
-		return err
+		return nil, err
 	}
 
-	// Invalidate cache
-	s.cache.Delete(ctx, string(JobsCacheKey))
+	// Only the cached job shows its applicants; listings and public documents
+	// stay in their generation, which an application does not change
+	s.cache.Delete(ctx, fmt.Sprintf("%s:%d", JobsCacheKey, jobID))
 
 	s.logger.Info("Successfully applied to job",
 		zap.Uint("job_id", jobID),
 		zap.Uint("user_id", userID),
+		zap.String("status", string(application.Status)),
 	)
 
-	return nil
+	return application, nil
 }
 
 func (s *JobService) CreateJob(ctx context.Context, job *models.Job) error {
-	if err := s.db.WithContext(ctx).Create(job).Error; err != nil {
+	if err := prepareNewJob(ctx, job, newJobStatuses); err != nil {
+		return err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return insertJob(ctx, tx, job)
+	})
+	if isJobWriteRefusal(err) {
+		return err
+	}
+	if err != nil {
 		s.logger.Error("Failed to create job", zap.Error(err))
 		return err
 	}
 	s.logger.Info("Job created successfully", zap.Uint("job_id", job.ID))
 
 	// Invalidate cache for jobs
-	s.cache.Delete(ctx, string(JobsCacheKey))
+	s.invalidateJobListings(ctx)
+
+	return nil
+}
+
+var (
+	// newJobStatuses are the statuses a job can be created in
+	newJobStatuses = []models.JobStatus{models.JobStatusDraft, models.JobStatusPublished}
+	// importedJobStatuses also let an import bring over jobs that already
+	// ended in the system they come from
+	importedJobStatuses = []models.JobStatus{models.JobStatusDraft, models.JobStatusPublished, models.JobStatusClosed, models.JobStatusArchived}
+)
+
+// prepareNewJob resets the fields a new job never takes from its creator and
+// validates the rest. The job's status must be one of statuses.
+func prepareNewJob(ctx context.Context, job *models.Job, statuses []models.JobStatus) error {
+	// Jobs always belong to the creator's organization
+	job.OrganizationID = nil
+	if orgID, ok := util.OrganizationFromContext(ctx); ok {
+		job.OrganizationID = &orgID
+	}
+
+	// Bookkeeping fields are never taken from the request
+	job.ID = 0
+	job.TotalApplications = 0
+	job.Version = 1
+	job.PostedBy = models.User{}
+	job.Applicants = nil
+	if job.PostedOn.IsZero() {
+		job.PostedOn = time.Now()
+	}
 
+	// New jobs are published straight away unless they are drafts or are
+	// scheduled to be published later
+	now := time.Now()
+	switch {
+	case job.Status == "":
+		job.Status = models.JobStatusPublished
+		if job.PublishAt != nil && job.PublishAt.After(now) {
+			job.Status = models.JobStatusDraft
+		}
+	case !slices.Contains(statuses, job.Status):
+		names := make([]string, len(statuses))
+		for i, status := range statuses {
+			names[i] = string(status)
+		}
+		return fmt.Errorf("%w: new jobs are %s", ErrInvalidJobStatus, strings.Join(names, ", "))
+	}
+	if err := validateJobSchedule(job); err != nil {
+		return err
+	}
+	if err := validateDraftPublishAt(job, now); err != nil {
+		return err
+	}
+	if err := validateJobAttributes(job); err != nil {
+		return err
+	}
+	job.StatusChangedAt = &now
+	job.StatusChangedByID = nil
+	if request := util.RequestInfoFromContext(ctx); request.ActorID != 0 {
+		job.StatusChangedByID = &request.ActorID
+	}
 	return nil
 }
 
+// insertJob saves a job prepared by prepareNewJob with its first status transition
+func insertJob(ctx context.Context, tx *gorm.DB, job *models.Job) error {
+	if job.HiringManagerID != nil {
+		if err := checkHiringManager(tx, *job.HiringManagerID, job.OrganizationID); err != nil {
+			return err
+		}
+	}
+	if err := tx.Create(job).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrJobExternalRefExists
+		}
+		return err
+	}
+	if err := tx.Create(&models.JobStatusTransition{
+		JobID:    job.ID,
+		ToStatus: job.Status,
+		ActorID:  job.StatusChangedByID,
+		Reason:   models.JobTransitionManual,
+	}).Error; err != nil {
+		return err
+	}
+	return recordAudit(ctx, tx, models.AuditActionJobCreate, "job", job.ID, nil, job)
+}
+
+// JobOwner loads only who posted and manages a job the caller's organization
+// can see, for checks under job:read:own
+func (s *JobService) JobOwner(ctx context.Context, id uint) (*models.Job, error) {
+	var job models.Job
+	err := s.db.WithContext(ctx).Scopes(jobTenantScope(ctx)).
+		Select("id", "posted_by_id", "hiring_manager_id").
+		First(&job, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		s.logger.Error("Failed to fetch job owner", zap.Error(err))
+		return nil, err
+	}
+	return &job, nil
+}
+
 func (s *JobService) GetJobWithApplicants(ctx context.Context, id uint) (*models.Job, error) {
 	cacheKey := fmt.Sprintf("%s:%d", JobsCacheKey, id)
 	var job models.Job
@@ -179,11 +370,19 @@ func (s *JobService) GetJobWithApplicants(ctx context.Context, id uint) (*models
 	// Try to get from cache
 	err := s.cache.Get(ctx, cacheKey, &job)
 	if err == nil {
+		if !inTenant(ctx, job.OrganizationID) {
+			return nil, ErrJobNotFound
+		}
 		return &job, nil
 	}
 
-	// If not found in cache, fetch from database
-	if err := s.db.WithContext(ctx).Preload("Applicants").First(&job, id).Error; err != nil {
+	// If not found in cache, fetch from database. Applicants rejected by a
+	// knockout rule are only listed by ListJobApplications.
+	submitted := s.db.Model(&models.JobApplication{}).Select("user_id").Where("job_id = ? AND status = ?", id, models.ApplicationSubmitted)
+	if err := s.db.WithContext(ctx).Preload("Applicants", "users.id IN (?)", submitted).First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
 		s.logger.Error("Failed to fetch job with applicants", zap.Error(err))
 		return nil, err
 	}
@@ -193,33 +392,309 @@ func (s *JobService) GetJobWithApplicants(ctx context.Context, id uint) (*models
 		s.logger.Warn("Failed to cache job with applicants", zap.Error(err))
 	}
 
+	if !inTenant(ctx, job.OrganizationID) {
+		return nil, ErrJobNotFound
+	}
+
 	return &job, nil
 }
 
-func (s *JobService) UpdateJob(ctx context.Context, job *models.Job) error {
-	if err := s.db.WithContext(ctx).Save(job).Error; err != nil {
-		s.logger.Error("Failed to update job", zap.Error(err))
-		return err
+// UpdateJob copies the named fields (by JSON name) from changes onto the job
+// and returns the result. Fields not listed, such as the application count,
+// are never written.
+func (s *JobService) UpdateJob(ctx context.Context, id uint, changes *models.Job, fields []string, pre JobPrecondition) (*models.Job, error) {
+	columns := make([]string, 0, len(fields)+1)
+	for _, field := range fields {
+		column, ok := jobUpdatableFields[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrJobFieldNotUpdatable, field)
+		}
+		columns = append(columns, column)
 	}
-	s.logger.Info("Job updated successfully", zap.Uint("job_id", job.ID))
 
-	// Invalidate cache for the specific job
-	cacheKey := fmt.Sprintf("%s:%d", JobsCacheKey, job.ID)
-	s.cache.Delete(ctx, cacheKey)
+	var job models.Job
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existing, err := s.loadJobForWrite(ctx, tx, id, pre)
+		if err != nil {
+			return err
+		}
+		return updateJobColumns(ctx, tx, existing, changes, columns, &job)
+	})
+	if err != nil {
+		if !isJobWriteRefusal(err) {
+			s.logger.Error("Failed to update job", zap.Error(err))
+		}
+		return nil, err
+	}
+	s.logger.Info("Job updated successfully", zap.Uint("job_id", id), zap.Uint("version", job.Version))
 
-	return nil
+	// Invalidate the cached job and the listings and feeds it appears in
+	s.invalidateJob(ctx, id)
+
+	return &job, nil
+}
+
+// updateJobColumns writes the given columns of changes onto existing, which
+// tx has locked, and loads the result into job
+func updateJobColumns(ctx context.Context, tx *gorm.DB, existing *models.Job, changes *models.Job, columns []string, job *models.Job) error {
+	if changes.HiringManagerID != nil && slices.Contains(columns, "hiring_manager_id") {
+		if err := checkHiringManager(tx, *changes.HiringManagerID, existing.OrganizationID); err != nil {
+			return err
+		}
+	}
+
+	changes.Version = existing.Version + 1
+	result := tx.Model(&models.Job{}).
+		Where("id = ? AND version = ?", existing.ID, existing.Version).
+		Select(append(columns, "version")).
+		Omit(clause.Associations).
+		Updates(changes)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return ErrJobExternalRefExists
+		}
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrJobVersionConflict
+	}
+
+	if err := tx.First(job, existing.ID).Error; err != nil {
+		return err
+	}
+	if err := validateJobSchedule(job); err != nil {
+		return err
+	}
+	if !sameTime(existing.PublishAt, job.PublishAt) {
+		if err := validateDraftPublishAt(job, time.Now()); err != nil {
+			return err
+		}
+	}
+	if err := validateJobAttributes(job); err != nil {
+		return err
+	}
+	return recordAudit(ctx, tx, models.AuditActionJobUpdate, "job", job.ID, existing, job)
 }
 
-func (s *JobService) DeleteJob(ctx context.Context, id uint) error {
-	if err := s.db.WithContext(ctx).Delete(&models.Job{}, id).Error; err != nil {
+func (s *JobService) DeleteJob(ctx context.Context, id uint, pre JobPrecondition) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		job, err := s.loadJobForWrite(ctx, tx, id, pre)
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(job).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditActionJobDelete, "job", job.ID, job, nil)
+	})
+	if isJobWriteRefusal(err) {
+		return err
+	}
+	if err != nil {
 		s.logger.Error("Failed to delete job", zap.Error(err))
 		return err
 	}
 	s.logger.Info("Job deleted successfully", zap.Uint("job_id", id))
 
-	// Invalidate cache for the deleted job
-	cacheKey := fmt.Sprintf("%s:%d", JobsCacheKey, id)
-	s.cache.Delete(ctx, cacheKey)
+	// Invalidate the cached job and the listings and feeds it appeared in
+	s.invalidateJob(ctx, id)
+
+	return nil
+}
+
+// AssignUnownedJobs records posterID as the poster of the visible jobs that
+// have none, which were created before posters were recorded. Until then only
+// callers allowed to change any job can change them.
+func (s *JobService) AssignUnownedJobs(ctx context.Context, posterID uint) (int, error) {
+	var assigned []uint
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var jobs []models.Job
+		if err := tx.Scopes(jobTenantScope(ctx)).
+			Where("posted_by_id = 0").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Find(&jobs).Error; err != nil {
+			return err
+		}
+		for i := range jobs {
+			job := &jobs[i]
+			staff, err := isOrganizationStaff(tx, posterID, job.OrganizationID)
+			if err != nil {
+				return err
+			}
+			if !staff {
+				return fmt.Errorf("%w: job %d", ErrInvalidPoster, job.ID)
+			}
+			before := *job
+			job.PostedByID = posterID
+			if err := tx.Model(job).Update("posted_by_id", posterID).Error; err != nil {
+				return err
+			}
+			if err := recordAudit(ctx, tx, models.AuditActionJobUpdate, "job", job.ID, &before, job); err != nil {
+				return err
+			}
+			assigned = append(assigned, job.ID)
+		}
+		return nil
+	})
+	if errors.Is(err, ErrInvalidPoster) {
+		return 0, err
+	}
+	if err != nil {
+		s.logger.Error("Failed to assign unowned jobs", zap.Error(err))
+		return 0, err
+	}
+
+	for _, id := range assigned {
+		s.cache.Delete(ctx, fmt.Sprintf("%s:%d", JobsCacheKey, id))
+	}
+	s.logger.Info("Unowned jobs assigned", zap.Uint("poster_id", posterID), zap.Int("jobs", len(assigned)))
+	return len(assigned), nil
+}
+
+// CloneJob creates a draft copy of a job, and of its screening questions,
+// posted by posterID. Applicants, counters and the publishing schedule are
+// not copied.
+func (s *JobService) CloneJob(ctx context.Context, id, posterID uint) (*models.Job, error) {
+	var source models.Job
+	if err := s.db.WithContext(ctx).Scopes(jobTenantScope(ctx)).First(&source, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		s.logger.Error("Failed to fetch job to clone", zap.Error(err))
+		return nil, err
+	}
+
+	clone := &models.Job{
+		Title:           source.Title,
+		Description:     source.Description,
+		CompanyName:     source.CompanyName,
+		PostedByID:      posterID,
+		HiringManagerID: source.HiringManagerID,
+		Status:          models.JobStatusDraft,
+		LocationCity:    source.LocationCity,
+		LocationCountry: source.LocationCountry,
+		Latitude:        source.Latitude,
+		Longitude:       source.Longitude,
+		RemotePolicy:    source.RemotePolicy,
+		SalaryMin:       source.SalaryMin,
+		SalaryMax:       source.SalaryMax,
+		SalaryCurrency:  source.SalaryCurrency,
+		SalaryPeriod:    source.SalaryPeriod,
+		EmploymentType:  source.EmploymentType,
+		Seniority:       source.Seniority,
+		Department:      source.Department,
+		TemplateID:      source.TemplateID,
+		ClonedFromID:    &source.ID,
+	}
+	if err := prepareNewJob(ctx, clone, newJobStatuses); err != nil {
+		return nil, err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := insertJob(ctx, tx, clone); err != nil {
+			return err
+		}
+		return copyScreeningQuestions(tx, source.ID, clone.ID)
+	})
+	if isJobWriteRefusal(err) {
+		return nil, err
+	}
+	if err != nil {
+		s.logger.Error("Failed to clone job", zap.Error(err))
+		return nil, err
+	}
+	s.logger.Info("Job cloned successfully", zap.Uint("job_id", clone.ID), zap.Uint("cloned_from_id", source.ID))
+
+	s.invalidateJobListings(ctx)
+	return clone, nil
+}
+
+// jobUpdatableFields maps the JSON names of the fields UpdateJob may change to their columns
+var jobUpdatableFields = map[string]string{
+	"title":             "title",
+	"description":       "description",
+	"company_name":      "company_name",
+	"hiring_manager_id": "hiring_manager_id",
+	"publish_at":        "publish_at",
+	"closes_at":         "closes_at",
+	"location_city":     "location_city",
+	"location_country":  "location_country",
+	"latitude":          "latitude",
+	"longitude":         "longitude",
+	"remote_policy":     "remote_policy",
+	"salary_min":        "salary_min",
+	"salary_max":        "salary_max",
+	"salary_currency":   "salary_currency",
+	"salary_period":     "salary_period",
+	"employment_type":   "employment_type",
+	"seniority":         "seniority",
+	"department":        "department",
+	"external_ref":      "external_ref",
+}
+
+// JobUpdatableFields lists the fields a full replacement of a job writes
+func JobUpdatableFields() []string {
+	fields := make([]string, 0, len(jobUpdatableFields))
+	for field := range jobUpdatableFields {
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// loadJobForWrite loads a job visible to the caller inside tx, locking it and
+// checking the precondition
+func (s *JobService) loadJobForWrite(ctx context.Context, tx *gorm.DB, id uint, pre JobPrecondition) (*models.Job, error) {
+	var job models.Job
+	if err := tx.Scopes(jobTenantScope(ctx)).Clauses(clause.Locking{Strength: "UPDATE"}).First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+	// Restricted callers can change the jobs they can read: ones they posted or manage
+	if pre.OwnerID != 0 && !job.IsManagedBy(pre.OwnerID) {
+		return nil, ErrNotJobOwner
+	}
+	if pre.Version != 0 && job.Version != pre.Version {
+		return nil, ErrJobVersionConflict
+	}
+	return &job, nil
+}
 
+// checkHiringManager requires the hiring manager to be staff in the job's organization
+func checkHiringManager(tx *gorm.DB, userID uint, orgID *uint) error {
+	staff, err := isOrganizationStaff(tx, userID, orgID)
+	if err != nil {
+		return err
+	}
+	if !staff {
+		return ErrInvalidHiringManager
+	}
 	return nil
 }
+
+// isOrganizationStaff reports whether userID is a staff member of the organization
+func isOrganizationStaff(tx *gorm.DB, userID uint, orgID *uint) (bool, error) {
+	query := tx.Model(&models.User{}).Where("id = ? AND user_type = ?", userID, models.UserTypeAdmin)
+	if orgID != nil {
+		query = query.Where("organization_id = ?", *orgID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func isJobWriteRefusal(err error) bool {
+	return errors.Is(err, ErrJobNotFound) ||
+		errors.Is(err, ErrNotJobOwner) ||
+		errors.Is(err, ErrJobVersionConflict) ||
+		errors.Is(err, ErrJobFieldNotUpdatable) ||
+		errors.Is(err, ErrInvalidHiringManager) ||
+		errors.Is(err, ErrInvalidJobStatus) ||
+		errors.Is(err, ErrJobTransitionNotAllowed) ||
+		errors.Is(err, ErrInvalidJobSchedule) ||
+		errors.Is(err, ErrInvalidJobAttribute) ||
+		errors.Is(err, ErrJobExternalRefExists)
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"synergylabs/models"
+	"synergylabs/util"
+	"testing"
+)
+
+// createTestStaff creates staff accounts outside any organization
+func createTestStaff(t *testing.T, s *JobService, names ...string) []models.User {
+	t.Helper()
+	users := make([]models.User, len(names))
+	for i, name := range names {
+		users[i] = models.User{Name: name, Email: testEmail(name), UserType: models.UserTypeAdmin}
+		if err := s.db.Create(&users[i]).Error; err != nil {
+			t.Fatalf("creating %s: %v", name, err)
+		}
+	}
+	return users
+}
+
+func TestJobOwnerIsScopedToTenant(t *testing.T) {
+	s := NewJobService(testDB(t), testCache(t), testLogger())
+	var orgs [2]models.Organization
+	for i := range orgs {
+		slug := testSSODomain()
+		orgs[i] = models.Organization{Name: slug, Slug: slug}
+		if err := s.db.Create(&orgs[i]).Error; err != nil {
+			t.Fatalf("creating organization: %v", err)
+		}
+	}
+	poster := createTestStaff(t, s, "owner")[0]
+	own := util.ContextWithOrganization(context.Background(), orgs[0].ID)
+	other := util.ContextWithOrganization(context.Background(), orgs[1].ID)
+
+	job := &models.Job{Title: "Owned job", Description: "Checked without its applicants", PostedByID: poster.ID}
+	if err := s.CreateJob(own, job); err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+
+	owner, err := s.JobOwner(own, job.ID)
+	if err != nil {
+		t.Fatalf("JobOwner: %v", err)
+	}
+	if !owner.IsManagedBy(poster.ID) || owner.Title != "" {
+		t.Errorf("JobOwner = %+v, want only the poster and hiring manager", owner)
+	}
+	if _, err := s.JobOwner(other, job.ID); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("JobOwner from another organization: got %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestRestrictedJobWritesFollowManagement(t *testing.T) {
+	s := NewJobService(testDB(t), testCache(t), testLogger())
+	ctx := platformAdminContext()
+	staff := createTestStaff(t, s, "poster", "manager", "stranger")
+	poster, manager, stranger := staff[0], staff[1], staff[2]
+
+	job := &models.Job{
+		Title:           "Managed job",
+		Description:     "Written by its poster, run by its hiring manager",
+		PostedByID:      poster.ID,
+		HiringManagerID: &manager.ID,
+	}
+	if err := s.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+
+	// Whoever can read a job under job:read:own can change it under job:update:own
+	for _, user := range []models.User{poster, manager} {
+		changes := &models.Job{Title: "Changed by " + user.Name}
+		if _, err := s.UpdateJob(ctx, job.ID, changes, []string{"title"}, JobPrecondition{OwnerID: user.ID}); err != nil {
+			t.Errorf("%s updating: %v", user.Name, err)
+		}
+	}
+	_, err := s.UpdateJob(ctx, job.ID, &models.Job{Title: "Hijacked"}, []string{"title"}, JobPrecondition{OwnerID: stranger.ID})
+	if !errors.Is(err, ErrNotJobOwner) {
+		t.Errorf("stranger updating: got %v, want ErrNotJobOwner", err)
+	}
+	if err := s.DeleteJob(ctx, job.ID, JobPrecondition{OwnerID: stranger.ID}); !errors.Is(err, ErrNotJobOwner) {
+		t.Errorf("stranger deleting: got %v, want ErrNotJobOwner", err)
+	}
+	if err := s.DeleteJob(ctx, job.ID, JobPrecondition{OwnerID: manager.ID}); err != nil {
+		t.Errorf("hiring manager deleting: %v", err)
+	}
+}
+
+func TestRejectedApplicationsAreNotCounted(t *testing.T) {
+	s := NewJobService(testDB(t), testCache(t), testLogger())
+	ctx := platformAdminContext()
+	poster := createTestStaff(t, s, "screener")[0]
+
+	job := &models.Job{Title: "Screened job", Description: "Asks for a work permit", PostedByID: poster.ID}
+	if err := s.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	yes := true
+	questions, err := s.ReplaceScreeningQuestions(ctx, job.ID, []models.ScreeningQuestion{
+		{Prompt: "Do you have a work permit?", Type: models.ScreeningYesNo, Required: true, Knockout: &models.KnockoutRule{Expected: &yes}},
+	}, JobPrecondition{})
+	if err != nil {
+		t.Fatalf("ReplaceScreeningQuestions: %v", err)
+	}
+
+	applicants := make(map[bool]models.User)
+	for _, permit := range []bool{true, false} {
+		user := models.User{Name: "Applicant", Email: testEmail("applicant"), UserType: models.UserTypeApplicant}
+		if err := s.db.Create(&user).Error; err != nil {
+			t.Fatalf("creating applicant: %v", err)
+		}
+		answer, _ := json.Marshal(permit)
+		if _, err := s.ApplyToJob(context.Background(), job.ID, user.ID, []ApplicationAnswer{{QuestionID: questions[0].ID, Answer: answer}}); err != nil {
+			t.Fatalf("ApplyToJob: %v", err)
+		}
+		applicants[permit] = user
+	}
+
+	loaded, err := s.GetJobWithApplicants(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("GetJobWithApplicants: %v", err)
+	}
+	if loaded.TotalApplications != 1 {
+		t.Errorf("total_applications = %d, want 1", loaded.TotalApplications)
+	}
+	if len(loaded.Applicants) != 1 || loaded.Applicants[0].ID != applicants[true].ID {
+		t.Errorf("applicants = %v, want only the submitted application's applicant", loaded.Applicants)
+	}
+
+	// The rejected application is still there for whoever reviews them
+	rejected, err := s.ListJobApplications(ctx, job.ID, models.ApplicationRejected)
+	if err != nil {
+		t.Fatalf("ListJobApplications: %v", err)
+	}
+	if len(rejected) != 1 || rejected[0].UserID != applicants[false].ID {
+		t.Errorf("rejected applications = %v, want the one without a permit", rejected)
+	}
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"synergylabs/models"
+	"synergylabs/util"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type JobTemplateService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+var _ JobTemplateServiceInterface = (*JobTemplateService)(nil)
+
+func NewJobTemplateService(db *gorm.DB, logger *zap.Logger) *JobTemplateService {
+	return &JobTemplateService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ListTemplates lists the caller's organization's templates by name
+func (s *JobTemplateService) ListTemplates(ctx context.Context) ([]JobTemplateView, error) {
+	var templates []models.JobTemplate
+	if err := s.db.WithContext(ctx).Scopes(jobTemplateTenantScope(ctx)).Order("name").Find(&templates).Error; err != nil {
+		s.logger.Error("Failed to fetch job templates", zap.Error(err))
+		return nil, err
+	}
+
+	views := make([]JobTemplateView, len(templates))
+	for i := range templates {
+		views[i] = JobTemplateView{JobTemplate: templates[i], Variables: templates[i].Variables()}
+	}
+	return views, nil
+}
+
+func (s *JobTemplateService) GetTemplate(ctx context.Context, id uint) (*JobTemplateView, error) {
+	template, err := s.loadTemplate(ctx, s.db.WithContext(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	return &JobTemplateView{JobTemplate: *template, Variables: template.Variables()}, nil
+}
+
+// CreateTemplate saves a template in the caller's organization. Its
+// structured attributes are validated like a job's; placeholders only appear
+// in the text fields.
+func (s *JobTemplateService) CreateTemplate(ctx context.Context, template *models.JobTemplate) error {
+	template.ID = 0
+	template.OrganizationID = nil
+	if orgID, ok := util.OrganizationFromContext(ctx); ok {
+		template.OrganizationID = &orgID
+	}
+	if err := validateJobAttributes(template.Job()); err != nil {
+		return err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(template).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditActionJobTemplateCreate, "job_template", template.ID, nil, template)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrJobTemplateExists
+		}
+		s.logger.Error("Failed to create job template", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Job template created successfully", zap.Uint("template_id", template.ID))
+	return nil
+}
+
+// UpdateTemplate replaces a template's name and content. Its organization
+// and creator are kept.
+func (s *JobTemplateService) UpdateTemplate(ctx context.Context, template *models.JobTemplate) error {
+	if err := validateJobAttributes(template.Job()); err != nil {
+		return err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existing, err := s.loadTemplate(ctx, tx, template.ID)
+		if err != nil {
+			return err
+		}
+		template.CreatedAt = existing.CreatedAt
+		template.OrganizationID = existing.OrganizationID
+		template.CreatedByID = existing.CreatedByID
+		if err := tx.Save(template).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditActionJobTemplateUpdate, "job_template", template.ID, existing, template)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrJobTemplateExists
+		}
+		if !errors.Is(err, ErrJobTemplateNotFound) {
+			s.logger.Error("Failed to update job template", zap.Error(err))
+		}
+		return err
+	}
+
+	s.logger.Info("Job template updated successfully", zap.Uint("template_id", template.ID))
+	return nil
+}
+
+func (s *JobTemplateService) DeleteTemplate(ctx context.Context, id uint) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		template, err := s.loadTemplate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(template).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditActionJobTemplateDelete, "job_template", id, template, nil)
+	})
+	if err != nil {
+		if !errors.Is(err, ErrJobTemplateNotFound) {
+			s.logger.Error("Failed to delete job template", zap.Error(err))
+		}
+		return err
+	}
+
+	s.logger.Info("Job template deleted successfully", zap.Uint("template_id", id))
+	return nil
+}
+
+// RenderTemplate fills in a template's placeholders and returns the job to
+// create from it. vars must give a value for every variable the template uses
+// and nothing else, so a misspelled name is caught.
+func (s *JobTemplateService) RenderTemplate(ctx context.Context, id uint, vars map[string]string) (*models.Job, error) {
+	template, err := s.loadTemplate(ctx, s.db.WithContext(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+
+	used := template.Variables()
+	var missing, unknown []string
+	for _, name := range used {
+		if _, ok := vars[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	for name := range vars {
+		if !slices.Contains(used, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	switch {
+	case len(missing) > 0:
+		return nil, fmt.Errorf("%w: missing %s", ErrInvalidTemplateVariables, strings.Join(missing, ", "))
+	case len(unknown) > 0:
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("%w: the template does not use %s", ErrInvalidTemplateVariables, strings.Join(unknown, ", "))
+	}
+
+	rendered := template.Render(vars)
+	job := rendered.Job()
+	job.TemplateID = &template.ID
+	return job, nil
+}
+
+func (s *JobTemplateService) loadTemplate(ctx context.Context, tx *gorm.DB, id uint) (*models.JobTemplate, error) {
+	var template models.JobTemplate
+	if err := tx.Scopes(jobTemplateTenantScope(ctx)).First(&template, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobTemplateNotFound
+		}
+		s.logger.Error("Failed to fetch job template", zap.Error(err))
+		return nil, err
+	}
+	return &template, nil
+}
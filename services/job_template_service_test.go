@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"synergylabs/models"
+	"testing"
+)
+
+func TestJobTemplateRender(t *testing.T) {
+	template := models.JobTemplate{
+		Title:        "{{level}} Engineer, {{ city }}",
+		Description:  "Join us in {{city}}. Use {{ }} or {{1st}} or {city} as you like.",
+		CompanyName:  "Acme",
+		LocationCity: "{{city}}",
+		Department:   "{{team}}",
+	}
+	if got, want := template.Variables(), []string{"city", "level", "team"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Variables = %v, want %v", got, want)
+	}
+
+	rendered := template.Render(map[string]string{"city": "Berlin", "level": "Senior", "team": "{{level}}"})
+	want := models.JobTemplate{
+		Title:        "Senior Engineer, Berlin",
+		Description:  "Join us in Berlin. Use {{ }} or {{1st}} or {city} as you like.",
+		CompanyName:  "Acme",
+		LocationCity: "Berlin",
+		// Values are inserted as they are, never expanded again
+		Department: "{{level}}",
+	}
+	if !reflect.DeepEqual(rendered, want) {
+		t.Errorf("Render = %+v, want %+v", rendered, want)
+	}
+	if template.Title != "{{level}} Engineer, {{ city }}" {
+		t.Errorf("Render changed the template: %q", template.Title)
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	s := NewJobTemplateService(testDB(t), testLogger())
+	ctx := platformAdminContext()
+
+	template := &models.JobTemplate{
+		Name:         testEmail("template"),
+		Title:        "{{level}} Engineer",
+		Description:  "Work from {{city}}.",
+		CompanyName:  "Acme",
+		LocationCity: "{{city}}",
+	}
+	if err := s.CreateTemplate(ctx, template); err != nil {
+		t.Fatalf("CreateTemplate: %v", err)
+	}
+
+	job, err := s.RenderTemplate(ctx, template.ID, map[string]string{"city": "Berlin", "level": "Senior"})
+	if err != nil {
+		t.Fatalf("RenderTemplate: %v", err)
+	}
+	if job.Title != "Senior Engineer" || job.Description != "Work from Berlin." || job.LocationCity != "Berlin" || job.CompanyName != "Acme" {
+		t.Errorf("job = %q, %q, %q, %q", job.Title, job.Description, job.LocationCity, job.CompanyName)
+	}
+	if job.TemplateID == nil || *job.TemplateID != template.ID {
+		t.Errorf("TemplateID = %v, want %d", job.TemplateID, template.ID)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		vars    map[string]string
+		mention string
+	}{
+		{"no values", nil, "missing city, level"},
+		{"missing value", map[string]string{"city": "Berlin"}, "missing level"},
+		{"empty value is still given", map[string]string{"city": "", "level": "Senior"}, ""},
+		{"unknown placeholder", map[string]string{"city": "Berlin", "level": "Senior", "salary": "a lot"}, "does not use salary"},
+	} {
+		_, err := s.RenderTemplate(ctx, template.ID, tt.vars)
+		if tt.mention == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidTemplateVariables) || !strings.Contains(err.Error(), tt.mention) {
+			t.Errorf("%s: error = %v, want ErrInvalidTemplateVariables mentioning %q", tt.name, err, tt.mention)
+		}
+	}
+
+	if _, err := s.RenderTemplate(context.Background(), template.ID, nil); !errors.Is(err, ErrJobTemplateNotFound) {
+		t.Errorf("outside any organization: error = %v, want %v", err, ErrJobTemplateNotFound)
+	}
+}
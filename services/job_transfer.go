@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"synergylabs/models"
+	"synergylabs/util"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxImportRows bounds one import, which runs in a single transaction
+const maxImportRows = 10000
+
+// errImportRolledBack ends the import transaction without saving anything
+var errImportRolledBack = errors.New("import rolled back")
+
+// ImportJobs creates or updates a job for each record in r. A record updates
+// the job with the same external_ref in the caller's organization and creates
+// one otherwise. Rows that fail validation are reported and skipped, unless
+// opts.AllOrNothing is set, in which case nothing is saved.
+func (s *JobService) ImportJobs(ctx context.Context, r io.Reader, opts JobImportOptions) (*JobImportReport, error) {
+	reader, err := newJobRecordReader(r, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	report := &JobImportReport{DryRun: opts.DryRun, AllOrNothing: opts.AllOrNothing, Errors: []JobImportError{}}
+
+	var changed []uint
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var posters int64
+		if err := tx.Model(&models.User{}).Where("id = ?", opts.PosterID).Count(&posters).Error; err != nil {
+			return err
+		}
+		if posters == 0 {
+			return fmt.Errorf("%w: poster %d does not exist", ErrInvalidJobImport, opts.PosterID)
+		}
+
+		// Lines are kept by reference so a file cannot write the same job twice
+		lines := map[string]int{}
+		for {
+			record, line, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil && !errors.Is(err, ErrInvalidJobRecord) {
+				return err
+			}
+			report.Rows++
+			if report.Rows > maxImportRows {
+				return fmt.Errorf("%w: more than %d rows", ErrInvalidJobImport, maxImportRows)
+			}
+
+			if err == nil {
+				err = record.validate()
+			}
+			if err == nil {
+				if first, ok := lines[record.ExternalRef]; ok {
+					err = fmt.Errorf("%w: external_ref is also used on line %d", ErrInvalidJobRecord, first)
+				} else {
+					lines[record.ExternalRef] = line
+				}
+			}
+
+			var job *models.Job
+			var created bool
+			if err == nil {
+				job = record.job()
+				// Each row runs in a savepoint, so a failed row leaves no trace
+				err = tx.Transaction(func(tx *gorm.DB) error {
+					var err error
+					created, err = importJob(ctx, tx, job, record.fields, opts.PosterID)
+					return err
+				})
+			}
+
+			if err != nil {
+				if !errors.Is(err, ErrInvalidJobRecord) && !isJobWriteRefusal(err) {
+					return err
+				}
+				rowError := JobImportError{Line: line, Error: err.Error()}
+				if record != nil {
+					rowError.ExternalRef = record.ExternalRef
+				}
+				report.Failed++
+				report.Errors = append(report.Errors, rowError)
+				continue
+			}
+			if created {
+				report.Created++
+			} else {
+				report.Updated++
+			}
+			changed = append(changed, job.ID)
+		}
+
+		if opts.DryRun || (opts.AllOrNothing && report.Failed > 0) {
+			return errImportRolledBack
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportRolledBack) {
+		if !errors.Is(err, ErrInvalidJobImport) {
+			s.logger.Error("Failed to import jobs", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	report.Committed = err == nil
+	if report.Committed && len(changed) > 0 {
+		for _, id := range changed {
+			s.cache.Delete(ctx, fmt.Sprintf("%s:%d", JobsCacheKey, id))
+		}
+		s.invalidateJobListings(ctx)
+	}
+	s.logger.Info("Jobs imported",
+		zap.Int("rows", report.Rows),
+		zap.Int("created", report.Created),
+		zap.Int("updated", report.Updated),
+		zap.Int("failed", report.Failed),
+		zap.Bool("committed", report.Committed),
+	)
+	return report, nil
+}
+
+// importJob updates the job with the same external reference, locked by tx,
+// or creates it. An update only writes the columns in fields. It reports
+// whether the job was created.
+func importJob(ctx context.Context, tx *gorm.DB, job *models.Job, fields []string, posterID uint) (bool, error) {
+	query := tx.Scopes(jobTenantScope(ctx)).Clauses(clause.Locking{Strength: "UPDATE"})
+	if _, ok := util.OrganizationFromContext(ctx); !ok {
+		query = query.Where("jobs.organization_id IS NULL")
+	}
+	query = query.Session(&gorm.Session{})
+
+	var existing models.Job
+	err := query.Where("external_ref = ?", *job.ExternalRef).First(&existing).Error
+	// A job exported without a reference comes back as job-<id> and takes it on
+	if id, ok := parseDefaultExternalRef(*job.ExternalRef); ok && errors.Is(err, gorm.ErrRecordNotFound) {
+		err = query.Where("external_ref IS NULL").First(&existing, id).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		job.PostedByID = posterID
+		if err := prepareNewJob(ctx, job, importedJobStatuses); err != nil {
+			return false, err
+		}
+		return true, insertJob(ctx, tx, job)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// Imports replace a job's content; its status keeps its own history
+	if job.Status != "" && job.Status != existing.Status {
+		return false, fmt.Errorf("%w: the job is %s; change its status through the status route", ErrInvalidJobStatus, existing.Status)
+	}
+	columns := []string{"external_ref"}
+	for _, field := range fields {
+		if column, ok := jobUpdatableFields[field]; ok && column != "external_ref" {
+			columns = append(columns, column)
+		}
+	}
+	changes := *job
+	return false, updateJobColumns(ctx, tx, &existing, &changes, columns, job)
+}
+
+// ExportJobs streams the caller's organization's jobs to w in the given
+// format, oldest first, optionally only those with the given status. It
+// returns how many jobs were written.
+func (s *JobService) ExportJobs(ctx context.Context, w io.Writer, format JobDataFormat, status models.JobStatus) (int, error) {
+	writer, err := newJobRecordWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	query := s.db.WithContext(ctx).Model(&models.Job{}).Scopes(jobTenantScope(ctx)).Order("id")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	rows, err := query.Rows()
+	if err != nil {
+		s.logger.Error("Failed to export jobs", zap.Error(err))
+		return 0, err
+	}
+	defer rows.Close()
+
+	exported := 0
+	for rows.Next() {
+		var job models.Job
+		if err := query.ScanRows(rows, &job); err != nil {
+			s.logger.Error("Failed to export jobs", zap.Error(err))
+			return exported, err
+		}
+		if err := writer.Write(newJobRecord(&job)); err != nil {
+			return exported, err
+		}
+		exported++
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Error("Failed to export jobs", zap.Error(err))
+		return exported, err
+	}
+	return exported, writer.Flush()
+}
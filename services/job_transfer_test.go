@@ -0,0 +1,171 @@
+package services
+
+import (
+	"encoding/csv"
+	"reflect"
+	"sort"
+	"strings"
+	"synergylabs/models"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestJobRecordFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		format JobDataFormat
+		input  string
+		want   []string
+	}{
+		// An empty cell is still a column of the file and clears it
+		{"csv", JobFormatCSV, "external_ref,title,description,department\nref,Title,Description,\n", []string{"department", "description", "external_ref", "title"}},
+		{"jsonl", JobFormatJSONL, `{"external_ref":"ref","title":"Title","description":"Description","seniority":null}` + "\n", []string{"description", "external_ref", "seniority", "title"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := newJobRecordReader(strings.NewReader(tt.input), tt.format)
+			if err != nil {
+				t.Fatalf("newJobRecordReader: %v", err)
+			}
+			record, _, err := reader.Read()
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+			fields := append([]string(nil), record.fields...)
+			sort.Strings(fields)
+			if !reflect.DeepEqual(fields, tt.want) {
+				t.Errorf("fields = %q, want %q", fields, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVExportEscapesFormulas(t *testing.T) {
+	latitude := -33.87
+	record := &JobRecord{
+		ExternalRef:  "ref",
+		Title:        "=HYPERLINK(\"https://evil.example.com\")",
+		Description:  "+49 30 1234",
+		CompanyName:  "'=already quoted",
+		Department:   "@support",
+		LocationCity: "Sydney",
+		Latitude:     &latitude,
+	}
+	var out strings.Builder
+	writer, err := newJobRecordWriter(&out, JobFormatCSV)
+	if err != nil {
+		t.Fatalf("newJobRecordWriter: %v", err)
+	}
+	if err := writer.Write(record); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("reading the export: %v", err)
+	}
+	cells := map[string]string{}
+	for i, name := range rows[0] {
+		cells[name] = rows[1][i]
+	}
+	want := map[string]string{
+		"title":         "'=HYPERLINK(\"https://evil.example.com\")",
+		"description":   "'+49 30 1234",
+		"company_name":  "''=already quoted",
+		"department":    "'@support",
+		"location_city": "Sydney",
+		// Numbers are never prefixed
+		"latitude": "-33.87",
+	}
+	for name, cell := range want {
+		if cells[name] != cell {
+			t.Errorf("%s cell = %q, want %q", name, cells[name], cell)
+		}
+	}
+
+	reader, err := newJobRecordReader(strings.NewReader(out.String()), JobFormatCSV)
+	if err != nil {
+		t.Fatalf("newJobRecordReader: %v", err)
+	}
+	imported, _, err := reader.Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	imported.fields = nil
+	if !reflect.DeepEqual(imported, record) {
+		t.Errorf("imported %+v, want %+v", imported, record)
+	}
+}
+
+func TestDefaultExternalRef(t *testing.T) {
+	if got := newJobRecord(&models.Job{Model: gorm.Model{ID: 42}}).ExternalRef; got != "job-42" {
+		t.Errorf("exported reference of a job without one = %q, want job-42", got)
+	}
+	ref := "ATS-7"
+	if got := newJobRecord(&models.Job{Model: gorm.Model{ID: 42}, ExternalRef: &ref}).ExternalRef; got != ref {
+		t.Errorf("exported reference = %q, want %q", got, ref)
+	}
+
+	if id, ok := parseDefaultExternalRef("job-42"); !ok || id != 42 {
+		t.Errorf("parseDefaultExternalRef(job-42) = %d, %v", id, ok)
+	}
+	for _, ref := range []string{"job-", "job-0", "job-042", "job-+4", "job-4x", "job-99999999999", "ATS-42"} {
+		if _, ok := parseDefaultExternalRef(ref); ok {
+			t.Errorf("parseDefaultExternalRef(%q) matched", ref)
+		}
+	}
+}
+
+func TestImportWritesOnlyPresentColumns(t *testing.T) {
+	s := NewJobService(testDB(t), testCache(t), testLogger())
+	ctx := platformAdminContext()
+	poster := createTestStaff(t, s, "importer")[0]
+
+	job := &models.Job{Title: "Exported", Description: "Without a reference", Department: "Sales", PostedByID: poster.ID}
+	if err := s.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+
+	// The export's job-<id> reference finds the job again, and the row leaves
+	// out department, which keeps its value
+	input := `{"external_ref":"` + defaultExternalRef(job.ID) + `","title":"Imported","description":"Without a reference"}` + "\n"
+	report, err := s.ImportJobs(ctx, strings.NewReader(input), JobImportOptions{Format: JobFormatJSONL, PosterID: poster.ID})
+	if err != nil {
+		t.Fatalf("ImportJobs: %v", err)
+	}
+	if report.Updated != 1 || report.Created != 0 {
+		t.Fatalf("report = %+v, want one update", report)
+	}
+	var updated models.Job
+	if err := s.db.First(&updated, job.ID).Error; err != nil {
+		t.Fatalf("reloading job: %v", err)
+	}
+	if updated.Title != "Imported" || updated.Department != "Sales" {
+		t.Errorf("job = %q in %q, want Imported in Sales", updated.Title, updated.Department)
+	}
+	if updated.ExternalRef == nil || *updated.ExternalRef != defaultExternalRef(job.ID) {
+		t.Errorf("external_ref = %v, want %s", updated.ExternalRef, defaultExternalRef(job.ID))
+	}
+
+	// Jobs that already ended elsewhere can be brought over as they are
+	ref := testEmail("closed")
+	input = "external_ref,title,description,status\n" + ref + ",Closed,Filled last year,CLOSED\n"
+	report, err = s.ImportJobs(ctx, strings.NewReader(input), JobImportOptions{Format: JobFormatCSV, PosterID: poster.ID})
+	if err != nil {
+		t.Fatalf("ImportJobs: %v", err)
+	}
+	if report.Created != 1 {
+		t.Fatalf("report = %+v, want one created job", report)
+	}
+	var closed models.Job
+	if err := s.db.Where("external_ref = ?", ref).First(&closed).Error; err != nil {
+		t.Fatalf("loading imported job: %v", err)
+	}
+	if closed.Status != models.JobStatusClosed {
+		t.Errorf("status = %s, want %s", closed.Status, models.JobStatusClosed)
+	}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"synergylabs/models"
+	"testing"
+	"time"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	tests := []struct {
+		name   string
+		excess int64
+		base   time.Duration
+		max    time.Duration
+		want   time.Duration
+	}{
+		{"at the threshold", 0, time.Minute, time.Hour, time.Minute},
+		{"one past", 1, time.Minute, time.Hour, 2 * time.Minute},
+		{"three past", 3, time.Minute, time.Hour, 8 * time.Minute},
+		{"just under the cap", 5, time.Minute, time.Hour, 32 * time.Minute},
+		{"doubling past the cap", 6, time.Minute, time.Hour, time.Hour},
+		{"far past the cap", 1000, time.Minute, time.Hour, time.Hour},
+		{"base above the cap", 0, 2 * time.Hour, time.Hour, time.Hour},
+		{"account lockout cap", 10, accountBaseLockout, accountMaxLockout, accountMaxLockout},
+		{"ip lockout cap", 10, ipBaseLockout, ipMaxLockout, ipMaxLockout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lockoutDuration(tt.excess, tt.base, tt.max); got != tt.want {
+				t.Errorf("lockoutDuration(%d, %s, %s) = %s, want %s", tt.excess, tt.base, tt.max, got, tt.want)
+			}
+		})
+	}
+}
+
+// testIP returns a client IP no other test run has used
+func testIP() string {
+	now := time.Now().UnixNano()
+	return fmt.Sprintf("2001:db8:%x:%x::%x", now>>32&0xffff, now>>16&0xffff, emailSeq.Add(1))
+}
+
+// assertLocked fails unless the email or IP is locked for at most max
+func assertLocked(t *testing.T, err error, max time.Duration) {
+	t.Helper()
+	var locked *AccountLockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("error = %v, want an AccountLockedError", err)
+	}
+	if locked.RetryAfter <= 0 || locked.RetryAfter > max {
+		t.Errorf("RetryAfter = %s, want at most %s", locked.RetryAfter, max)
+	}
+}
+
+func TestAccountLockoutThreshold(t *testing.T) {
+	s := NewUserService(nil, testCache(t), testLogger())
+	ctx := context.Background()
+	email := normalizeEmail(testEmail("locked"))
+
+	for i := 1; i < accountFailureThreshold; i++ {
+		s.recordLoginFailure(ctx, email, testIP())
+	}
+	if err := s.checkLoginLockout(ctx, email, testIP()); err != nil {
+		t.Fatalf("locked after %d failures: %v", accountFailureThreshold-1, err)
+	}
+
+	s.recordLoginFailure(ctx, email, testIP())
+	assertLocked(t, s.checkLoginLockout(ctx, email, testIP()), accountBaseLockout)
+	if err := s.checkLoginLockout(ctx, normalizeEmail(testEmail("other")), testIP()); err != nil {
+		t.Errorf("another account is locked: %v", err)
+	}
+
+	// Every failure past the threshold doubles the lockout
+	s.recordLoginFailure(ctx, email, testIP())
+	assertLocked(t, s.checkLoginLockout(ctx, email, testIP()), 2*accountBaseLockout)
+}
+
+func TestIPLockoutThreshold(t *testing.T) {
+	s := NewUserService(nil, testCache(t), testLogger())
+	ctx := context.Background()
+	ip := testIP()
+
+	// Spraying one password across many accounts never locks any of them
+	for i := 1; i < ipFailureThreshold; i++ {
+		s.recordLoginFailure(ctx, normalizeEmail(testEmail("spray")), ip)
+	}
+	if err := s.checkLoginLockout(ctx, normalizeEmail(testEmail("spray")), ip); err != nil {
+		t.Fatalf("locked after %d failures: %v", ipFailureThreshold-1, err)
+	}
+
+	s.recordLoginFailure(ctx, normalizeEmail(testEmail("spray")), ip)
+	assertLocked(t, s.checkLoginLockout(ctx, normalizeEmail(testEmail("spray")), ip), ipBaseLockout)
+	if err := s.checkLoginLockout(ctx, normalizeEmail(testEmail("spray")), testIP()); err != nil {
+		t.Errorf("another IP is locked: %v", err)
+	}
+}
+
+func TestLoginLockoutIgnoresEmailCase(t *testing.T) {
+	s := NewUserService(testDB(t), testCache(t), testLogger())
+	ctx := context.Background()
+
+	email := testEmail("Mixed.Case")
+	user := models.User{Name: "Jane", Email: " " + email, UserType: models.UserTypeApplicant, PasswordHash: "correct-password"}
+	if err := s.CreateUser(ctx, &user); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if user.Email != strings.ToLower(email) {
+		t.Errorf("stored email = %q, want %q", user.Email, strings.ToLower(email))
+	}
+	duplicate := models.User{Name: "Jane", Email: strings.ToUpper(email), UserType: models.UserTypeApplicant, PasswordHash: "correct-password"}
+	if err := s.CreateUser(ctx, &duplicate); !errors.Is(err, ErrEmailExists) {
+		t.Errorf("signing up with the email upper-cased: error = %v, want %v", err, ErrEmailExists)
+	}
+
+	got, err := s.ValidateLogin(ctx, strings.ToUpper(email), "correct-password", testIP())
+	if err != nil {
+		t.Fatalf("signing in with the email upper-cased: %v", err)
+	}
+	if got.ID != user.ID {
+		t.Errorf("signed in as user %d, want %d", got.ID, user.ID)
+	}
+
+	for i := 0; i < accountFailureThreshold; i++ {
+		if _, err := s.ValidateLogin(ctx, strings.ToUpper(email), "wrong-password", testIP()); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("failure %d: error = %v, want %v", i+1, err, ErrInvalidCredentials)
+		}
+	}
+	if _, err := s.ValidateLogin(ctx, strings.ToLower(email), "correct-password", testIP()); !errors.Is(err, ErrAccountLocked) {
+		t.Errorf("signing in with the email lower-cased after the lockout: error = %v, want %v", err, ErrAccountLocked)
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"synergylabs/db"
+	"synergylabs/models"
+	"synergylabs/services/cache"
+	"synergylabs/util"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	migrateOnce sync.Once
+	migrateErr  error
+	keyringOnce sync.Once
+	emailSeq    atomic.Int64
+)
+
+// testDB connects to the database in TEST_DATABASE_URL and migrates it. Tests
+// that need a database are skipped when it is not set. Tests share the
+// database, so they create their own users and never rely on it being empty.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	conn, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		TranslateError: true,
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("connecting to test database: %v", err)
+	}
+	migrateOnce.Do(func() { migrateErr = db.Migrate(conn) })
+	if migrateErr != nil {
+		t.Fatalf("migrating test database: %v", migrateErr)
+	}
+	return conn
+}
+
+// testCache connects to the Redis server in TEST_REDIS_ADDR. Tests that need
+// Redis are skipped when it is not set.
+func testCache(t *testing.T) *cache.Cache {
+	t.Helper()
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR is not set")
+	}
+	return cache.NewCache(addr)
+}
+
+// testKeyring installs a throwaway signing key so tokens can be issued
+func testKeyring(t *testing.T) {
+	t.Helper()
+	keyringOnce.Do(func() {
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("generating signing key: %v", err)
+		}
+		keyring, err := util.NewKeyring("test", &util.SigningKey{
+			ID:         "test",
+			Method:     util.EdDSA,
+			PrivateKey: private,
+			PublicKey:  public,
+		})
+		if err != nil {
+			t.Fatalf("building keyring: %v", err)
+		}
+		util.SetKeyring(keyring)
+	})
+}
+
+// testEmail returns an address no other test run has used
+func testEmail(prefix string) string {
+	return fmt.Sprintf("%s-%d-%d@example.com", prefix, time.Now().UnixNano(), emailSeq.Add(1))
+}
+
+// testPermissions resolves permissions from a fixed map of user IDs
+type testPermissions map[uint][]models.Permission
+
+func (p testPermissions) Permissions(ctx context.Context, userID uint) ([]models.Permission, error) {
+	return p[userID], nil
+}
+
+// platformAdminContext is the context of a request from a platform administrator
+func platformAdminContext() context.Context {
+	return util.ContextWithAllOrganizations(context.Background())
+}
+
+func testLogger() *zap.Logger {
+	return zap.NewNop()
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"synergylabs/models"
+	"synergylabs/services/cache"
+	"synergylabs/util"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	recoveryCodeCount = 10
+	// maxMFAAttempts limits second-factor guesses per pending login token
+	maxMFAAttempts = 5
+	// maxMFAUserAttempts limits guesses per account across pending logins,
+	// since every password login yields a new pending token, and across
+	// enrollment confirmations and attempts to disable MFA
+	maxMFAUserAttempts    = 10
+	mfaUserAttemptsWindow = 15 * time.Minute
+)
+
+type MFAService struct {
+	db     *gorm.DB
+	cache  *cache.Cache
+	logger *zap.Logger
+
+	issuer           string
+	requiredForAdmin bool
+}
+
+var _ MFAServiceInterface = (*MFAService)(nil)
+
+// NewMFAService creates the TOTP service. issuer is shown in authenticator
+// apps; requiredForAdmin makes enrollment mandatory for UserTypeAdmin.
+func NewMFAService(db *gorm.DB, cache *cache.Cache, logger *zap.Logger, issuer string, requiredForAdmin bool) *MFAService {
+	return &MFAService{
+		db:               db,
+		cache:            cache,
+		logger:           logger,
+		issuer:           issuer,
+		requiredForAdmin: requiredForAdmin,
+	}
+}
+
+// Required reports whether policy forces the user to use a second factor
+func (s *MFAService) Required(user *models.User) bool {
+	return s.requiredForAdmin && user.UserType == models.UserTypeAdmin
+}
+
+// BeginEnrollment generates a new TOTP secret for the user. The secret only
+// becomes active once ConfirmEnrollment succeeds.
+func (s *MFAService) BeginEnrollment(ctx context.Context, userID uint) (*MFAEnrollment, error) {
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		s.logger.Error("Failed to generate TOTP secret", zap.Error(err))
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).Model(user).Update("totp_secret", secret).Error; err != nil {
+		s.logger.Error("Failed to store TOTP secret", zap.Error(err))
+		return nil, err
+	}
+
+	return &MFAEnrollment{
+		Secret: secret,
+		URI:    util.TOTPURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmEnrollment activates MFA once the user proves their authenticator
+// produces valid codes, and returns a fresh set of recovery codes
+func (s *MFAService) ConfirmEnrollment(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	if err := s.limitUserAttempts(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	if err := s.checkTOTP(ctx, user, code); err != nil {
+		return nil, err
+	}
+	s.resetUserAttempts(ctx, user.ID)
+
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		s.logger.Error("Failed to generate recovery codes", zap.Error(err))
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		records := make([]models.MFARecoveryCode, 0, len(codes))
+		for _, code := range codes {
+			records = append(records, models.MFARecoveryCode{UserID: user.ID, CodeHash: util.HashToken(code)})
+		}
+		if err := tx.Create(&records).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(user).Update("mfa_enabled_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return appendAuditEvent(ctx, tx, userAuditEvent(models.AuditActionMFAEnable, user.ID))
+	})
+	if err != nil {
+		s.logger.Error("Failed to enable MFA", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("MFA enabled", zap.Uint("user_id", user.ID))
+	return codes, nil
+}
+
+// Verify checks the second factor of a login, accepting either a TOTP code or
+// an unused recovery code. attemptKey identifies the pending login so guesses
+// against it can be limited; guesses against the account are limited too, and
+// forgiven once a login succeeds.
+func (s *MFAService) Verify(ctx context.Context, userID uint, attemptKey, code, recoveryCode string) error {
+	attempts, err := s.cache.Increment(ctx, fmt.Sprintf("%s:%s", MFAAttemptsCacheKey, attemptKey), util.MFAPendingTokenTTL)
+	if err != nil {
+		s.logger.Error("Failed to count MFA attempts", zap.Error(err))
+		return err
+	}
+	if attempts > maxMFAAttempts {
+		return ErrMFATooManyAttempts
+	}
+
+	if err := s.limitUserAttempts(ctx, userID); err != nil {
+		return err
+	}
+
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.MFAEnabled() {
+		return ErrMFANotEnrolled
+	}
+
+	if recoveryCode != "" {
+		err = s.useRecoveryCode(ctx, user, recoveryCode)
+	} else {
+		err = s.checkTOTP(ctx, user, code)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.resetUserAttempts(ctx, userID)
+	return nil
+}
+
+// Disable turns MFA off after checking a current code. Users the policy
+// requires MFA for cannot disable it.
+func (s *MFAService) Disable(ctx context.Context, userID uint, code string) error {
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.MFAEnabled() {
+		return ErrMFANotEnrolled
+	}
+	if s.Required(user) {
+		return ErrMFARequired
+	}
+
+	if err := s.limitUserAttempts(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := s.checkTOTP(ctx, user, code); err != nil {
+		return err
+	}
+	s.resetUserAttempts(ctx, user.ID)
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"totp_secret":    "",
+			"mfa_enabled_at": nil,
+		}).Error; err != nil {
+			return err
+		}
+		return appendAuditEvent(ctx, tx, userAuditEvent(models.AuditActionMFADisable, user.ID))
+	})
+	if err != nil {
+		s.logger.Error("Failed to disable MFA", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("MFA disabled", zap.Uint("user_id", user.ID))
+	return nil
+}
+
+// limitUserAttempts counts a second-factor guess against the account and
+// refuses it once the account has made too many in the window
+func (s *MFAService) limitUserAttempts(ctx context.Context, userID uint) error {
+	attempts, err := s.cache.Increment(ctx, mfaUserAttemptsCacheKey(userID), mfaUserAttemptsWindow)
+	if err != nil {
+		s.logger.Error("Failed to count MFA attempts", zap.Error(err))
+		return err
+	}
+	if attempts > maxMFAUserAttempts {
+		if attempts == maxMFAUserAttempts+1 {
+			s.logger.Warn("MFA attempts limited for account", zap.Uint("user_id", userID))
+		}
+		return ErrMFATooManyAttempts
+	}
+	return nil
+}
+
+// resetUserAttempts forgives the account's guesses once a code is accepted
+func (s *MFAService) resetUserAttempts(ctx context.Context, userID uint) {
+	if err := s.cache.Delete(ctx, mfaUserAttemptsCacheKey(userID)); err != nil {
+		s.logger.Warn("Failed to reset MFA attempts", zap.Error(err))
+	}
+}
+
+func mfaUserAttemptsCacheKey(userID uint) string {
+	return fmt.Sprintf("%s:%d", MFAUserAttemptsCacheKey, userID)
+}
+
+// checkTOTP validates a code and rejects reuse of a code within its window
+func (s *MFAService) checkTOTP(ctx context.Context, user *models.User, code string) error {
+	step, ok := util.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	key := fmt.Sprintf("%s:%d:%d", MFAUsedCodeCacheKey, user.ID, step)
+	fresh, err := s.cache.SetNX(ctx, key, true, time.Duration(2*util.TOTPSkew+1)*util.TOTPPeriod)
+	if err != nil {
+		s.logger.Error("Failed to record used TOTP code", zap.Error(err))
+		return err
+	}
+	if !fresh {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *MFAService) useRecoveryCode(ctx context.Context, user *models.User, code string) error {
+	hash := util.HashToken(normalizeRecoveryCode(code))
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var record models.MFARecoveryCode
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hash).
+			First(&record).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidMFACode
+			}
+			return err
+		}
+		return tx.Model(&record).Update("used_at", time.Now()).Error
+	})
+	if err != nil {
+		if !errors.Is(err, ErrInvalidMFACode) {
+			s.logger.Error("Failed to use recovery code", zap.Error(err))
+		}
+		return err
+	}
+
+	s.logger.Info("MFA recovery code used", zap.Uint("user_id", user.ID))
+	return nil
+}
+
+func (s *MFAService) loadUser(ctx context.Context, userID uint) (*models.User, error) {
+	var user models.User
+	if err := s.db.WithContext(ctx).First(&user, userID).Error; err != nil {
+		s.logger.Error("Failed to fetch user", zap.Error(err))
+		return nil, err
+	}
+	return &user, nil
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateRecoveryCodes returns codes formatted as xxxxx-xxxxx
+func generateRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+		codes = append(codes, code[:5]+"-"+code[5:])
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	if len(code) != 10 {
+		return code
+	}
+	return code[:5] + "-" + code[5:]
+}
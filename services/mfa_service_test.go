@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"synergylabs/models"
+	"synergylabs/util"
+	"testing"
+	"time"
+)
+
+func newTestMFAService(t *testing.T) (*MFAService, *models.User) {
+	t.Helper()
+	db := testDB(t)
+	s := NewMFAService(db, testCache(t), testLogger(), "Synergy Labs", false)
+
+	user := &models.User{Name: "Admin", Email: testEmail("mfa"), UserType: models.UserTypeAdmin}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	return s, user
+}
+
+// totpCodeAt returns the code for the time step offset steps away from now
+func totpCodeAt(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+	code, err := util.TOTPCode(secret, util.TOTPStep(time.Now())+offset)
+	if err != nil {
+		t.Fatalf("TOTPCode: %v", err)
+	}
+	return code
+}
+
+func TestMFAEnrollment(t *testing.T) {
+	s, user := newTestMFAService(t)
+	ctx := context.Background()
+
+	if _, err := s.ConfirmEnrollment(ctx, user.ID, "123456"); !errors.Is(err, ErrMFANotEnrolled) {
+		t.Fatalf("confirming before enrolling: error = %v, want %v", err, ErrMFANotEnrolled)
+	}
+
+	enrollment, err := s.BeginEnrollment(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("BeginEnrollment: %v", err)
+	}
+	if !strings.Contains(enrollment.URI, "secret="+enrollment.Secret) {
+		t.Errorf("URI %q does not carry the secret", enrollment.URI)
+	}
+
+	if _, err := s.ConfirmEnrollment(ctx, user.ID, totpCodeAt(t, enrollment.Secret, 3)); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("confirming with a code outside the window: error = %v, want %v", err, ErrInvalidMFACode)
+	}
+	codes, err := s.ConfirmEnrollment(ctx, user.ID, totpCodeAt(t, enrollment.Secret, 0))
+	if err != nil {
+		t.Fatalf("ConfirmEnrollment: %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Errorf("%d recovery codes, want %d", len(codes), recoveryCodeCount)
+	}
+
+	if _, err := s.BeginEnrollment(ctx, user.ID); !errors.Is(err, ErrMFAAlreadyEnabled) {
+		t.Errorf("enrolling again: error = %v, want %v", err, ErrMFAAlreadyEnabled)
+	}
+}
+
+func TestMFAVerify(t *testing.T) {
+	s, user := newTestMFAService(t)
+	ctx := context.Background()
+
+	enrollment, err := s.BeginEnrollment(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("BeginEnrollment: %v", err)
+	}
+	confirmCode := totpCodeAt(t, enrollment.Secret, 0)
+	recoveryCodes, err := s.ConfirmEnrollment(ctx, user.ID, confirmCode)
+	if err != nil {
+		t.Fatalf("ConfirmEnrollment: %v", err)
+	}
+	nextCode := totpCodeAt(t, enrollment.Secret, 1)
+	recoveryCode := recoveryCodes[0]
+
+	tests := []struct {
+		name         string
+		code         string
+		recoveryCode string
+		want         error
+	}{
+		{"code used to confirm enrollment", confirmCode, "", ErrInvalidMFACode},
+		{"wrong code", "000000", "", ErrInvalidMFACode},
+		{"code from the next step", nextCode, "", nil},
+		{"replayed code", nextCode, "", ErrInvalidMFACode},
+		{"recovery code typed without the dash", "", strings.ToUpper(strings.ReplaceAll(recoveryCode, "-", "")), nil},
+		{"used recovery code", "", recoveryCode, ErrInvalidMFACode},
+		{"unknown recovery code", "", "aaaaa-aaaaa", ErrInvalidMFACode},
+		{"another recovery code", "", recoveryCodes[1], nil},
+	}
+	for _, tt := range tests {
+		err := s.Verify(ctx, user.ID, testEmail("pending"), tt.code, tt.recoveryCode)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestMFAVerifyLimitsAttemptsPerPendingLogin(t *testing.T) {
+	s, user := newTestMFAService(t)
+	ctx := context.Background()
+
+	enrollment, err := s.BeginEnrollment(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("BeginEnrollment: %v", err)
+	}
+	if _, err := s.ConfirmEnrollment(ctx, user.ID, totpCodeAt(t, enrollment.Secret, 0)); err != nil {
+		t.Fatalf("ConfirmEnrollment: %v", err)
+	}
+
+	attemptKey := testEmail("pending")
+	for i := 0; i < maxMFAAttempts; i++ {
+		if err := s.Verify(ctx, user.ID, attemptKey, "000000", ""); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("attempt %d: error = %v, want %v", i+1, err, ErrInvalidMFACode)
+		}
+	}
+	if err := s.Verify(ctx, user.ID, attemptKey, totpCodeAt(t, enrollment.Secret, 1), ""); !errors.Is(err, ErrMFATooManyAttempts) {
+		t.Errorf("valid code after the limit: error = %v, want %v", err, ErrMFATooManyAttempts)
+	}
+}
+
+func TestMFAEnrollmentChecksLimitAttemptsPerAccount(t *testing.T) {
+	ctx := context.Background()
+
+	s, user := newTestMFAService(t)
+	enrollment, err := s.BeginEnrollment(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("BeginEnrollment: %v", err)
+	}
+	for i := 0; i < maxMFAUserAttempts; i++ {
+		if _, err := s.ConfirmEnrollment(ctx, user.ID, "000000"); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("confirming, attempt %d: error = %v, want %v", i+1, err, ErrInvalidMFACode)
+		}
+	}
+	if _, err := s.ConfirmEnrollment(ctx, user.ID, totpCodeAt(t, enrollment.Secret, 0)); !errors.Is(err, ErrMFATooManyAttempts) {
+		t.Errorf("confirming with a valid code after the limit: error = %v, want %v", err, ErrMFATooManyAttempts)
+	}
+
+	s, user = newTestMFAService(t)
+	enrollment, err = s.BeginEnrollment(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("BeginEnrollment: %v", err)
+	}
+	if _, err := s.ConfirmEnrollment(ctx, user.ID, totpCodeAt(t, enrollment.Secret, 0)); err != nil {
+		t.Fatalf("ConfirmEnrollment: %v", err)
+	}
+	for i := 0; i < maxMFAUserAttempts; i++ {
+		if err := s.Disable(ctx, user.ID, "000000"); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("disabling, attempt %d: error = %v, want %v", i+1, err, ErrInvalidMFACode)
+		}
+	}
+	if err := s.Disable(ctx, user.ID, totpCodeAt(t, enrollment.Secret, 1)); !errors.Is(err, ErrMFATooManyAttempts) {
+		t.Errorf("disabling with a valid code after the limit: error = %v, want %v", err, ErrMFATooManyAttempts)
+	}
+}
@@ -0,0 +1,142 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"synergylabs/services/oidc"
+	"synergylabs/services/oidc/oidctest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func newProvider(mock *oidctest.Provider) *oidc.Provider {
+	return oidc.NewProvider(oidc.Config{
+		IssuerURL:    mock.Issuer(),
+		ClientID:     oidctest.ClientID,
+		ClientSecret: oidctest.ClientSecret,
+		RedirectURL:  "http://localhost:3000/sso/oidc/callback",
+	}, mock.Client())
+}
+
+func TestCodeFlow(t *testing.T) {
+	mock := oidctest.NewProvider(t)
+	provider := newProvider(mock)
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	u, _ := url.Parse(authURL)
+	if got := u.Query().Get("code_challenge"); got != oidc.CodeChallenge("verifier-1") {
+		t.Errorf("code_challenge = %q, want the S256 challenge of the verifier", got)
+	}
+
+	code, state := mock.Authorize(t, authURL, map[string]interface{}{
+		"sub":            "user-1",
+		"email":          "sso@example.com",
+		"email_verified": true,
+	})
+	if state != "state-1" {
+		t.Errorf("state = %q, want state-1", state)
+	}
+
+	tokens, err := provider.Exchange(ctx, code, "verifier-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	idToken, err := provider.VerifyIDToken(ctx, tokens.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if idToken.Subject != "user-1" || idToken.Email != "sso@example.com" || !idToken.EmailVerified {
+		t.Errorf("unexpected claims %+v", idToken)
+	}
+
+	// Codes are single-use
+	if _, err := provider.Exchange(ctx, code, "verifier-1"); !errors.Is(err, oidc.ErrTokenExchange) {
+		t.Errorf("second exchange of the same code: got %v, want ErrTokenExchange", err)
+	}
+}
+
+func TestExchangeRequiresCodeVerifier(t *testing.T) {
+	mock := oidctest.NewProvider(t)
+	provider := newProvider(mock)
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", "verifier")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code, _ := mock.Authorize(t, authURL, map[string]interface{}{"sub": "user-1"})
+
+	if _, err := provider.Exchange(ctx, code, "another-verifier"); !errors.Is(err, oidc.ErrTokenExchange) {
+		t.Errorf("got %v, want ErrTokenExchange", err)
+	}
+}
+
+func TestDiscoveryChecksIssuer(t *testing.T) {
+	mock := oidctest.NewProvider(t)
+
+	// The issuer is compared exactly, so a trailing slash the provider does
+	// not use is a different issuer
+	for _, issuer := range []string{mock.Issuer() + "/tenant", mock.Issuer() + "/"} {
+		provider := oidc.NewProvider(oidc.Config{IssuerURL: issuer, ClientID: oidctest.ClientID}, mock.Client())
+		if _, err := provider.Metadata(context.Background()); !errors.Is(err, oidc.ErrDiscovery) {
+			t.Errorf("issuer %q: got %v, want ErrDiscovery", issuer, err)
+		}
+	}
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	mock := oidctest.NewProvider(t)
+	provider := newProvider(mock)
+	hour := time.Hour
+
+	hmacToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss":   mock.Issuer(),
+		"aud":   oidctest.ClientID,
+		"sub":   "user-1",
+		"nonce": "nonce",
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(hour).Unix(),
+	}).SignedString([]byte(oidctest.ClientSecret))
+	if err != nil {
+		t.Fatalf("signing HMAC token: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		nonce string
+		valid bool
+	}{
+		{"valid", mock.IDToken(t, map[string]interface{}{"sub": "user-1", "nonce": "nonce"}), "nonce", true},
+		{"several audiences with azp", mock.IDToken(t, map[string]interface{}{"sub": "user-1", "nonce": "nonce", "aud": []string{oidctest.ClientID, "other"}, "azp": oidctest.ClientID}), "nonce", true},
+		{"several audiences without azp", mock.IDToken(t, map[string]interface{}{"sub": "user-1", "nonce": "nonce", "aud": []string{oidctest.ClientID, "other"}}), "nonce", false},
+		{"wrong nonce", mock.IDToken(t, map[string]interface{}{"sub": "user-1", "nonce": "other"}), "nonce", false},
+		{"no nonce", mock.IDToken(t, map[string]interface{}{"sub": "user-1"}), "", false},
+		{"other audience", mock.IDToken(t, map[string]interface{}{"sub": "user-1", "nonce": "nonce", "aud": "other"}), "nonce", false},
+		{"other issuer", mock.IDToken(t, map[string]interface{}{"sub": "user-1", "nonce": "nonce", "iss": "https://evil.example.com"}), "nonce", false},
+		{"issuer with a trailing slash", mock.IDToken(t, map[string]interface{}{"sub": "user-1", "nonce": "nonce", "iss": mock.Issuer() + "/"}), "nonce", false},
+		{"expired", mock.IDToken(t, map[string]interface{}{"sub": "user-1", "nonce": "nonce", "exp": time.Now().Add(-hour).Unix()}), "nonce", false},
+		{"issued in the future", mock.IDToken(t, map[string]interface{}{"sub": "user-1", "nonce": "nonce", "iat": time.Now().Add(hour).Unix()}), "nonce", false},
+		{"no subject", mock.IDToken(t, map[string]interface{}{"nonce": "nonce"}), "nonce", false},
+		{"signed with the client secret", hmacToken, "nonce", false},
+		{"garbage", "not.a.token", "nonce", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.VerifyIDToken(context.Background(), tt.token, tt.nonce)
+			if tt.valid && err != nil {
+				t.Errorf("got %v, want the token to be accepted", err)
+			}
+			if !tt.valid && !errors.Is(err, oidc.ErrInvalidToken) {
+				t.Errorf("got %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
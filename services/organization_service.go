@@ -0,0 +1,373 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"synergylabs/models"
+	"synergylabs/services/cache"
+	"synergylabs/util"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OrganizationService struct {
+	db          *gorm.DB
+	cache       *cache.Cache
+	permissions util.PermissionResolver
+	logger      *zap.Logger
+}
+
+var _ OrganizationServiceInterface = (*OrganizationService)(nil)
+
+// NewOrganizationService creates the organization service. permissions
+// resolves what administrators hold, since the single sign-on default role
+// cannot grant more.
+func NewOrganizationService(db *gorm.DB, cache *cache.Cache, permissions util.PermissionResolver, logger *zap.Logger) *OrganizationService {
+	return &OrganizationService{
+		db:          db,
+		cache:       cache,
+		permissions: permissions,
+		logger:      logger,
+	}
+}
+
+// CreateOrganization creates an organization. Only platform administrators can.
+func (s *OrganizationService) CreateOrganization(ctx context.Context, org *models.Organization) error {
+	if !util.SeesAllOrganizations(ctx) {
+		return ErrPlatformAdminRequired
+	}
+	if err := s.validateSettings(ctx, 0, nil, &org.Settings); err != nil {
+		return err
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditActionOrganizationCreate, "organization", org.ID, nil, org)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrOrganizationExists
+		}
+		s.logger.Error("Failed to create organization", zap.Error(err))
+		return err
+	}
+	s.logger.Info("Organization created successfully", zap.Uint("organization_id", org.ID))
+	return nil
+}
+
+// ListOrganizations lists the organizations the caller can see: all of them
+// for platform administrators, otherwise only the caller's own
+func (s *OrganizationService) ListOrganizations(ctx context.Context, page, pageSize int) (*PaginatedResponse, error) {
+	var total int64
+	if err := s.db.WithContext(ctx).Model(&models.Organization{}).Scopes(organizationTenantScope(ctx)).Count(&total).Error; err != nil {
+		s.logger.Error("Failed to count organizations", zap.Error(err))
+		return nil, err
+	}
+
+	var orgs []models.Organization
+	if err := s.db.WithContext(ctx).
+		Scopes(organizationTenantScope(ctx)).
+		Order("name").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&orgs).Error; err != nil {
+		s.logger.Error("Failed to fetch organizations", zap.Error(err))
+		return nil, err
+	}
+
+	response := newPaginatedResponse(orgs, total, page, pageSize)
+	return &response, nil
+}
+
+// GetOrganization returns an organization. Callers other than platform
+// administrators can only see their own.
+func (s *OrganizationService) GetOrganization(ctx context.Context, id uint) (*models.Organization, error) {
+	if !inTenant(ctx, &id) {
+		return nil, ErrOrganizationNotFound
+	}
+
+	var org models.Organization
+	if err := s.db.WithContext(ctx).First(&org, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		s.logger.Error("Failed to fetch organization", zap.Error(err))
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (s *OrganizationService) UpdateSettings(ctx context.Context, id uint, settings models.OrganizationSettings) (*models.Organization, error) {
+	org, err := s.GetOrganization(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateSettings(ctx, org.ID, &org.Settings, &settings); err != nil {
+		return nil, err
+	}
+
+	before := *org
+	org.Settings = settings
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(org).Update("settings", org.Settings).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditActionOrganizationSettings, "organization", org.ID, &before, org)
+	})
+	if err != nil {
+		s.logger.Error("Failed to update organization settings", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Organization settings updated", zap.Uint("organization_id", id))
+	return org, nil
+}
+
+func (s *OrganizationService) ListMembers(ctx context.Context, id uint) ([]models.User, error) {
+	if _, err := s.GetOrganization(ctx, id); err != nil {
+		return nil, err
+	}
+
+	var members []models.User
+	if err := s.db.WithContext(ctx).
+		Where("organization_id = ?", id).
+		Preload("Roles").
+		Order("name").
+		Find(&members).Error; err != nil {
+		s.logger.Error("Failed to fetch organization members", zap.Error(err))
+		return nil, err
+	}
+	return members, nil
+}
+
+// AddMember moves a staff account without an organization into the
+// organization. Platform administrators can only be moved by one of their own,
+// since joining an organization limits them to it.
+func (s *OrganizationService) AddMember(ctx context.Context, id, userID uint) error {
+	org, err := s.GetOrganization(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return err
+		}
+		if user.UserType != models.UserTypeAdmin {
+			return ErrNotStaff
+		}
+		if user.OrganizationID != nil {
+			if *user.OrganizationID == org.ID {
+				return nil
+			}
+			return ErrUserInOtherOrganization
+		}
+		if !org.Settings.AllowsEmail(user.Email) {
+			return ErrEmailDomainNotAllowed
+		}
+		if !util.SeesAllOrganizations(ctx) {
+			var superAdmin int64
+			if err := tx.Table("user_roles").
+				Joins("JOIN roles ON roles.id = user_roles.role_id").
+				Where("user_roles.user_id = ? AND roles.name = ?", userID, models.RoleSuperAdmin).
+				Count(&superAdmin).Error; err != nil {
+				return err
+			}
+			if superAdmin > 0 {
+				return ErrPlatformAdminRequired
+			}
+		}
+		if err := tx.Model(&user).Update("organization_id", org.ID).Error; err != nil {
+			return err
+		}
+		// Roles are granted separately; the inert role of a removal has done its job
+		if err := tx.Exec(`
+			DELETE FROM user_roles WHERE user_id = ?
+			AND role_id IN (SELECT id FROM roles WHERE name = ?)`,
+			userID, models.RoleFormerMember,
+		).Error; err != nil {
+			return err
+		}
+		return appendAuditEvent(ctx, tx, memberAuditEvent(models.AuditActionMemberAdd, org.ID, userID))
+	})
+	if err != nil {
+		s.logger.Warn("Failed to add organization member", zap.Uint("organization_id", id), zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Organization member added", zap.Uint("organization_id", id), zap.Uint("user_id", userID))
+	return nil
+}
+
+// RemoveMember detaches a member from the organization and replaces their
+// roles with former_member and revokes their API keys, so they keep no staff
+// access outside it. The caller revokes their sessions.
+func (s *OrganizationService) RemoveMember(ctx context.Context, id, userID uint) error {
+	if _, err := s.GetOrganization(ctx, id); err != nil {
+		return err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND organization_id = ?", userID, id).
+			Update("organization_id", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := checkNotLastSuperAdmin(tx, userID); err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM user_roles WHERE user_id = ?", userID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`
+			INSERT INTO user_roles (user_id, role_id)
+			SELECT ?, id FROM roles WHERE name = ?`,
+			userID, models.RoleFormerMember,
+		).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.APIKey{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return appendAuditEvent(ctx, tx, memberAuditEvent(models.AuditActionMemberRemove, id, userID))
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, ErrLastSuperAdmin) {
+		return err
+	}
+	if err != nil {
+		s.logger.Error("Failed to remove organization member", zap.Error(err))
+		return err
+	}
+
+	s.cache.Delete(ctx, permissionsCacheKey(userID))
+	s.logger.Info("Organization member removed", zap.Uint("organization_id", id), zap.Uint("user_id", userID))
+	return nil
+}
+
+// validateSettings checks the single sign-on settings of organization orgID,
+// whose settings are currently current (nil when it is being created), and
+// normalizes the provisioning domains. Every account the identity provider
+// provisions gets the default role, so it is never super_admin and the caller
+// must hold all of its permissions.
+func (s *OrganizationService) validateSettings(ctx context.Context, orgID uint, current, settings *models.OrganizationSettings) error {
+	domains, err := normalizeSSODomains(settings.SSOProvisioningDomains)
+	if err != nil {
+		return err
+	}
+	settings.SSOProvisioningDomains = domains
+	if err := s.checkSSODomains(ctx, orgID, current, domains); err != nil {
+		return err
+	}
+
+	if settings.SSODefaultRole == "" {
+		return nil
+	}
+	if models.UserTypeForRole(settings.SSODefaultRole) != models.UserTypeAdmin {
+		return ErrRoleUserTypeMismatch
+	}
+	if settings.SSODefaultRole == models.RoleSuperAdmin {
+		return ErrSuperAdminSSORole
+	}
+	var role models.Role
+	if err := s.db.WithContext(ctx).Where("name = ?", settings.SSODefaultRole).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRoleNotFound
+		}
+		s.logger.Error("Failed to look up role", zap.Error(err))
+		return err
+	}
+	return checkGrantable(ctx, s.permissions, util.RequestInfoFromContext(ctx).ActorID, &role)
+}
+
+// checkSSODomains guards the provisioning domains, which hand out accounts in
+// the organization to anyone the identity provider vouches for. Only platform
+// administrators can add a domain, having checked the organization owns it,
+// and no two organizations can list the same domain.
+func (s *OrganizationService) checkSSODomains(ctx context.Context, orgID uint, current *models.OrganizationSettings, domains []string) error {
+	if len(domains) == 0 {
+		return nil
+	}
+	for _, domain := range domains {
+		if (current == nil || !current.ProvisionsSSODomain(domain)) && !util.SeesAllOrganizations(ctx) {
+			return ErrPlatformAdminRequired
+		}
+	}
+
+	var others []models.Organization
+	if err := s.db.WithContext(ctx).Where("id <> ?", orgID).Find(&others).Error; err != nil {
+		s.logger.Error("Failed to fetch organizations", zap.Error(err))
+		return err
+	}
+	for _, other := range others {
+		for _, domain := range domains {
+			if other.Settings.ProvisionsSSODomain(domain) {
+				return fmt.Errorf("%w: %s", ErrSSODomainTaken, domain)
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeSSODomains lowercases and deduplicates provisioning domains,
+// rejecting anything that is not a plain domain name
+func normalizeSSODomains(domains []string) ([]string, error) {
+	var normalized []string
+	seen := make(map[string]bool, len(domains))
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+		if !validDomain(domain) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSSODomain, domain)
+		}
+		if !seen[domain] {
+			seen[domain] = true
+			normalized = append(normalized, domain)
+		}
+	}
+	return normalized, nil
+}
+
+// validDomain reports whether domain is a lowercase name with at least two labels
+func validDomain(domain string) bool {
+	labels := strings.Split(domain, ".")
+	if len(domain) > 253 || len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// memberAuditEvent describes a membership change, visible to the organization's own audit log
+func memberAuditEvent(action string, orgID, userID uint) *models.AuditEvent {
+	return &models.AuditEvent{
+		OrganizationID: &orgID,
+		Action:         action,
+		EntityType:     "organization",
+		EntityID:       strconv.FormatUint(uint64(orgID), 10),
+		Metadata:       map[string]interface{}{"user_id": userID},
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"synergylabs/db"
+	"synergylabs/models"
+	"synergylabs/util"
+	"testing"
+)
+
+func TestNormalizeSSODomains(t *testing.T) {
+	tests := []struct {
+		domains []string
+		want    []string
+		valid   bool
+	}{
+		{nil, nil, true},
+		{[]string{"Example.COM", " @example.com ", "jobs.example.com"}, []string{"example.com", "jobs.example.com"}, true},
+		{[]string{"xn--bcher-kva.example"}, []string{"xn--bcher-kva.example"}, true},
+		{[]string{"localhost"}, nil, false},
+		{[]string{""}, nil, false},
+		{[]string{"example..com"}, nil, false},
+		{[]string{"-example.com"}, nil, false},
+		{[]string{"user@example.com"}, nil, false},
+		{[]string{"*.example.com"}, nil, false},
+		{[]string{"example.com/path"}, nil, false},
+	}
+	for _, tt := range tests {
+		got, err := normalizeSSODomains(tt.domains)
+		if !tt.valid {
+			if !errors.Is(err, ErrInvalidSSODomain) {
+				t.Errorf("normalizeSSODomains(%q) error = %v, want ErrInvalidSSODomain", tt.domains, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("normalizeSSODomains(%q) = %q, %v, want %q", tt.domains, got, err, tt.want)
+		}
+	}
+}
+
+func TestSSODomainsNeedPlatformAdminAndAreUnique(t *testing.T) {
+	db := testDB(t)
+	s := NewOrganizationService(db, nil, testPermissions{}, testLogger())
+	domain := testSSODomain()
+	org := createSSOOrganization(t, db, domain)
+	orgAdmin := util.ContextWithOrganization(context.Background(), org.ID)
+
+	// Organization administrators can keep or drop domains but not add them
+	added := models.OrganizationSettings{SSOProvisioningDomains: []string{domain, testSSODomain()}}
+	if _, err := s.UpdateSettings(orgAdmin, org.ID, added); !errors.Is(err, ErrPlatformAdminRequired) {
+		t.Errorf("organization admin adding a domain: got %v, want ErrPlatformAdminRequired", err)
+	}
+	kept := models.OrganizationSettings{SSOProvisioningDomains: []string{domain}, CareersPageURL: "https://" + domain}
+	if _, err := s.UpdateSettings(orgAdmin, org.ID, kept); err != nil {
+		t.Errorf("organization admin keeping its domain: %v", err)
+	}
+	if _, err := s.UpdateSettings(orgAdmin, org.ID, models.OrganizationSettings{}); err != nil {
+		t.Errorf("organization admin dropping its domain: %v", err)
+	}
+	if _, err := s.UpdateSettings(platformAdminContext(), org.ID, kept); err != nil {
+		t.Fatalf("platform admin restoring the domain: %v", err)
+	}
+
+	// No other organization can claim it, however it is written
+	other := &models.Organization{
+		Name:     "other-" + domain,
+		Slug:     "other-" + domain,
+		Settings: models.OrganizationSettings{SSOProvisioningDomains: []string{"@" + domain}},
+	}
+	if err := s.CreateOrganization(platformAdminContext(), other); !errors.Is(err, ErrSSODomainTaken) {
+		t.Errorf("second organization claiming the domain: got %v, want ErrSSODomainTaken", err)
+	}
+}
+
+func TestSSODefaultRoleIsGrantable(t *testing.T) {
+	conn := testDB(t)
+	var recruiter models.Role
+	if err := conn.Where("name = ?", models.RoleRecruiter).First(&recruiter).Error; err != nil {
+		t.Fatalf("loading recruiter role: %v", err)
+	}
+	const recruiterAdmin, viewer = 1, 2
+	s := NewOrganizationService(conn, nil, testPermissions{recruiterAdmin: recruiter.Permissions}, testLogger())
+	org := createSSOOrganization(t, conn, testSSODomain())
+
+	tests := []struct {
+		name  string
+		actor uint
+		role  string
+		want  error
+	}{
+		{"role the actor holds", recruiterAdmin, models.RoleRecruiter, nil},
+		{"role granting more than the actor holds", viewer, models.RoleRecruiter, ErrRoleNotGrantable},
+		{"super_admin", recruiterAdmin, models.RoleSuperAdmin, ErrSuperAdminSSORole},
+		{"applicant role", recruiterAdmin, models.RoleApplicant, ErrRoleUserTypeMismatch},
+		{"unknown role", recruiterAdmin, "wizard", ErrRoleNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := util.ContextWithRequestInfo(platformAdminContext(), util.RequestInfo{ActorID: tt.actor})
+			settings := models.OrganizationSettings{SSOProvisioningDomains: org.Settings.SSOProvisioningDomains, SSODefaultRole: tt.role}
+			if _, err := s.UpdateSettings(ctx, org.ID, settings); !errors.Is(err, tt.want) {
+				t.Errorf("UpdateSettings error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemovedMemberStaysWithoutAccess(t *testing.T) {
+	conn := testDB(t)
+	redis := testCache(t)
+	s := NewOrganizationService(conn, redis, testPermissions{}, testLogger())
+	rbac := NewRBACService(conn, redis, testLogger())
+	ctx := platformAdminContext()
+
+	slug := testSSODomain()
+	org := models.Organization{Name: slug, Slug: slug}
+	if err := conn.Create(&org).Error; err != nil {
+		t.Fatalf("creating organization: %v", err)
+	}
+	member := models.User{Name: "Member", Email: testEmail("member"), UserType: models.UserTypeAdmin}
+	if err := conn.Create(&member).Error; err != nil {
+		t.Fatalf("creating member: %v", err)
+	}
+	if err := s.AddMember(ctx, org.ID, member.ID); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	var recruiter models.Role
+	if err := conn.Where("name = ?", models.RoleRecruiter).First(&recruiter).Error; err != nil {
+		t.Fatalf("loading recruiter role: %v", err)
+	}
+	if err := rbac.AssignRole(ctx, member.ID, recruiter.ID); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+
+	if err := s.RemoveMember(ctx, org.ID, member.ID); err != nil {
+		t.Fatalf("RemoveMember: %v", err)
+	}
+	// Restarting used to give a staff account without roles super_admin
+	if err := db.Migrate(conn); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	roles, err := rbac.GetUserRoles(ctx, member.ID)
+	if err != nil {
+		t.Fatalf("GetUserRoles: %v", err)
+	}
+	if len(roles) != 1 || roles[0].Name != models.RoleFormerMember {
+		t.Errorf("roles = %v, want only %s", roles, models.RoleFormerMember)
+	}
+	permissions, err := rbac.Permissions(context.Background(), member.ID)
+	if err != nil {
+		t.Fatalf("Permissions: %v", err)
+	}
+	if len(permissions) != 0 {
+		t.Errorf("permissions = %v, want none", permissions)
+	}
+	// Outside an organization only platform administrators see any tenant
+	if platformAdmin, err := rbac.IsPlatformAdmin(context.Background(), member.ID); err != nil || platformAdmin {
+		t.Errorf("IsPlatformAdmin = %v, %v, want false", platformAdmin, err)
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"synergylabs/util"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pageCursor is the position a cursor page continues from: the sort key and
+// ID of the row at the edge of the previous page
+type pageCursor struct {
+	// Listing identifies the listing, sort and filters the cursor was issued
+	// for, so it cannot be replayed against another one
+	Listing string          `json:"l"`
+	Key     json.RawMessage `json:"k,omitempty"`
+	ID      uint            `json:"id"`
+	// Before reads the page preceding the row instead of the one following it
+	Before bool `json:"b,omitempty"`
+}
+
+// keyset orders a listing by one column and then by ID, which is what lets a
+// cursor resume from a row without an offset
+type keyset[T any] struct {
+	// column is the sort key; empty when the listing is ordered by ID alone
+	column   string
+	idColumn string
+	desc     bool
+	// keyOf and parseKey read the sort key of a row and decode it from a cursor
+	keyOf    func(*T) interface{}
+	parseKey func(json.RawMessage) (interface{}, error)
+	idOf     func(*T) uint
+}
+
+// order sorts in the keyset's direction, or against it when reverse is set
+func (k *keyset[T]) order(reverse bool) clause.OrderBy {
+	direction := "ASC"
+	if k.desc != reverse {
+		direction = "DESC"
+	}
+	sql := k.idColumn + " " + direction
+	if k.column != "" {
+		sql = k.column + " " + direction + ", " + sql
+	}
+	return clause.OrderBy{Expression: clause.Expr{SQL: sql}}
+}
+
+// after matches the rows that come after the cursor's row in the direction it reads
+func (k *keyset[T]) after(cursor *pageCursor) (clause.Expr, error) {
+	op := ">"
+	if k.desc != cursor.Before {
+		op = "<"
+	}
+	if k.column == "" {
+		return clause.Expr{SQL: k.idColumn + " " + op + " ?", Vars: []interface{}{cursor.ID}}, nil
+	}
+	key, err := k.parseKey(cursor.Key)
+	if err != nil {
+		return clause.Expr{}, ErrInvalidCursor
+	}
+	return clause.Expr{
+		SQL:  fmt.Sprintf("(%s, %s) %s (?, ?)", k.column, k.idColumn, op),
+		Vars: []interface{}{key, cursor.ID},
+	}, nil
+}
+
+func (k *keyset[T]) cursor(listing string, row *T, before bool) (string, error) {
+	cursor := pageCursor{Listing: listing, ID: k.idOf(row), Before: before}
+	if k.column != "" {
+		key, err := json.Marshal(k.keyOf(row))
+		if err != nil {
+			return "", err
+		}
+		cursor.Key = key
+	}
+	return util.SignCursor(cursor)
+}
+
+// parseKeyAs decodes a cursor's sort key as a V
+func parseKeyAs[V any](raw json.RawMessage) (interface{}, error) {
+	var key V
+	if err := json.Unmarshal(raw, &key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// listingID names a listing and its filters for pageCursor.Listing
+func listingID(name string, filters interface{}) (string, error) {
+	encoded, err := json.Marshal(filters)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return name + ":" + hex.EncodeToString(sum[:8]), nil
+}
+
+// paginate reads one page of query. Listings ordered by keys hand out cursors
+// and accept them; keys is nil when the sort cannot be resumed from a row, and
+// the listing is then ordered by order and only paged by number. rowScopes,
+// such as preloads, apply to the page but not to the count.
+func paginate[T any](query *gorm.DB, req PageRequest, order clause.OrderBy, keys *keyset[T], listing string, rowScopes ...func(*gorm.DB) *gorm.DB) ([]T, PaginatedResponse, error) {
+	response := PaginatedResponse{PageSize: req.PageSize}
+
+	var cursor *pageCursor
+	if req.Cursor != "" {
+		if keys == nil {
+			return nil, response, fmt.Errorf("%w: this sort order cannot be paged with a cursor", ErrInvalidCursor)
+		}
+		cursor = &pageCursor{}
+		if err := util.OpenCursor(req.Cursor, cursor); err != nil {
+			if errors.Is(err, util.ErrInvalidCursor) {
+				return nil, response, ErrInvalidCursor
+			}
+			return nil, response, err
+		}
+		if cursor.Listing != listing {
+			return nil, response, fmt.Errorf("%w: the cursor belongs to a different listing or filter", ErrInvalidCursor)
+		}
+	}
+
+	// Pages by number always carry the total; only cursor reads may skip it
+	query = query.Session(&gorm.Session{})
+	if cursor == nil || !req.SkipCount {
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			return nil, response, err
+		}
+		counted := newPaginatedResponse(nil, total, req.Page, req.PageSize)
+		response.Total, response.TotalPages = counted.Total, counted.TotalPages
+	}
+
+	// One extra row tells whether there is another page in the reading direction
+	page := query.Scopes(rowScopes...).Limit(req.PageSize + 1)
+	switch {
+	case cursor != nil:
+		condition, err := keys.after(cursor)
+		if err != nil {
+			return nil, response, err
+		}
+		page = page.Where(condition).Order(keys.order(cursor.Before))
+	case keys != nil:
+		page = page.Order(keys.order(false)).Offset((req.Page - 1) * req.PageSize)
+	default:
+		page = page.Order(order).Offset((req.Page - 1) * req.PageSize)
+	}
+
+	var rows []T
+	if err := page.Find(&rows).Error; err != nil {
+		return nil, response, err
+	}
+	more := len(rows) > req.PageSize
+	if more {
+		rows = rows[:req.PageSize]
+	}
+
+	switch {
+	case cursor == nil:
+		response.Page = req.Page
+		response.HasPrev = req.Page > 1
+		response.HasNext = more
+	case cursor.Before:
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+		response.HasPrev = more
+		response.HasNext = true
+	default:
+		response.HasPrev = true
+		response.HasNext = more
+	}
+
+	if keys != nil && len(rows) > 0 {
+		var err error
+		if response.HasNext {
+			if response.NextCursor, err = keys.cursor(listing, &rows[len(rows)-1], false); err != nil {
+				return nil, response, err
+			}
+		}
+		if response.HasPrev {
+			if response.PrevCursor, err = keys.cursor(listing, &rows[0], true); err != nil {
+				return nil, response, err
+			}
+		}
+	}
+	return rows, response, nil
+}
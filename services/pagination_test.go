@@ -0,0 +1,86 @@
+package services
+
+import (
+	"errors"
+	"synergylabs/models"
+	"synergylabs/util"
+	"testing"
+
+	"gorm.io/gorm/clause"
+)
+
+func TestCursorRejectedOnAnotherListing(t *testing.T) {
+	util.SetCursorKey([]byte("pagination test key"))
+	defer util.SetCursorKey(nil)
+
+	listing := func(filters JobFilters) string {
+		t.Helper()
+		id, err := listingID(string(JobsCacheKey), filters)
+		if err != nil {
+			t.Fatalf("listingID: %v", err)
+		}
+		return id
+	}
+	issued := JobFilters{Department: "Sales", Sort: "title"}
+	keys := jobKeyset(issued)
+	token, err := keys.cursor(listing(issued), &models.Job{Title: "Account Executive"}, false)
+	if err != nil {
+		t.Fatalf("cursor: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		filters JobFilters
+	}{
+		{"other filter", JobFilters{Department: "Marketing", Sort: "title"}},
+		{"filter removed", JobFilters{Sort: "title"}},
+		{"other sort direction", JobFilters{Department: "Sales", Sort: "-title"}},
+		{"other sort field", JobFilters{Department: "Sales", Sort: "posted_on"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The cursor is refused before the query is touched
+			_, _, err := paginate(nil, PageRequest{PageSize: 10, Cursor: token}, clause.OrderBy{}, jobKeyset(tt.filters), listing(tt.filters))
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("paginate = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+
+	if listing(issued) != listing(JobFilters{Department: "Sales", Sort: "title"}) {
+		t.Error("the same filters and sort named two listings")
+	}
+}
+
+func TestNewPaginatedResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		total      int64
+		page       int
+		pageSize   int
+		totalPages int
+		hasNext    bool
+		hasPrev    bool
+	}{
+		{"no items", 0, 1, 10, 0, false, false},
+		{"one item", 1, 1, 10, 1, false, false},
+		{"one partial page", 9, 1, 10, 1, false, false},
+		{"exactly one page", 10, 1, 10, 1, false, false},
+		{"one past a page", 11, 1, 10, 2, true, false},
+		{"exact multiple, first page", 20, 1, 10, 2, true, false},
+		{"exact multiple, last page", 20, 2, 10, 2, false, true},
+		{"page past the end", 20, 3, 10, 2, false, true},
+		{"no page size", 20, 1, 0, 0, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := newPaginatedResponse(nil, tt.total, tt.page, tt.pageSize)
+			if *response.Total != tt.total || *response.TotalPages != tt.totalPages {
+				t.Errorf("total = %d, total pages = %d, want %d, %d", *response.Total, *response.TotalPages, tt.total, tt.totalPages)
+			}
+			if response.HasNext != tt.hasNext || response.HasPrev != tt.hasPrev {
+				t.Errorf("has next = %v, has prev = %v, want %v, %v", response.HasNext, response.HasPrev, tt.hasNext, tt.hasPrev)
+			}
+		})
+	}
+}
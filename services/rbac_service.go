@@ -0,0 +1,437 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"synergylabs/models"
+	"synergylabs/services/cache"
+	"synergylabs/util"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type RBACService struct {
+	db     *gorm.DB
+	cache  *cache.Cache
+	logger *zap.Logger
+}
+
+var _ RBACServiceInterface = (*RBACService)(nil)
+var _ util.PermissionResolver = (*RBACService)(nil)
+var _ util.PlatformAdminChecker = (*RBACService)(nil)
+
+func NewRBACService(db *gorm.DB, cache *cache.Cache, logger *zap.Logger) *RBACService {
+	return &RBACService{
+		db:     db,
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+// userAccess is what a user's roles grant, cached as one entry so a single
+// invalidation covers both
+type userAccess struct {
+	Permissions   []models.Permission `json:"permissions"`
+	PlatformAdmin bool                `json:"platform_admin"`
+}
+
+// Permissions implements util.PermissionResolver
+func (s *RBACService) Permissions(ctx context.Context, userID uint) ([]models.Permission, error) {
+	access, err := s.access(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return access.Permissions, nil
+}
+
+// IsPlatformAdmin implements util.PlatformAdminChecker. Holders of the
+// super_admin role administer the platform; the role alone does not decide
+// whether they are scoped to an organization.
+func (s *RBACService) IsPlatformAdmin(ctx context.Context, userID uint) (bool, error) {
+	access, err := s.access(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return access.PlatformAdmin, nil
+}
+
+func (s *RBACService) access(ctx context.Context, userID uint) (*userAccess, error) {
+	cacheKey := permissionsCacheKey(userID)
+	var access userAccess
+
+	// Try to get from cache
+	err := s.cache.Get(ctx, cacheKey, &access)
+	if err == nil {
+		return &access, nil
+	}
+
+	roles, err := s.userRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[models.Permission]bool{}
+	access.Permissions = []models.Permission{}
+	for _, role := range roles {
+		if role.Name == models.RoleSuperAdmin {
+			access.PlatformAdmin = true
+		}
+		for _, perm := range role.Permissions {
+			if !seen[perm] {
+				seen[perm] = true
+				access.Permissions = append(access.Permissions, perm)
+			}
+		}
+	}
+
+	// Cache the permissions
+	if err := s.cache.Set(ctx, cacheKey, access, 5*time.Minute); err != nil {
+		s.logger.Warn("Failed to cache permissions", zap.Error(err))
+	}
+
+	return &access, nil
+}
+
+func (s *RBACService) ListRoles(ctx context.Context) ([]models.Role, error) {
+	var roles []models.Role
+	if err := s.db.WithContext(ctx).Order("name").Find(&roles).Error; err != nil {
+		s.logger.Error("Failed to fetch roles", zap.Error(err))
+		return nil, err
+	}
+	return roles, nil
+}
+
+// CreateRole adds a custom role. Roles are shared by every organization, so
+// only platform administrators manage them.
+func (s *RBACService) CreateRole(ctx context.Context, role *models.Role) error {
+	if !util.SeesAllOrganizations(ctx) {
+		return ErrPlatformAdminRequired
+	}
+	if err := validatePermissions(role.Permissions); err != nil {
+		return err
+	}
+	role.System = false
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(role).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditActionRoleCreate, "role", role.ID, nil, role)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrRoleExists
+		}
+		s.logger.Error("Failed to create role", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Role created successfully", zap.Uint("role_id", role.ID), zap.String("name", role.Name))
+	return nil
+}
+
+// UpdateRole changes a custom role's description and permissions. Built-in
+// roles are reset at startup, so they cannot be edited.
+func (s *RBACService) UpdateRole(ctx context.Context, role *models.Role) error {
+	if !util.SeesAllOrganizations(ctx) {
+		return ErrPlatformAdminRequired
+	}
+	var existing models.Role
+	if err := s.db.WithContext(ctx).First(&existing, role.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRoleNotFound
+		}
+		s.logger.Error("Failed to fetch role", zap.Error(err))
+		return err
+	}
+	if existing.System {
+		return ErrSystemRole
+	}
+	if err := validatePermissions(role.Permissions); err != nil {
+		return err
+	}
+
+	before := existing
+	existing.Description = role.Description
+	existing.Permissions = role.Permissions
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&existing).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditActionRoleUpdate, "role", existing.ID, &before, &existing)
+	})
+	if err != nil {
+		s.logger.Error("Failed to update role", zap.Error(err))
+		return err
+	}
+	*role = existing
+
+	s.invalidateRoleHolders(ctx, role.ID)
+	s.logger.Info("Role updated successfully", zap.Uint("role_id", role.ID))
+	return nil
+}
+
+func (s *RBACService) DeleteRole(ctx context.Context, id uint) error {
+	if !util.SeesAllOrganizations(ctx) {
+		return ErrPlatformAdminRequired
+	}
+	var role models.Role
+	if err := s.db.WithContext(ctx).First(&role, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRoleNotFound
+		}
+		s.logger.Error("Failed to fetch role", zap.Error(err))
+		return err
+	}
+	if role.System {
+		return ErrSystemRole
+	}
+
+	holders := s.roleHolders(ctx, id)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM user_roles WHERE role_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&role).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditActionRoleDelete, "role", role.ID, &role, nil)
+	})
+	if err != nil {
+		s.logger.Error("Failed to delete role", zap.Error(err))
+		return err
+	}
+
+	for _, userID := range holders {
+		s.cache.Delete(ctx, permissionsCacheKey(userID))
+	}
+	s.logger.Info("Role deleted successfully", zap.Uint("role_id", id))
+	return nil
+}
+
+// GetUserRoles lists a user's roles. Organization-scoped callers only see their own members.
+func (s *RBACService) GetUserRoles(ctx context.Context, userID uint) ([]models.Role, error) {
+	if err := s.checkMember(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.userRoles(ctx, userID)
+}
+
+func (s *RBACService) userRoles(ctx context.Context, userID uint) ([]models.Role, error) {
+	var roles []models.Role
+	if err := s.db.WithContext(ctx).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Find(&roles).Error; err != nil {
+		s.logger.Error("Failed to fetch user roles", zap.Error(err))
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (s *RBACService) AssignRole(ctx context.Context, userID, roleID uint) error {
+	var user models.User
+	if err := s.db.WithContext(ctx).Scopes(memberTenantScope(ctx)).First(&user, userID).Error; err != nil {
+		s.logger.Error("Failed to fetch user", zap.Error(err))
+		return err
+	}
+	var role models.Role
+	if err := s.db.WithContext(ctx).First(&role, roleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRoleNotFound
+		}
+		s.logger.Error("Failed to fetch role", zap.Error(err))
+		return err
+	}
+	if models.UserTypeForRole(role.Name) != user.UserType {
+		return ErrRoleUserTypeMismatch
+	}
+	if err := checkGrantable(ctx, s, util.RequestInfoFromContext(ctx).ActorID, &role); err != nil {
+		return err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Association("Roles").Append(&role); err != nil {
+			return err
+		}
+		return appendAuditEvent(ctx, tx, roleAuditEvent(models.AuditActionRoleAssign, userID, &role))
+	})
+	if err != nil {
+		s.logger.Error("Failed to assign role", zap.Error(err))
+		return err
+	}
+
+	s.cache.Delete(ctx, permissionsCacheKey(userID))
+	s.logger.Info("Role assigned", zap.Uint("user_id", userID), zap.Uint("role_id", roleID))
+	return nil
+}
+
+// RemoveRole takes a role away from a user, refusing to remove the last super admin
+func (s *RBACService) RemoveRole(ctx context.Context, userID, roleID uint) error {
+	if err := s.checkMember(ctx, userID); err != nil {
+		return err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var role models.Role
+		if err := tx.First(&role, roleID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRoleNotFound
+			}
+			return err
+		}
+
+		var held int64
+		if err := tx.Table("user_roles").Where("user_id = ? AND role_id = ?", userID, roleID).Count(&held).Error; err != nil {
+			return err
+		}
+		if held == 0 {
+			return nil
+		}
+
+		if role.Name == models.RoleSuperAdmin {
+			if err := checkSuperAdminHolders(tx, roleID, userID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Exec("DELETE FROM user_roles WHERE user_id = ? AND role_id = ?", userID, roleID).Error; err != nil {
+			return err
+		}
+		return appendAuditEvent(ctx, tx, roleAuditEvent(models.AuditActionRoleRemove, userID, &role))
+	})
+	if err != nil {
+		if !errors.Is(err, ErrRoleNotFound) && !errors.Is(err, ErrLastSuperAdmin) {
+			s.logger.Error("Failed to remove role", zap.Error(err))
+		}
+		return err
+	}
+
+	s.cache.Delete(ctx, permissionsCacheKey(userID))
+	s.logger.Info("Role removed", zap.Uint("user_id", userID), zap.Uint("role_id", roleID))
+	return nil
+}
+
+// checkMember returns gorm.ErrRecordNotFound unless the user is visible to the caller
+func (s *RBACService) checkMember(ctx context.Context, userID uint) error {
+	var user models.User
+	if err := s.db.WithContext(ctx).Scopes(memberTenantScope(ctx)).Select("id").First(&user, userID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *RBACService) roleHolders(ctx context.Context, roleID uint) []uint {
+	var userIDs []uint
+	if err := s.db.WithContext(ctx).Table("user_roles").
+		Where("role_id = ?", roleID).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		s.logger.Warn("Failed to fetch role holders", zap.Error(err))
+	}
+	return userIDs
+}
+
+func (s *RBACService) invalidateRoleHolders(ctx context.Context, roleID uint) {
+	for _, userID := range s.roleHolders(ctx, roleID) {
+		s.cache.Delete(ctx, permissionsCacheKey(userID))
+	}
+}
+
+// roleAuditEvent describes a role being given to or taken from a user
+func roleAuditEvent(action string, userID uint, role *models.Role) *models.AuditEvent {
+	return &models.AuditEvent{
+		Action:     action,
+		EntityType: "user",
+		EntityID:   strconv.FormatUint(uint64(userID), 10),
+		Metadata:   map[string]interface{}{"role_id": role.ID, "role": role.Name},
+	}
+}
+
+// assignRoleByName gives a newly created user a role inside tx
+func assignRoleByName(tx *gorm.DB, user *models.User, roleName string) error {
+	var role models.Role
+	if err := tx.Where("name = ?", roleName).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRoleNotFound
+		}
+		return err
+	}
+	return tx.Model(user).Association("Roles").Append(&role)
+}
+
+// checkNotLastSuperAdmin refuses to let the user give up their roles when
+// they are the only super admin left
+func checkNotLastSuperAdmin(tx *gorm.DB, userID uint) error {
+	var roleIDs []uint
+	if err := tx.Table("user_roles").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("user_roles.user_id = ? AND roles.name = ?", userID, models.RoleSuperAdmin).
+		Pluck("roles.id", &roleIDs).Error; err != nil {
+		return err
+	}
+	if len(roleIDs) == 0 {
+		return nil
+	}
+	return checkSuperAdminHolders(tx, roleIDs[0], userID)
+}
+
+// checkSuperAdminHolders returns ErrLastSuperAdmin unless an active user other
+// than userID holds the super admin role
+func checkSuperAdminHolders(tx *gorm.DB, roleID, userID uint) error {
+	// Serialize concurrent removals so two admins cannot demote each other at once
+	if err := tx.Exec("SELECT id FROM roles WHERE id = ? FOR UPDATE", roleID).Error; err != nil {
+		return err
+	}
+	var others int64
+	if err := tx.Table("user_roles").
+		Joins("JOIN users ON users.id = user_roles.user_id AND users.deleted_at IS NULL").
+		Where("user_roles.role_id = ? AND user_roles.user_id <> ?", roleID, userID).
+		Count(&others).Error; err != nil {
+		return err
+	}
+	if others == 0 {
+		return ErrLastSuperAdmin
+	}
+	return nil
+}
+
+// checkGrantable returns ErrRoleNotGrantable unless granterID holds every
+// permission role grants, so nobody hands out more access than they have.
+// Calls made outside a request, with no granter, are trusted.
+func checkGrantable(ctx context.Context, permissions util.PermissionResolver, granterID uint, role *models.Role) error {
+	if granterID == 0 {
+		return nil
+	}
+	granted, err := permissions.Permissions(ctx, granterID)
+	if err != nil {
+		return err
+	}
+	held := map[models.Permission]bool{}
+	for _, perm := range granted {
+		held[perm] = true
+	}
+	for _, perm := range role.Permissions {
+		if !held[perm] {
+			return fmt.Errorf("%w: %s", ErrRoleNotGrantable, perm)
+		}
+	}
+	return nil
+}
+
+func validatePermissions(perms []models.Permission) error {
+	for _, perm := range perms {
+		if !perm.Valid() {
+			return fmt.Errorf("%w: %s", ErrUnknownPermission, perm)
+		}
+	}
+	return nil
+}
+
+func permissionsCacheKey(userID uint) string {
+	return fmt.Sprintf("%s:%d", PermissionsCacheKey, userID)
+}
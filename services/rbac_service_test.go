@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"synergylabs/db"
+	"synergylabs/models"
+	"testing"
+)
+
+func TestCheckGrantable(t *testing.T) {
+	permissions := testPermissions{
+		1: models.AllPermissions,
+		2: {models.PermJobCreate, models.PermJobRead, models.PermUserInvite},
+	}
+	recruiterLike := &models.Role{Name: "sourcer", Permissions: []models.Permission{models.PermJobCreate, models.PermJobRead}}
+	superAdmin := &models.Role{Name: models.RoleSuperAdmin, Permissions: models.AllPermissions}
+
+	tests := []struct {
+		name      string
+		granterID uint
+		role      *models.Role
+		want      error
+	}{
+		{"super admin grants anything", 1, superAdmin, nil},
+		{"subset of the granter's permissions", 2, recruiterLike, nil},
+		{"more than the granter holds", 2, superAdmin, ErrRoleNotGrantable},
+		{"granter without permissions", 3, recruiterLike, ErrRoleNotGrantable},
+		{"no granter outside a request", 0, superAdmin, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkGrantable(context.Background(), permissions, tt.granterID, tt.role)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("checkGrantable error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrateKeepsRemovedRolesRemoved(t *testing.T) {
+	conn := testDB(t)
+	s := NewRBACService(conn, testCache(t), testLogger())
+
+	user := models.User{Name: "Former recruiter", Email: testEmail("former-recruiter"), UserType: models.UserTypeAdmin}
+	if err := conn.Create(&user).Error; err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	var recruiter models.Role
+	if err := conn.Where("name = ?", models.RoleRecruiter).First(&recruiter).Error; err != nil {
+		t.Fatalf("loading recruiter role: %v", err)
+	}
+	ctx := platformAdminContext()
+	if err := s.AssignRole(ctx, user.ID, recruiter.ID); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+	if err := s.RemoveRole(ctx, user.ID, recruiter.ID); err != nil {
+		t.Fatalf("RemoveRole: %v", err)
+	}
+
+	// A restart must not hand the staff account the default super_admin role
+	if err := db.Migrate(conn); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	var roles int64
+	if err := conn.Table("user_roles").Where("user_id = ?", user.ID).Count(&roles).Error; err != nil {
+		t.Fatalf("counting roles: %v", err)
+	}
+	if roles != 0 {
+		t.Errorf("user has %d roles after a restart, want none", roles)
+	}
+}
@@ -97,7 +97,13 @@ func (s *ResumeService) ProcessResume(ctx context.Context, file *multipart.FileH
 	}
 
 	// Save the profile to the database
-	if err := s.db.WithContext(ctx).Save(&profile).Error; err != nil {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&profile).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditActionProfileUpdate, "profile", profile.ID, nil, &profile)
+	})
+	if err != nil {
 		s.logger.Error("Failed to save profile data", zap.Error(err))
 		return err
 	}
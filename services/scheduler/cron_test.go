@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRejectsInvalidSchedules(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"0 0 0 * *",
+		"0 0 * 13 *",
+		"0 0 * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"1-x * * * *",
+		"a * * * *",
+		"@every 500ms",
+		"@every soon",
+		"@fortnightly",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// A Friday
+	from := time.Date(2026, 10, 16, 10, 7, 20, 0, time.UTC)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		year := 2026
+		if month < time.October {
+			year = 2027
+		}
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"every minute", "* * * * *", from, at(time.October, 16, 10, 8)},
+		{"strictly after the current minute", "7 * * * *", from, at(time.October, 16, 11, 7)},
+		{"step over the whole field", "*/15 * * * *", from, at(time.October, 16, 10, 15)},
+		{"step from a start value", "5/20 * * * *", from, at(time.October, 16, 10, 25)},
+		{"step over a range", "0 9-17/4 * * *", from, at(time.October, 16, 13, 0)},
+		{"list", "30 8,20 * * *", from, at(time.October, 16, 20, 30)},
+		{"range in the past today", "0 1-3 * * *", from, at(time.October, 17, 1, 0)},
+		{"day of week", "0 0 * * 1", from, at(time.October, 19, 0, 0)},
+		{"sunday as 0", "0 0 * * 0", from, at(time.October, 18, 0, 0)},
+		{"sunday as 7", "0 0 * * 7", from, at(time.October, 18, 0, 0)},
+		{"day of month", "0 0 13 * *", from, at(time.November, 13, 0, 0)},
+		{"day of month or day of week", "0 0 13 * 5", from, at(time.October, 23, 0, 0)},
+		{"day of month and month", "0 0 1 1 *", from, at(time.January, 1, 0, 0)},
+		{"day of week and month", "0 12 * 2 1-5", from, at(time.February, 1, 12, 0)},
+		{"skips short months", "0 0 31 * *", at(time.November, 1, 0, 0), at(time.December, 31, 0, 0)},
+		{"leap day", "0 0 29 2 *", from, time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"never", "0 0 30 2 *", from, time.Time{}},
+		{"shorthand", "@daily", from, at(time.October, 17, 0, 0)},
+		{"in UTC", "0 9 * * *", from.In(time.FixedZone("UTC+2", 2*60*60)).Add(-2 * time.Hour), at(time.October, 16, 9, 0)},
+		{"every", "@every 90s", time.Date(2026, 10, 16, 10, 0, 30, 0, time.UTC), time.Date(2026, 10, 16, 10, 1, 30, 0, time.UTC)},
+		{"every on the boundary", "@every 1h", time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC), at(time.October, 16, 11, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.spec, err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
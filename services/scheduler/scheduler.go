@@ -0,0 +1,387 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"synergylabs/models"
+	"synergylabs/services"
+	"synergylabs/services/cache"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownTask   = errors.New("unknown task")
+	ErrDuplicateTask = errors.New("task already registered")
+)
+
+const (
+	// DefaultTimeout bounds one attempt of a task without a Timeout
+	DefaultTimeout = 5 * time.Minute
+
+	// defaultLockTTL is how long a run's lock outlives an instance that stops
+	// renewing it; running instances renew it every third of that
+	defaultLockTTL = 30 * time.Second
+
+	// slotClaimTTL keeps the claim on a scheduled time long enough for every
+	// instance, whatever its clock skew, to see that it was taken
+	slotClaimTTL = time.Hour
+)
+
+// Task is a unit of background work
+type Task struct {
+	Name string
+	// Schedule is a cron expression as accepted by Parse; empty for tasks that
+	// only run when triggered
+	Schedule string
+	// Timeout bounds each attempt; zero means DefaultTimeout
+	Timeout time.Duration
+	// MaxAttempts is how often a failing run is tried; zero means once
+	MaxAttempts int
+	// RetryDelay is the wait before the first retry and doubles after each one
+	RetryDelay time.Duration
+	// Run does the work and returns a short summary for the run history
+	Run func(ctx context.Context) (string, error)
+}
+
+// TaskInfo describes a registered task for the admin API
+type TaskInfo struct {
+	Name     string          `json:"name"`
+	Schedule string          `json:"schedule,omitempty"`
+	NextRun  *time.Time      `json:"next_run,omitempty"`
+	LastRun  *models.TaskRun `json:"last_run,omitempty"`
+}
+
+type registeredTask struct {
+	Task
+	schedule Schedule
+	// triggers holds at most one pending manual or event run
+	triggers chan string
+}
+
+// Scheduler runs tasks on their schedules in every instance of the API. A
+// Redis lock makes sure each scheduled run happens on one instance only, and
+// every run is recorded in the task_runs table.
+type Scheduler struct {
+	db       *gorm.DB
+	cache    *cache.Cache
+	logger   *zap.Logger
+	instance string
+	lockTTL  time.Duration
+
+	mu      sync.Mutex
+	tasks   map[string]*registeredTask
+	names   []string
+	started bool
+	// loops counts the task loops that have not returned yet
+	loops sync.WaitGroup
+}
+
+func NewScheduler(db *gorm.DB, cache *cache.Cache, logger *zap.Logger) *Scheduler {
+	return &Scheduler{
+		db:       db,
+		cache:    cache,
+		logger:   logger,
+		instance: instanceName(),
+		lockTTL:  defaultLockTTL,
+		tasks:    make(map[string]*registeredTask),
+	}
+}
+
+// Register adds a task. Tasks must be registered before Start.
+func (s *Scheduler) Register(task Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return fmt.Errorf("task %s registered after the scheduler started", task.Name)
+	}
+	if _, ok := s.tasks[task.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateTask, task.Name)
+	}
+	registered := &registeredTask{Task: task, triggers: make(chan string, 1)}
+	if task.Schedule != "" {
+		schedule, err := Parse(task.Schedule)
+		if err != nil {
+			return err
+		}
+		if schedule.Next(time.Now()).IsZero() {
+			return fmt.Errorf("schedule %q of task %s never runs", task.Schedule, task.Name)
+		}
+		registered.schedule = schedule
+	}
+	if registered.Timeout <= 0 {
+		registered.Timeout = DefaultTimeout
+	}
+	if registered.MaxAttempts < 1 {
+		registered.MaxAttempts = 1
+	}
+
+	s.tasks[task.Name] = registered
+	s.names = append(s.names, task.Name)
+	return nil
+}
+
+// Start runs every registered task on its schedule until ctx is done
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started = true
+	for _, name := range s.names {
+		s.loops.Add(1)
+		go func(task *registeredTask) {
+			defer s.loops.Done()
+			s.loop(ctx, task)
+		}(s.tasks[name])
+	}
+	s.logger.Info("Scheduler started", zap.String("instance", s.instance), zap.Int("tasks", len(s.names)))
+}
+
+// Wait blocks until the context passed to Start is done and the runs in
+// progress at that moment have been recorded
+func (s *Scheduler) Wait() {
+	s.loops.Wait()
+}
+
+// Trigger asks for a run of the task outside its schedule. A trigger arriving
+// while another one is pending is merged into it.
+func (s *Scheduler) Trigger(name, trigger string) error {
+	s.mu.Lock()
+	task, ok := s.tasks[name]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownTask, name)
+	}
+	select {
+	case task.triggers <- trigger:
+	default:
+	}
+	return nil
+}
+
+// Tasks lists the registered tasks with their next and latest runs
+func (s *Scheduler) Tasks(ctx context.Context) ([]TaskInfo, error) {
+	s.mu.Lock()
+	infos := make([]TaskInfo, 0, len(s.names))
+	for _, name := range s.names {
+		task := s.tasks[name]
+		info := TaskInfo{Name: name, Schedule: task.Schedule}
+		if task.schedule != nil {
+			next := task.schedule.Next(time.Now())
+			info.NextRun = &next
+		}
+		infos = append(infos, info)
+	}
+	s.mu.Unlock()
+
+	for i := range infos {
+		var last models.TaskRun
+		err := s.db.WithContext(ctx).Where("task = ?", infos[i].Name).Order("id DESC").First(&last).Error
+		switch {
+		case err == nil:
+			infos[i].LastRun = &last
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			s.logger.Error("Failed to fetch last task run", zap.Error(err))
+			return nil, err
+		}
+	}
+	return infos, nil
+}
+
+// Runs returns a task's most recent runs, newest first
+func (s *Scheduler) Runs(ctx context.Context, name string, limit int) ([]models.TaskRun, error) {
+	s.mu.Lock()
+	_, ok := s.tasks[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTask, name)
+	}
+
+	var runs []models.TaskRun
+	if err := s.db.WithContext(ctx).Where("task = ?", name).Order("id DESC").Limit(limit).Find(&runs).Error; err != nil {
+		s.logger.Error("Failed to fetch task runs", zap.Error(err))
+		return nil, err
+	}
+	return runs, nil
+}
+
+// PruneRuns deletes the history of runs that started before cutoff
+func (s *Scheduler) PruneRuns(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Where("started_at < ?", cutoff).Delete(&models.TaskRun{})
+	return result.RowsAffected, result.Error
+}
+
+func (s *Scheduler) loop(ctx context.Context, task *registeredTask) {
+	for {
+		// Triggered-only tasks wait on a timer that never fires
+		var fire <-chan time.Time
+		var slot time.Time
+		stop := func() bool { return false }
+		if task.schedule != nil {
+			slot = task.schedule.Next(time.Now())
+			timer := time.NewTimer(time.Until(slot))
+			fire, stop = timer.C, timer.Stop
+		}
+
+		select {
+		case <-ctx.Done():
+			stop()
+			return
+		case <-fire:
+			s.execute(ctx, task, slot, models.TaskTriggerSchedule)
+		case trigger := <-task.triggers:
+			stop()
+			s.execute(ctx, task, time.Time{}, trigger)
+		}
+	}
+}
+
+// execute runs a task once on this instance, unless another instance claimed
+// the scheduled slot or is still running the task
+func (s *Scheduler) execute(ctx context.Context, task *registeredTask, slot time.Time, trigger string) {
+	logger := s.logger.With(zap.String("task", task.Name), zap.String("trigger", trigger))
+
+	if !slot.IsZero() {
+		claimKey := fmt.Sprintf("%s:%s:%d", services.SchedulerSlotCacheKey, task.Name, slot.Unix())
+		claimed, err := s.cache.SetNX(ctx, claimKey, s.instance, slotClaimTTL)
+		if err != nil {
+			logger.Error("Failed to claim scheduled task run", zap.Error(err))
+			return
+		}
+		if !claimed {
+			return
+		}
+	}
+
+	release, err := s.lock(ctx, task.Name)
+	if err != nil {
+		logger.Error("Failed to lock task", zap.Error(err))
+		return
+	}
+	if release == nil {
+		logger.Info("Task is still running on another instance, skipping this run")
+		return
+	}
+	defer release()
+
+	run := models.TaskRun{
+		Task:      task.Name,
+		Trigger:   trigger,
+		Instance:  s.instance,
+		StartedAt: time.Now(),
+		Status:    models.TaskRunRunning,
+	}
+	if err := s.db.WithContext(ctx).Create(&run).Error; err != nil {
+		logger.Error("Failed to record task run", zap.Error(err))
+		return
+	}
+
+	result, err := s.attempt(ctx, task, &run, logger)
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.Result = result
+	run.Status = models.TaskRunSucceeded
+	if err != nil {
+		run.Status = models.TaskRunFailed
+		run.Error = err.Error()
+		logger.Error("Task failed", zap.Int("attempts", run.Attempts), zap.Error(err))
+	} else {
+		logger.Info("Task finished", zap.String("result", result), zap.Duration("duration", finished.Sub(run.StartedAt)))
+	}
+	// The run is recorded even when ctx was cancelled during it
+	if err := s.db.Save(&run).Error; err != nil {
+		logger.Error("Failed to record task run", zap.Error(err))
+	}
+}
+
+// attempt runs the task, retrying failures with a doubling delay
+func (s *Scheduler) attempt(ctx context.Context, task *registeredTask, run *models.TaskRun, logger *zap.Logger) (string, error) {
+	delay := task.RetryDelay
+	for {
+		run.Attempts++
+		result, err := runOnce(ctx, task)
+		if err == nil || run.Attempts >= task.MaxAttempts {
+			return result, err
+		}
+
+		logger.Warn("Task attempt failed, retrying", zap.Int("attempt", run.Attempts), zap.Duration("delay", delay), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// runOnce runs one attempt, turning a panic into an error
+func runOnce(ctx context.Context, task *registeredTask) (result string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, task.Timeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panicked: %v", r)
+		}
+	}()
+	return task.Run(ctx)
+}
+
+// lock takes the task's lock in Redis and keeps renewing it until the
+// returned release is called. It returns a nil release when the lock is held
+// elsewhere.
+func (s *Scheduler) lock(ctx context.Context, name string) (func(), error) {
+	key := fmt.Sprintf("%s:%s", services.SchedulerLockCacheKey, name)
+	token := s.instance + ":" + randomHex(8)
+
+	acquired, err := s.cache.SetNX(ctx, key, token, s.lockTTL)
+	if err != nil || !acquired {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(s.lockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				renewed, err := s.cache.CompareAndSwap(ctx, key, token, token, s.lockTTL)
+				if err != nil || !renewed {
+					s.logger.Warn("Failed to renew task lock", zap.String("task", name), zap.Error(err))
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		if _, err := s.cache.DeleteIfEquals(context.Background(), key, token); err != nil {
+			s.logger.Warn("Failed to release task lock", zap.String("task", name), zap.Error(err))
+		}
+	}, nil
+}
+
+// instanceName identifies this process in locks and the run history
+func instanceName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return host + "-" + randomHex(4)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
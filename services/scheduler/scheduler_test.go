@@ -0,0 +1,183 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"synergylabs/models"
+	"testing"
+	"time"
+)
+
+// testTaskName returns a task name no other test run has used, since slot
+// claims and locks outlive the test in Redis
+func testTaskName(prefix string) string {
+	return prefix + "-" + randomHex(8)
+}
+
+func TestScheduledSlotRunsOnce(t *testing.T) {
+	conn, redis := testDB(t), testCache(t)
+	ctx := context.Background()
+
+	var runs atomic.Int32
+	name := testTaskName("slot")
+	schedulers := make([]*Scheduler, 2)
+	tasks := make([]*registeredTask, len(schedulers))
+	for i := range schedulers {
+		schedulers[i] = NewScheduler(conn, redis, testLogger())
+		err := schedulers[i].Register(Task{
+			Name:     name,
+			Schedule: "* * * * *",
+			Run: func(ctx context.Context) (string, error) {
+				runs.Add(1)
+				// Long enough for the other instance to try the slot meanwhile
+				time.Sleep(100 * time.Millisecond)
+				return "done", nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		tasks[i] = schedulers[i].tasks[name]
+	}
+
+	slot := time.Now().Truncate(time.Minute)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range schedulers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			schedulers[i].execute(ctx, tasks[i], slot, models.TaskTriggerSchedule)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	// A late instance finds the slot already claimed
+	schedulers[1].execute(ctx, tasks[1], slot, models.TaskTriggerSchedule)
+
+	if n := runs.Load(); n != 1 {
+		t.Errorf("task ran %d times for one slot, want once", n)
+	}
+	var recorded []models.TaskRun
+	if err := conn.Where("task = ?", name).Find(&recorded).Error; err != nil {
+		t.Fatalf("loading runs: %v", err)
+	}
+	if len(recorded) != 1 || recorded[0].Status != models.TaskRunSucceeded || recorded[0].Attempts != 1 {
+		t.Errorf("recorded runs = %+v, want one succeeded run of one attempt", recorded)
+	}
+
+	// The next slot is free again
+	schedulers[1].execute(ctx, tasks[1], slot.Add(time.Minute), models.TaskTriggerSchedule)
+	if n := runs.Load(); n != 2 {
+		t.Errorf("task ran %d times after the next slot, want twice", n)
+	}
+}
+
+func TestLockIsRenewedUntilReleased(t *testing.T) {
+	redis := testCache(t)
+	ctx := context.Background()
+	holder := NewScheduler(nil, redis, testLogger())
+	other := NewScheduler(nil, redis, testLogger())
+	holder.lockTTL = 300 * time.Millisecond
+	name := testTaskName("lock")
+
+	release, err := holder.lock(ctx, name)
+	if err != nil || release == nil {
+		t.Fatalf("lock = %v, %v, want the lock", release != nil, err)
+	}
+
+	// Without renewal the lock would have expired several times over
+	time.Sleep(4 * holder.lockTTL)
+	if stolen, err := other.lock(ctx, name); err != nil || stolen != nil {
+		t.Fatalf("another instance took a held lock: %v, %v", stolen != nil, err)
+	}
+
+	release()
+	taken, err := other.lock(ctx, name)
+	if err != nil || taken == nil {
+		t.Fatalf("lock after release = %v, %v, want the lock", taken != nil, err)
+	}
+	taken()
+}
+
+func TestAttemptRetries(t *testing.T) {
+	failing := errors.New("upstream unavailable")
+
+	tests := []struct {
+		name        string
+		maxAttempts int
+		failures    int
+		panics      bool
+		attempts    int
+		wantErr     string
+	}{
+		{"succeeds at once", 3, 0, false, 1, ""},
+		{"succeeds on a retry", 3, 2, false, 3, ""},
+		{"fails every attempt", 2, 5, false, 2, failing.Error()},
+		{"single attempt", 1, 1, false, 1, failing.Error()},
+		{"panics", 1, 0, true, 1, "task panicked"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			task := &registeredTask{Task: Task{
+				Name:        "retry",
+				Timeout:     time.Second,
+				MaxAttempts: tt.maxAttempts,
+				RetryDelay:  time.Millisecond,
+				Run: func(ctx context.Context) (string, error) {
+					calls++
+					if tt.panics {
+						panic("boom")
+					}
+					if calls <= tt.failures {
+						return "", failing
+					}
+					return "done", nil
+				},
+			}}
+
+			var run models.TaskRun
+			s := NewScheduler(nil, nil, testLogger())
+			result, err := s.attempt(context.Background(), task, &run, testLogger())
+			if run.Attempts != tt.attempts || calls != tt.attempts {
+				t.Errorf("attempts = %d, calls = %d, want %d", run.Attempts, calls, tt.attempts)
+			}
+			if tt.wantErr == "" {
+				if err != nil || result != "done" {
+					t.Errorf("attempt = %q, %v, want done", result, err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("attempt error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAttemptStopsRetryingWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	task := &registeredTask{Task: Task{
+		Name:        "cancelled",
+		Timeout:     time.Second,
+		MaxAttempts: 5,
+		RetryDelay:  time.Hour,
+		Run: func(ctx context.Context) (string, error) {
+			cancel()
+			return "", errors.New("failed")
+		},
+	}}
+
+	var run models.TaskRun
+	s := NewScheduler(nil, nil, testLogger())
+	if _, err := s.attempt(ctx, task, &run, testLogger()); !errors.Is(err, context.Canceled) {
+		t.Errorf("attempt error = %v, want %v", err, context.Canceled)
+	}
+	if run.Attempts != 1 {
+		t.Errorf("attempts = %d, want 1", run.Attempts)
+	}
+}
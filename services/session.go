@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"synergylabs/models"
+	"synergylabs/util"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// sessionSeenResolution limits how often a session's last_seen_at is written
+const sessionSeenResolution = time.Minute
+
+const maxUserAgentLength = 512
+
+// ListSessions returns the user's active sessions, marking the one currentFamily belongs to
+func (s *TokenService) ListSessions(ctx context.Context, userID uint, currentFamily string) ([]SessionView, error) {
+	var sessions []models.Session
+	if err := s.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		s.logger.Error("Failed to fetch sessions", zap.Error(err))
+		return nil, err
+	}
+
+	views := make([]SessionView, len(sessions))
+	for i, session := range sessions {
+		views[i] = SessionView{
+			Session: session,
+			Current: currentFamily != "" && session.Family == currentFamily,
+		}
+	}
+	return views, nil
+}
+
+// RevokeSession signs one of the user's devices out
+func (s *TokenService) RevokeSession(ctx context.Context, userID, id uint) error {
+	var session models.Session
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		s.logger.Error("Failed to fetch session", zap.Error(err))
+		return err
+	}
+
+	if err := s.endSession(ctx, session.Family); err != nil {
+		return err
+	}
+
+	s.logger.Info("Session revoked", zap.Uint("user_id", userID), zap.Uint("session_id", id))
+	return nil
+}
+
+// RevokeAllSessions signs the user out everywhere. Every token issued to the
+// user so far is rejected, including tokens from before sessions were recorded.
+// Tokens issued afterwards, even within the same second, are not.
+func (s *TokenService) RevokeAllSessions(ctx context.Context, userID uint) error {
+	if err := s.cache.Set(ctx, revokedUserCacheKey(userID), time.Now(), util.RefreshTokenTTL); err != nil {
+		s.logger.Error("Failed to revoke user tokens", zap.Error(err))
+		return err
+	}
+
+	var families []string
+	if err := s.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Pluck("family", &families).Error; err != nil {
+		s.logger.Error("Failed to fetch sessions", zap.Error(err))
+		return err
+	}
+	for _, family := range families {
+		if err := s.endSession(ctx, family); err != nil {
+			return err
+		}
+	}
+
+	s.logger.Info("All sessions revoked", zap.Uint("user_id", userID), zap.Int("sessions", len(families)))
+	return nil
+}
+
+// RevokeOtherSessions signs the user out on every device except the one currentFamily belongs to
+func (s *TokenService) RevokeOtherSessions(ctx context.Context, userID uint, currentFamily string) error {
+	var families []string
+	if err := s.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND family <> ? AND revoked_at IS NULL", userID, currentFamily).
+		Pluck("family", &families).Error; err != nil {
+		s.logger.Error("Failed to fetch sessions", zap.Error(err))
+		return err
+	}
+	for _, family := range families {
+		if err := s.endSession(ctx, family); err != nil {
+			return err
+		}
+	}
+
+	s.logger.Info("Other sessions revoked", zap.Uint("user_id", userID), zap.Int("sessions", len(families)))
+	return nil
+}
+
+// endSession revokes a session's token family and marks the session terminated
+func (s *TokenService) endSession(ctx context.Context, family string) error {
+	if err := s.RevokeFamily(ctx, family); err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Model(&models.Session{}).
+		Where("family = ? AND revoked_at IS NULL", family).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		s.logger.Error("Failed to revoke session", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// touchSession records activity on a session, at most once per sessionSeenResolution
+func (s *TokenService) touchSession(ctx context.Context, family string) {
+	first, err := s.cache.SetNX(ctx, sessionSeenCacheKey(family), true, sessionSeenResolution)
+	if err != nil || !first {
+		return
+	}
+	if err := s.db.WithContext(ctx).Model(&models.Session{}).
+		Where("family = ?", family).
+		Update("last_seen_at", time.Now()).Error; err != nil {
+		s.logger.Warn("Failed to update session", zap.Error(err))
+	}
+}
+
+// revocationCutoff is when a user last signed out everywhere
+type revocationCutoff struct {
+	time.Time
+}
+
+// revokes reports whether a token with claims was issued before the cutoff
+func (c revocationCutoff) revokes(claims *util.Claims) bool {
+	return !claims.Issued().After(c.Time)
+}
+
+func sessionSeenCacheKey(family string) string {
+	return fmt.Sprintf("%s:%s", SessionSeenCacheKey, family)
+}
+
+// truncate shortens value to at most max bytes without splitting a character
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	for max > 0 && !utf8.RuneStart(value[max]) {
+		max--
+	}
+	return value[:max]
+}
+
+// PurgeExpiredSessions deletes sessions whose refresh tokens expired before
+// the cutoff. Revoked sessions are kept until then, because a refresh without
+// a session row is only refused through the revocation kept in Redis.
+func (s *TokenService) PurgeExpiredSessions(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Unscoped().Where("expires_at < ?", before).Delete(&models.Session{})
+	if result.Error != nil {
+		s.logger.Error("Failed to purge expired sessions", zap.Error(result.Error))
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"synergylabs/models"
+	"synergylabs/util"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestRevocationCutoff(t *testing.T) {
+	cutoff := time.Date(2026, 3, 1, 12, 0, 0, 500_000_000, time.UTC)
+	current, _ := json.Marshal(cutoff)
+
+	tests := []struct {
+		name    string
+		stored  []byte
+		claims  util.Claims
+		revoked bool
+	}{
+		{"issued before", current, util.Claims{IssuedAtNano: cutoff.Add(-time.Millisecond).UnixNano()}, true},
+		{"issued at the cutoff", current, util.Claims{IssuedAtNano: cutoff.UnixNano()}, true},
+		{"issued later in the same second", current, util.Claims{IssuedAtNano: cutoff.Add(time.Millisecond).UnixNano()}, false},
+		{"token without nanoseconds from the same second", current, withIssuedAt(cutoff.Unix()), true},
+		{"token without nanoseconds from the next second", current, withIssuedAt(cutoff.Unix() + 1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c revocationCutoff
+			if err := json.Unmarshal(tt.stored, &c); err != nil {
+				t.Fatalf("decoding %s: %v", tt.stored, err)
+			}
+			if got := c.revokes(&tt.claims); got != tt.revoked {
+				t.Errorf("revokes = %v, want %v", got, tt.revoked)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		value string
+		max   int
+		want  string
+	}{
+		{"Mozilla/5.0", 64, "Mozilla/5.0"},
+		{"Mozilla/5.0", 7, "Mozilla"},
+		{"Pixel ü", 7, "Pixel "},
+		{"Pixel ü", 8, "Pixel ü"},
+		{"日本語", 5, "日"},
+		{"日本語", 6, "日本"},
+		{"日本語", 2, ""},
+		{"👍👍", 7, "👍"},
+	}
+	for _, tt := range tests {
+		got := truncate(tt.value, tt.max)
+		if got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.value, tt.max, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("truncate(%q, %d) = %q is not valid UTF-8", tt.value, tt.max, got)
+		}
+	}
+}
+
+func withIssuedAt(seconds int64) util.Claims {
+	var claims util.Claims
+	claims.IssuedAt = seconds
+	return claims
+}
+
+func TestRevokeAllSessionsSparesLaterLogins(t *testing.T) {
+	db := testDB(t)
+	testKeyring(t)
+	s := NewTokenService(db, testCache(t), testLogger())
+	ctx := context.Background()
+
+	user := models.User{Name: "Sessions", Email: testEmail("sessions"), UserType: models.UserTypeApplicant}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+
+	before, err := s.IssueTokens(ctx, &user, ClientInfo{})
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+	if err := s.RevokeAllSessions(ctx, user.ID); err != nil {
+		t.Fatalf("RevokeAllSessions: %v", err)
+	}
+	// Signing back in right away, as after a password reset, must work
+	after, err := s.IssueTokens(ctx, &user, ClientInfo{})
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		token   string
+		revoked bool
+	}{
+		{"access token from before", before.AccessToken, true},
+		{"refresh token from before", before.RefreshToken, true},
+		{"access token from after", after.AccessToken, false},
+		{"refresh token from after", after.RefreshToken, false},
+	} {
+		claims, err := util.ValidateToken(tt.token)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		revoked, err := s.IsRevoked(ctx, claims)
+		if err != nil {
+			t.Fatalf("%s: IsRevoked: %v", tt.name, err)
+		}
+		if revoked != tt.revoked {
+			t.Errorf("%s: revoked = %v, want %v", tt.name, revoked, tt.revoked)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"synergylabs/models"
+	"synergylabs/services/cache"
+	"synergylabs/services/oidc"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// SSOStateTTL bounds how long a user has to complete the login at the identity provider
+const SSOStateTTL = 10 * time.Minute
+
+type SSOService struct {
+	db       *gorm.DB
+	cache    *cache.Cache
+	provider *oidc.Provider
+	logger   *zap.Logger
+}
+
+var _ SSOServiceInterface = (*SSOService)(nil)
+
+func NewSSOService(db *gorm.DB, cache *cache.Cache, provider *oidc.Provider, logger *zap.Logger) *SSOService {
+	return &SSOService{
+		db:       db,
+		cache:    cache,
+		provider: provider,
+		logger:   logger,
+	}
+}
+
+// ssoLoginState is kept in Redis under the state parameter between the redirect and the callback
+type ssoLoginState struct {
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+// BeginLogin starts an authorization code flow and returns the provider URL to
+// redirect to, along with the state the browser must present on the callback
+func (s *SSOService) BeginLogin(ctx context.Context) (string, string, error) {
+	state, err := randomURLString(32)
+	if err != nil {
+		return "", "", err
+	}
+	loginState := ssoLoginState{}
+	if loginState.Nonce, err = randomURLString(32); err != nil {
+		return "", "", err
+	}
+	if loginState.CodeVerifier, err = randomURLString(32); err != nil {
+		return "", "", err
+	}
+
+	authURL, err := s.provider.AuthCodeURL(ctx, state, loginState.Nonce, loginState.CodeVerifier)
+	if err != nil {
+		s.logger.Error("Failed to build SSO authorization URL", zap.Error(err))
+		return "", "", err
+	}
+
+	if err := s.cache.Set(ctx, ssoStateCacheKey(state), loginState, SSOStateTTL); err != nil {
+		s.logger.Error("Failed to store SSO login state", zap.Error(err))
+		return "", "", err
+	}
+	return authURL, state, nil
+}
+
+// CompleteLogin handles the provider's callback and returns the user it signs
+// in. browserState is the state BeginLogin handed to the browser that started
+// the login; a callback carrying any other state, such as one an attacker
+// started and lured the victim into finishing, is refused.
+func (s *SSOService) CompleteLogin(ctx context.Context, code, state, browserState string) (*models.User, error) {
+	if browserState == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		securityEvent(s.logger, "sso_state_mismatch")
+		return nil, ErrInvalidSSOState
+	}
+
+	// The state is single-use, so a replayed callback fails here
+	var loginState ssoLoginState
+	if err := s.cache.Take(ctx, ssoStateCacheKey(state), &loginState); err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return nil, ErrInvalidSSOState
+		}
+		s.logger.Error("Failed to load SSO login state", zap.Error(err))
+		return nil, err
+	}
+
+	tokens, err := s.provider.Exchange(ctx, code, loginState.CodeVerifier)
+	if err != nil {
+		s.logger.Warn("SSO code exchange failed", zap.Error(err))
+		return nil, fmt.Errorf("%w: %v", ErrSSOFailed, err)
+	}
+	idToken, err := s.provider.VerifyIDToken(ctx, tokens.IDToken, loginState.Nonce)
+	if err != nil {
+		securityEvent(s.logger, "sso_id_token_rejected", zap.Error(err))
+		return nil, fmt.Errorf("%w: %v", ErrSSOFailed, err)
+	}
+
+	user, err := s.resolveUser(ctx, idToken)
+	if err != nil {
+		s.logger.Warn("SSO login refused",
+			zap.String("issuer", idToken.Issuer),
+			zap.String("subject", idToken.Subject),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	s.logger.Info("SSO login", zap.Uint("user_id", user.ID), zap.String("issuer", idToken.Issuer))
+	return user, nil
+}
+
+// resolveUser finds the user an ID token belongs to: first by a linked
+// identity, then by verified email, and finally by provisioning a new
+// account when an organization accepts the email's domain
+func (s *SSOService) resolveUser(ctx context.Context, idToken *oidc.IDToken) (*models.User, error) {
+	db := s.db.WithContext(ctx)
+
+	var identity models.UserIdentity
+	err := db.Where("issuer = ? AND subject = ?", idToken.Issuer, idToken.Subject).First(&identity).Error
+	if err == nil {
+		var user models.User
+		if err := db.First(&user, identity.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrSSOAccountNotFound
+			}
+			return nil, err
+		}
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Matching on email is only safe when the provider vouches for it
+	if idToken.Email == "" || !idToken.EmailVerified {
+		return nil, ErrSSOEmailNotVerified
+	}
+
+	var user models.User
+	err = db.Scopes(emailScope(idToken.Email)).First(&user).Error
+	switch {
+	case err == nil:
+		if user.UserType != models.UserTypeAdmin {
+			return nil, ErrNotStaff
+		}
+		if err := db.Create(&models.UserIdentity{UserID: user.ID, Issuer: idToken.Issuer, Subject: idToken.Subject}).Error; err != nil {
+			s.logger.Error("Failed to link SSO identity", zap.Error(err))
+			return nil, err
+		}
+		if user.EmailVerifiedAt == nil {
+			now := time.Now()
+			if err := db.Model(&user).Update("email_verified_at", now).Error; err != nil {
+				s.logger.Error("Failed to mark SSO email as verified", zap.Error(err))
+				return nil, err
+			}
+			user.EmailVerifiedAt = &now
+		}
+		return &user, nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return s.provisionUser(ctx, idToken)
+	default:
+		return nil, err
+	}
+}
+
+// provisionUser creates a staff account just in time for the organization
+// whose settings list the email's domain
+func (s *SSOService) provisionUser(ctx context.Context, idToken *oidc.IDToken) (*models.User, error) {
+	var orgs []models.Organization
+	if err := s.db.WithContext(ctx).Find(&orgs).Error; err != nil {
+		return nil, err
+	}
+	var matches []*models.Organization
+	for i := range orgs {
+		if orgs[i].Settings.ProvisionsSSOEmail(idToken.Email) {
+			matches = append(matches, &orgs[i])
+		}
+	}
+	if len(matches) == 0 {
+		return nil, ErrSSOAccountNotFound
+	}
+	// Domains are unique across organizations, but settings saved before
+	// that was enforced may still overlap; never guess which one is meant
+	if len(matches) > 1 {
+		securityEvent(s.logger, "sso_domain_ambiguous", zap.String("subject", idToken.Subject), zap.Int("organizations", len(matches)))
+		return nil, ErrSSOAccountNotFound
+	}
+	org := matches[0]
+
+	name := idToken.Name
+	if name == "" {
+		name = idToken.Email
+	}
+	now := time.Now()
+	user := models.User{
+		Name:            name,
+		Email:           normalizeEmail(idToken.Email),
+		UserType:        models.UserTypeAdmin,
+		EmailVerifiedAt: &now,
+		OrganizationID:  &org.ID,
+	}
+
+	// Provisioned accounts have no password and can only sign in through SSO
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrEmailExists
+			}
+			return err
+		}
+		if err := assignRoleByName(tx, &user, org.Settings.SSORole()); err != nil {
+			return err
+		}
+		if err := tx.Create(&models.UserIdentity{UserID: user.ID, Issuer: idToken.Issuer, Subject: idToken.Subject}).Error; err != nil {
+			return err
+		}
+		return appendAuditEvent(ctx, tx, &models.AuditEvent{
+			ActorID:        &user.ID,
+			OrganizationID: &org.ID,
+			Action:         models.AuditActionUserCreate,
+			EntityType:     "user",
+			EntityID:       strconv.FormatUint(uint64(user.ID), 10),
+			Metadata:       map[string]interface{}{"issuer": idToken.Issuer, "role": org.Settings.SSORole()},
+		})
+	})
+	if err != nil {
+		s.logger.Error("Failed to provision SSO user", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("SSO user provisioned",
+		zap.Uint("user_id", user.ID),
+		zap.Uint("organization_id", org.ID),
+		zap.String("role", org.Settings.SSORole()),
+	)
+	return &user, nil
+}
+
+func randomURLString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func ssoStateCacheKey(state string) string {
+	return fmt.Sprintf("%s:%s", SSOStateCacheKey, state)
+}
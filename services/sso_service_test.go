@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"synergylabs/models"
+	"synergylabs/services/oidc"
+	"synergylabs/services/oidc/oidctest"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func newTestSSOService(t *testing.T) (*SSOService, *oidctest.Provider, *gorm.DB) {
+	t.Helper()
+	db := testDB(t)
+	mock := oidctest.NewProvider(t)
+	provider := oidc.NewProvider(oidc.Config{
+		IssuerURL:    mock.Issuer(),
+		ClientID:     oidctest.ClientID,
+		ClientSecret: oidctest.ClientSecret,
+		RedirectURL:  "http://localhost:3000/sso/oidc/callback",
+	}, mock.Client())
+	return NewSSOService(db, testCache(t), provider, testLogger()), mock, db
+}
+
+// ssoLogin signs in through the mock provider from the browser that started the login
+func ssoLogin(t *testing.T, s *SSOService, mock *oidctest.Provider, claims map[string]interface{}) (*models.User, error) {
+	t.Helper()
+	ctx := context.Background()
+	authURL, browserState, err := s.BeginLogin(ctx)
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	code, state := mock.Authorize(t, authURL, claims)
+	return s.CompleteLogin(ctx, code, state, browserState)
+}
+
+// testSSODomain returns a domain no other test run has used
+func testSSODomain() string {
+	return fmt.Sprintf("sso-%d-%d.example.com", time.Now().UnixNano(), emailSeq.Add(1))
+}
+
+func createSSOOrganization(t *testing.T, db *gorm.DB, domain string) *models.Organization {
+	t.Helper()
+	org := &models.Organization{
+		Name:     domain,
+		Slug:     domain,
+		Settings: models.OrganizationSettings{SSOProvisioningDomains: []string{domain}},
+	}
+	if err := NewOrganizationService(db, nil, testPermissions{}, testLogger()).CreateOrganization(platformAdminContext(), org); err != nil {
+		t.Fatalf("CreateOrganization: %v", err)
+	}
+	return org
+}
+
+func TestCompleteLoginRequiresBrowserState(t *testing.T) {
+	// The state check comes before anything is looked up, so no dependencies are needed
+	s := NewSSOService(nil, nil, nil, testLogger())
+	for _, browserState := range []string{"", "state-of-another-login"} {
+		if _, err := s.CompleteLogin(context.Background(), "code", "state", browserState); !errors.Is(err, ErrInvalidSSOState) {
+			t.Errorf("browser state %q: got %v, want ErrInvalidSSOState", browserState, err)
+		}
+	}
+}
+
+func TestSSOLoginProvisionsUser(t *testing.T) {
+	s, mock, db := newTestSSOService(t)
+	domain := testSSODomain()
+	org := createSSOOrganization(t, db, domain)
+
+	claims := map[string]interface{}{
+		"sub":            "provisioned-" + domain,
+		"email":          "new@" + domain,
+		"email_verified": true,
+		"name":           "New Recruiter",
+	}
+	user, err := ssoLogin(t, s, mock, claims)
+	if err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+	if user.OrganizationID == nil || *user.OrganizationID != org.ID || user.UserType != models.UserTypeAdmin || user.EmailVerifiedAt == nil {
+		t.Errorf("provisioned user = %+v, want a verified staff account in organization %d", user, org.ID)
+	}
+	var roles int64
+	db.Table("user_roles").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("user_roles.user_id = ? AND roles.name = ?", user.ID, models.RoleRecruiter).
+		Count(&roles)
+	if roles != 1 {
+		t.Errorf("provisioned user has %d recruiter roles, want 1", roles)
+	}
+
+	// The second login finds the linked identity
+	again, err := ssoLogin(t, s, mock, claims)
+	if err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if again.ID != user.ID {
+		t.Errorf("second login signed in user %d, want %d", again.ID, user.ID)
+	}
+}
+
+func TestSSOLoginLinksVerifiedStaffEmail(t *testing.T) {
+	s, mock, db := newTestSSOService(t)
+	staff := models.User{Name: "Staff", Email: testEmail("sso-staff"), UserType: models.UserTypeAdmin}
+	applicant := models.User{Name: "Applicant", Email: testEmail("sso-applicant"), UserType: models.UserTypeApplicant}
+	for _, user := range []*models.User{&staff, &applicant} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("creating user: %v", err)
+		}
+	}
+
+	user, err := ssoLogin(t, s, mock, map[string]interface{}{"sub": staff.Email, "email": staff.Email, "email_verified": true})
+	if err != nil {
+		t.Fatalf("staff login: %v", err)
+	}
+	if user.ID != staff.ID {
+		t.Errorf("signed in user %d, want %d", user.ID, staff.ID)
+	}
+	var stored models.User
+	if err := db.First(&stored, staff.ID).Error; err != nil {
+		t.Fatalf("reloading staff: %v", err)
+	}
+	if stored.EmailVerifiedAt == nil {
+		t.Error("email_verified_at was not set by a login with a verified email")
+	}
+
+	_, err = ssoLogin(t, s, mock, map[string]interface{}{"sub": applicant.Email, "email": applicant.Email, "email_verified": true})
+	if !errors.Is(err, ErrNotStaff) {
+		t.Errorf("applicant login: got %v, want ErrNotStaff", err)
+	}
+}
+
+func TestSSOLoginRefusesUnverifiedEmail(t *testing.T) {
+	s, mock, db := newTestSSOService(t)
+	domain := testSSODomain()
+	createSSOOrganization(t, db, domain)
+
+	_, err := ssoLogin(t, s, mock, map[string]interface{}{"sub": "unverified-" + domain, "email": "someone@" + domain, "email_verified": false})
+	if !errors.Is(err, ErrSSOEmailNotVerified) {
+		t.Errorf("got %v, want ErrSSOEmailNotVerified", err)
+	}
+}
+
+func TestSSOLoginRefusesAmbiguousDomain(t *testing.T) {
+	s, mock, db := newTestSSOService(t)
+	domain := testSSODomain()
+	createSSOOrganization(t, db, domain)
+	// Bypass validation to recreate overlapping settings saved before domains were unique
+	overlap := models.Organization{
+		Name:     "overlap-" + domain,
+		Slug:     "overlap-" + domain,
+		Settings: models.OrganizationSettings{SSOProvisioningDomains: []string{domain}},
+	}
+	if err := db.Create(&overlap).Error; err != nil {
+		t.Fatalf("creating organization: %v", err)
+	}
+
+	_, err := ssoLogin(t, s, mock, map[string]interface{}{"sub": "ambiguous-" + domain, "email": "someone@" + domain, "email_verified": true})
+	if !errors.Is(err, ErrSSOAccountNotFound) {
+		t.Errorf("got %v, want ErrSSOAccountNotFound", err)
+	}
+}
+
+func TestSSOStateIsBoundToBrowserAndSingleUse(t *testing.T) {
+	s, mock, _ := newTestSSOService(t)
+	ctx := context.Background()
+	staff := testEmail("sso-state")
+	claims := map[string]interface{}{"sub": staff, "email": staff, "email_verified": true}
+
+	// An attacker starts a login and lures the victim into finishing it
+	attackerURL, _, err := s.BeginLogin(ctx)
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	_, victimState, err := s.BeginLogin(ctx)
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	code, state := mock.Authorize(t, attackerURL, claims)
+	if _, err := s.CompleteLogin(ctx, code, state, victimState); !errors.Is(err, ErrInvalidSSOState) {
+		t.Errorf("callback from another browser: got %v, want ErrInvalidSSOState", err)
+	}
+
+	authURL, browserState, err := s.BeginLogin(ctx)
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	code, state = mock.Authorize(t, authURL, claims)
+	// The account does not exist, which is fine: only the state matters here
+	s.CompleteLogin(ctx, code, state, browserState)
+	if _, err := s.CompleteLogin(ctx, code, state, browserState); !errors.Is(err, ErrInvalidSSOState) {
+		t.Errorf("replayed callback: got %v, want ErrInvalidSSOState", err)
+	}
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"synergylabs/util"
+
+	"gorm.io/gorm"
+)
+
+// Tenant scopes filter queries by the organization the request context is
+// scoped to. Only contexts marked with util.ContextWithAllOrganizations
+// (platform administrators and CLI commands) are left unfiltered; any other
+// context without an organization, such as an applicant's or a former
+// member's, matches nothing.
+
+// tenantScope filters a query with condition unless ctx sees every organization
+func tenantScope(ctx context.Context, condition string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if orgID, ok := util.OrganizationFromContext(ctx); ok {
+			return db.Where(condition, orgID)
+		}
+		if util.SeesAllOrganizations(ctx) {
+			return db
+		}
+		return db.Where("FALSE")
+	}
+}
+
+// jobTenantScope limits job queries to the caller's organization
+func jobTenantScope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return tenantScope(ctx, "jobs.organization_id = ?")
+}
+
+// applicantTenantScope limits user queries to applicants of the caller's organization's jobs
+func applicantTenantScope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return tenantScope(ctx, `users.id IN (
+		SELECT job_applications.user_id FROM job_applications
+		JOIN jobs ON jobs.id = job_applications.job_id
+		WHERE jobs.organization_id = ?)`)
+}
+
+// memberTenantScope limits user queries to members of the caller's organization
+func memberTenantScope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return tenantScope(ctx, "users.organization_id = ?")
+}
+
+// invitationTenantScope limits invitation queries to the caller's organization
+func invitationTenantScope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return tenantScope(ctx, "invitations.organization_id = ?")
+}
+
+// jobTemplateTenantScope limits job template queries to the caller's organization
+func jobTemplateTenantScope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return tenantScope(ctx, "job_templates.organization_id = ?")
+}
+
+// organizationTenantScope limits organization queries to the caller's own
+func organizationTenantScope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return tenantScope(ctx, "organizations.id = ?")
+}
+
+// hasTenantAccess reports whether the caller sees some organization's staff data
+func hasTenantAccess(ctx context.Context) bool {
+	_, ok := util.OrganizationFromContext(ctx)
+	return ok || util.SeesAllOrganizations(ctx)
+}
+
+// inTenant reports whether a record owned by orgID is visible to the caller
+func inTenant(ctx context.Context, orgID *uint) bool {
+	callerOrgID, ok := util.OrganizationFromContext(ctx)
+	if !ok {
+		return util.SeesAllOrganizations(ctx)
+	}
+	return orgID != nil && *orgID == callerOrgID
+}
+
+// tenantCacheKey namespaces a cache key by what the caller can see so cached
+// results are never shared across tenants
+func tenantCacheKey(ctx context.Context, key string) string {
+	if orgID, ok := util.OrganizationFromContext(ctx); ok {
+		return fmt.Sprintf("%s:org:%d", key, orgID)
+	}
+	if util.SeesAllOrganizations(ctx) {
+		return key + ":all"
+	}
+	return key + ":public"
+}
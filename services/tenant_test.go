@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"synergylabs/util"
+	"testing"
+)
+
+func TestTenantVisibility(t *testing.T) {
+	orgID, otherOrgID := uint(1), uint(2)
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		own       bool
+		other     bool
+		orgless   bool
+		access    bool
+		cacheTail string
+	}{
+		{"organization member", util.ContextWithOrganization(context.Background(), orgID), true, false, false, true, ":org:1"},
+		{"platform administrator", platformAdminContext(), true, true, true, true, ":all"},
+		// Applicants, former members and staff that were never given an organization
+		{"no organization", context.Background(), false, false, false, false, ":public"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inTenant(tt.ctx, &orgID); got != tt.own {
+				t.Errorf("inTenant(own organization) = %t, want %t", got, tt.own)
+			}
+			if got := inTenant(tt.ctx, &otherOrgID); got != tt.other {
+				t.Errorf("inTenant(other organization) = %t, want %t", got, tt.other)
+			}
+			if got := inTenant(tt.ctx, nil); got != tt.orgless {
+				t.Errorf("inTenant(no organization) = %t, want %t", got, tt.orgless)
+			}
+			if got := hasTenantAccess(tt.ctx); got != tt.access {
+				t.Errorf("hasTenantAccess = %t, want %t", got, tt.access)
+			}
+			if got := tenantCacheKey(tt.ctx, "jobs"); got != "jobs"+tt.cacheTail {
+				t.Errorf("tenantCacheKey = %s, want jobs%s", got, tt.cacheTail)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"synergylabs/models"
+	"synergylabs/services/cache"
+	"synergylabs/util"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type TokenService struct {
+	db     *gorm.DB
+	cache  *cache.Cache
+	logger *zap.Logger
+}
+
+var _ TokenServiceInterface = (*TokenService)(nil)
+var _ util.RevocationChecker = (*TokenService)(nil)
+
+func NewTokenService(db *gorm.DB, cache *cache.Cache, logger *zap.Logger) *TokenService {
+	return &TokenService{
+		db:     db,
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+// IssueTokens starts a new session, backed by a new refresh token family,
+// and returns its first token pair
+func (s *TokenService) IssueTokens(ctx context.Context, user *models.User, client ClientInfo) (*TokenPair, error) {
+	family, err := util.NewTokenID()
+	if err != nil {
+		s.logger.Error("Failed to generate token family", zap.Error(err))
+		return nil, err
+	}
+
+	pair, refreshClaims, err := s.generatePair(user, family)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := models.Session{
+		UserID:     user.ID,
+		Family:     family,
+		UserAgent:  truncate(client.UserAgent, maxUserAgentLength),
+		IP:         client.IP,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(util.RefreshTokenTTL),
+	}
+	if err := s.db.WithContext(ctx).Create(&session).Error; err != nil {
+		s.logger.Error("Failed to create session", zap.Error(err))
+		return nil, err
+	}
+
+	if err := s.cache.Set(ctx, familyCacheKey(family), refreshClaims.Id, util.RefreshTokenTTL); err != nil {
+		s.logger.Error("Failed to store refresh token family", zap.Error(err))
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// Refresh rotates a refresh token. Presenting a refresh token that has already been
+// rotated is treated as theft and revokes the whole family.
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string, client ClientInfo) (*TokenPair, error) {
+	claims, err := util.ValidateToken(refreshToken)
+	if err != nil || claims.TokenType != util.TokenTypeRefresh || claims.Family == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	revoked, err := s.isRevoked(ctx, claims)
+	if err != nil {
+		s.logger.Error("Failed to check token family revocation", zap.Error(err))
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	var currentID string
+	if err := s.cache.Get(ctx, familyCacheKey(claims.Family), &currentID); err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		s.logger.Error("Failed to load refresh token family", zap.Error(err))
+		return nil, err
+	}
+
+	if currentID != claims.Id {
+		s.logger.Warn("Refresh token reuse detected, revoking family",
+			zap.Uint("user_id", claims.UserId),
+			zap.String("family", claims.Family),
+		)
+		if err := s.RevokeFamily(ctx, claims.Family); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	var user models.User
+	if err := s.db.WithContext(ctx).First(&user, claims.UserId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.RevokeFamily(ctx, claims.Family)
+			return nil, ErrInvalidRefreshToken
+		}
+		s.logger.Error("Failed to load user for token refresh", zap.Error(err))
+		return nil, err
+	}
+
+	// Sessions terminated while Redis lost their revocation still end here
+	var session models.Session
+	err = s.db.WithContext(ctx).Where("family = ?", claims.Family).First(&session).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Error("Failed to load session for token refresh", zap.Error(err))
+		return nil, err
+	}
+	if err == nil && session.RevokedAt != nil {
+		s.RevokeFamily(ctx, claims.Family)
+		return nil, ErrInvalidRefreshToken
+	}
+
+	pair, refreshClaims, err := s.generatePair(&user, claims.Family)
+	if err != nil {
+		return nil, err
+	}
+
+	swapped, err := s.cache.CompareAndSwap(ctx, familyCacheKey(claims.Family), claims.Id, refreshClaims.Id, util.RefreshTokenTTL)
+	if err != nil {
+		s.logger.Error("Failed to rotate refresh token", zap.Error(err))
+		return nil, err
+	}
+	if !swapped {
+		// Another request rotated the same token first
+		s.logger.Warn("Concurrent refresh token reuse detected, revoking family",
+			zap.Uint("user_id", claims.UserId),
+			zap.String("family", claims.Family),
+		)
+		if err := s.RevokeFamily(ctx, claims.Family); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	if err := s.db.WithContext(ctx).Model(&models.Session{}).
+		Where("family = ?", claims.Family).
+		Updates(map[string]interface{}{
+			"ip":           client.IP,
+			"last_seen_at": now,
+			"expires_at":   now.Add(util.RefreshTokenTTL),
+		}).Error; err != nil {
+		s.logger.Warn("Failed to update session", zap.Error(err))
+	}
+
+	return pair, nil
+}
+
+// Revoke invalidates a single access token until it would have expired anyway
+func (s *TokenService) Revoke(ctx context.Context, claims *util.Claims) error {
+	ttl := time.Until(time.Unix(claims.ExpiresAt, 0))
+	if ttl <= 0 {
+		return nil
+	}
+	if err := s.cache.Set(ctx, revokedTokenCacheKey(claims.Id), true, ttl); err != nil {
+		s.logger.Error("Failed to revoke token", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// RevokeFamily invalidates a refresh token family and every access token issued from it
+func (s *TokenService) RevokeFamily(ctx context.Context, family string) error {
+	if err := s.cache.Set(ctx, revokedFamilyCacheKey(family), true, util.RefreshTokenTTL); err != nil {
+		s.logger.Error("Failed to revoke token family", zap.Error(err))
+		return err
+	}
+	s.cache.Delete(ctx, familyCacheKey(family))
+	return nil
+}
+
+// Logout revokes the presented access token together with its refresh token family
+func (s *TokenService) Logout(ctx context.Context, claims *util.Claims) error {
+	if err := s.Revoke(ctx, claims); err != nil {
+		return err
+	}
+	if claims.Family != "" {
+		if err := s.endSession(ctx, claims.Family); err != nil {
+			return err
+		}
+	}
+	s.logger.Info("User logged out", zap.Uint("user_id", claims.UserId))
+	return nil
+}
+
+// IsRevoked implements util.RevocationChecker. Tokens that are still valid
+// mark their session as seen.
+func (s *TokenService) IsRevoked(ctx context.Context, claims *util.Claims) (bool, error) {
+	revoked, err := s.isRevoked(ctx, claims)
+	if err != nil || revoked {
+		return revoked, err
+	}
+	if claims.Family != "" {
+		s.touchSession(ctx, claims.Family)
+	}
+	return false, nil
+}
+
+// isRevoked checks the token, its family and the user's sign-out-everywhere cutoff
+func (s *TokenService) isRevoked(ctx context.Context, claims *util.Claims) (bool, error) {
+	revoked, err := s.cache.Exists(ctx, revokedTokenCacheKey(claims.Id))
+	if err != nil || revoked {
+		return revoked, err
+	}
+
+	var cutoff revocationCutoff
+	err = s.cache.Get(ctx, revokedUserCacheKey(claims.UserId), &cutoff)
+	if err != nil && !errors.Is(err, cache.ErrNotFound) {
+		return false, err
+	}
+	if err == nil && cutoff.revokes(claims) {
+		return true, nil
+	}
+
+	if claims.Family == "" {
+		return false, nil
+	}
+	return s.cache.Exists(ctx, revokedFamilyCacheKey(claims.Family))
+}
+
+func (s *TokenService) generatePair(user *models.User, family string) (*TokenPair, *util.Claims, error) {
+	accessToken, err := util.GenerateToken(user.ID, string(user.UserType),
+		util.WithFamily(family),
+		util.WithOrganizationID(user.OrganizationID),
+	)
+	if err != nil {
+		s.logger.Error("Failed to generate access token", zap.Error(err))
+		return nil, nil, err
+	}
+
+	refreshToken, refreshClaims, err := util.GenerateRefreshToken(user.ID, string(user.UserType), family)
+	if err != nil {
+		s.logger.Error("Failed to generate refresh token", zap.Error(err))
+		return nil, nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(util.AccessTokenTTL.Seconds()),
+	}, refreshClaims, nil
+}
+
+func familyCacheKey(family string) string {
+	return fmt.Sprintf("%s:%s", RefreshFamilyCacheKey, family)
+}
+
+func revokedFamilyCacheKey(family string) string {
+	return fmt.Sprintf("%s:%s", RevokedFamilyCacheKey, family)
+}
+
+func revokedTokenCacheKey(jti string) string {
+	return fmt.Sprintf("%s:%s", RevokedTokenCacheKey, jti)
+}
+
+func revokedUserCacheKey(userID uint) string {
+	return fmt.Sprintf("%s:%d", RevokedUserCacheKey, userID)
+}
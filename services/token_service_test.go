@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"synergylabs/models"
+	"synergylabs/util"
+	"testing"
+)
+
+func newTestTokenService(t *testing.T) (*TokenService, *models.User) {
+	t.Helper()
+	db := testDB(t)
+	testKeyring(t)
+	s := NewTokenService(db, testCache(t), testLogger())
+
+	user := &models.User{Name: "Tokens", Email: testEmail("tokens"), UserType: models.UserTypeApplicant}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	return s, user
+}
+
+// assertRevoked fails unless the token is rejected by IsRevoked
+func assertRevoked(t *testing.T, s *TokenService, name, token string) {
+	t.Helper()
+	claims, err := util.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	revoked, err := s.IsRevoked(context.Background(), claims)
+	if err != nil {
+		t.Fatalf("%s: IsRevoked: %v", name, err)
+	}
+	if !revoked {
+		t.Errorf("%s is still valid", name)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	s, user := newTestTokenService(t)
+	ctx := context.Background()
+
+	issued, err := s.IssueTokens(ctx, user, ClientInfo{})
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+	rotated, err := s.Refresh(ctx, issued.RefreshToken, ClientInfo{})
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if rotated.RefreshToken == issued.RefreshToken {
+		t.Fatal("Refresh returned the same refresh token")
+	}
+
+	// Presenting the rotated-out token again is treated as theft
+	if _, err := s.Refresh(ctx, issued.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("reused refresh token: error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+	if _, err := s.Refresh(ctx, rotated.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("latest refresh token after reuse: error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+	assertRevoked(t, s, "access token issued by the rotation", rotated.AccessToken)
+}
+
+func TestConcurrentRefreshRotatesOnce(t *testing.T) {
+	s, user := newTestTokenService(t)
+	ctx := context.Background()
+
+	issued, err := s.IssueTokens(ctx, user, ClientInfo{})
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+
+	const racers = 2
+	pairs := make([]*TokenPair, racers)
+	errs := make([]error, racers)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range racers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			pairs[i], errs[i] = s.Refresh(ctx, issued.RefreshToken, ClientInfo{})
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	var winner *TokenPair
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			if winner != nil {
+				t.Fatal("the same refresh token was rotated twice")
+			}
+			winner = pairs[i]
+		case !errors.Is(err, ErrInvalidRefreshToken):
+			t.Fatalf("Refresh: %v", err)
+		}
+	}
+	if winner == nil {
+		t.Fatal("no rotation succeeded")
+	}
+
+	// The loser presented a token that was already rotated, so the family is dead
+	if _, err := s.Refresh(ctx, winner.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("winning refresh token after the race: error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
+
+func TestLogoutRevokesFamily(t *testing.T) {
+	s, user := newTestTokenService(t)
+	ctx := context.Background()
+
+	issued, err := s.IssueTokens(ctx, user, ClientInfo{})
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+	claims, err := util.ValidateToken(issued.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	if err := s.Logout(ctx, claims); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+
+	assertRevoked(t, s, "access token", issued.AccessToken)
+	if _, err := s.Refresh(ctx, issued.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh after logout: error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
@@ -1,23 +1,269 @@
 package services
 
 import (
+	"encoding/json"
+	"synergylabs/models"
 	"time"
 )
 
+// PaginatedResponse is one page of a listing. Total and TotalPages are left out
+// when the count was skipped, and Page when the page was read with a cursor.
 type PaginatedResponse struct {
 	Data       interface{} `json:"data"`
-	Total      int64       `json:"total"`
-	Page       int         `json:"page"`
+	Total      *int64      `json:"total,omitempty"`
+	Page       int         `json:"page,omitempty"`
 	PageSize   int         `json:"page_size"`
-	TotalPages int         `json:"total_pages"`
+	TotalPages *int        `json:"total_pages,omitempty"`
+	HasNext    bool        `json:"has_next"`
+	HasPrev    bool        `json:"has_prev"`
+	// NextCursor and PrevCursor continue the listing from either end of this page
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	// Suggestions are similar job titles offered when a search finds nothing
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// DefaultPageSize is the page size of listings that do not ask for one
+const DefaultPageSize = 20
+
+// PageRequest selects a page of a listing, either by number or with a cursor
+// from a previous response
+type PageRequest struct {
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+	Cursor   string `json:"cursor"`
+	// SkipCount leaves the total out of pages read with a cursor, saving a
+	// count of every matching row. Pages by number are always counted.
+	SkipCount bool `json:"skip_count"`
+}
+
+// newPaginatedResponse fills in the page metadata for one page of results
+func newPaginatedResponse(data interface{}, total int64, page, pageSize int) PaginatedResponse {
+	totalPages := 0
+	if pageSize > 0 {
+		totalPages = int((total + int64(pageSize) - 1) / int64(pageSize))
+	}
+	return PaginatedResponse{
+		Data:       data,
+		Total:      &total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: &totalPages,
+		HasNext:    page < totalPages,
+		HasPrev:    page > 1,
+	}
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// ImpersonationToken is a read-only access token for acting as another user
+type ImpersonationToken struct {
+	AccessToken string `json:"token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	UserID      uint   `json:"impersonated_user_id"`
+}
+
+// ClientInfo describes the device a session is created or used from
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+type SessionView struct {
+	models.Session
+	Current bool `json:"current"`
+}
+
+type InvitationView struct {
+	models.Invitation
+	Status models.InvitationStatus `json:"status"`
+}
+
+// JobTemplateView is a job template with the placeholder variables it uses
+type JobTemplateView struct {
+	models.JobTemplate
+	Variables []string `json:"variables"`
+}
+
+// JobImportOptions controls how ImportJobs applies a file
+type JobImportOptions struct {
+	Format JobDataFormat
+	// PosterID is recorded as the poster of the jobs the import creates
+	PosterID uint
+	// DryRun validates every row against the database without saving anything
+	DryRun bool
+	// AllOrNothing saves nothing if any row fails
+	AllOrNothing bool
+}
+
+// JobImportReport describes the outcome of an import, row by row for failures
+type JobImportReport struct {
+	DryRun       bool             `json:"dry_run"`
+	AllOrNothing bool             `json:"all_or_nothing"`
+	Committed    bool             `json:"committed"`
+	Rows         int              `json:"rows"`
+	Created      int              `json:"created"`
+	Updated      int              `json:"updated"`
+	Failed       int              `json:"failed"`
+	Errors       []JobImportError `json:"errors"`
+}
+
+// JobImportError is a row that could not be imported. Line is where the row
+// starts in the file, counting the CSV header.
+type JobImportError struct {
+	Line        int    `json:"line"`
+	ExternalRef string `json:"external_ref,omitempty"`
+	Error       string `json:"error"`
+}
+
+// ApplicationAnswer is an applicant's answer to a screening question, in the
+// JSON type the question's type calls for
+type ApplicationAnswer struct {
+	QuestionID uint            `json:"question_id"`
+	Answer     json.RawMessage `json:"answer"`
+}
+
+// PublicJob is what anyone may see of a published job: its content, but not
+// who posted or manages it
+type PublicJob struct {
+	ID              uint                  `json:"id"`
+	URL             string                `json:"url"`
+	Title           string                `json:"title"`
+	Description     string                `json:"description"`
+	CompanyName     string                `json:"company_name"`
+	DatePosted      time.Time             `json:"date_posted"`
+	UpdatedAt       time.Time             `json:"updated_at"`
+	ClosesAt        *time.Time            `json:"closes_at,omitempty"`
+	LocationCity    string                `json:"location_city,omitempty"`
+	LocationCountry string                `json:"location_country,omitempty"`
+	Latitude        *float64              `json:"latitude,omitempty"`
+	Longitude       *float64              `json:"longitude,omitempty"`
+	RemotePolicy    models.RemotePolicy   `json:"remote_policy,omitempty"`
+	EmploymentType  models.EmploymentType `json:"employment_type,omitempty"`
+	Seniority       models.Seniority      `json:"seniority,omitempty"`
+	Department      string                `json:"department,omitempty"`
+	SalaryMin       *int64                `json:"salary_min,omitempty"`
+	SalaryMax       *int64                `json:"salary_max,omitempty"`
+	SalaryCurrency  string                `json:"salary_currency,omitempty"`
+	SalaryPeriod    models.SalaryPeriod   `json:"salary_period,omitempty"`
+}
+
+// PublicDocument is a rendered public job page or feed as cached in Redis.
+// LastModified is when the job, or the newest job of a feed, last changed.
+type PublicDocument struct {
+	ContentType  string    `json:"content_type"`
+	Body         []byte    `json:"body"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type AuditFilters struct {
+	ActorID    uint      `json:"actor_id"`
+	Action     string    `json:"action"`
+	EntityType string    `json:"entity_type"`
+	EntityID   string    `json:"entity_id"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	Page       int       `json:"page"`
+	PageSize   int       `json:"page_size"`
+}
+
+// AuditVerification is the result of checking the audit hash chains
+type AuditVerification struct {
+	Valid   bool `json:"valid"`
+	Checked int  `json:"checked"`
+	Chains  int  `json:"chains"`
+	// Skipped counts events from before the audit log was hash-chained
+	Skipped     int64  `json:"skipped"`
+	BrokenAtID  uint   `json:"broken_at_id,omitempty"`
+	BrokenChain string `json:"broken_chain,omitempty"`
+}
+
+// AccountUpdate holds the account fields a user can change themselves; nil fields are left as they are
+type AccountUpdate struct {
+	Name            *string `json:"name"`
+	Address         *string `json:"address"`
+	ProfileHeadline *string `json:"profile_headline"`
+}
+
+// ProfileUpdate corrects fields parsed from an applicant's resume; nil fields are left as they are
+type ProfileUpdate struct {
+	Name       *string `json:"name"`
+	Email      *string `json:"email"`
+	Phone      *string `json:"phone"`
+	Skills     *string `json:"skills"`
+	Education  *string `json:"education"`
+	Experience *string `json:"experience"`
+}
+
+// JobPrecondition restricts a job write to the job's poster or hiring
+// manager, and to the version the caller last read. Zero values skip the check.
+type JobPrecondition struct {
+	OwnerID uint
+	Version uint
 }
 
 type JobFilters struct {
+	// Status is empty for jobs open to applications, a models.JobStatus, or JobStatusAll
+	Status string `json:"status"`
+	// Query is a full-text search in web search syntax: quoted phrases, OR and -word
+	Query       string    `json:"query"`
 	Title       string    `json:"title"`
 	CompanyName string    `json:"company_name"`
 	PostedAfter time.Time `json:"posted_after"`
-	Page        int       `json:"page"`
-	PageSize    int       `json:"page_size"`
+
+	City            string                  `json:"city"`
+	Country         string                  `json:"country"`
+	RemotePolicies  []models.RemotePolicy   `json:"remote_policies"`
+	EmploymentTypes []models.EmploymentType `json:"employment_types"`
+	Seniorities     []models.Seniority      `json:"seniorities"`
+	Department      string                  `json:"department"`
+
+	// SalaryMin and SalaryMax match jobs whose salary range overlaps them.
+	// Salaries are only comparable within a currency and period, so both are required with them.
+	SalaryMin      *int64              `json:"salary_min"`
+	SalaryMax      *int64              `json:"salary_max"`
+	SalaryCurrency string              `json:"salary_currency"`
+	SalaryPeriod   models.SalaryPeriod `json:"salary_period"`
+
+	// Near and RadiusKm match jobs with coordinates within RadiusKm of Near
+	Near     *GeoPoint `json:"near"`
+	RadiusKm float64   `json:"radius_km"`
+
+	// Sort is one of JobSortFields, prefixed with "-" for descending order
+	Sort string `json:"sort"`
+	PageRequest
+}
+
+// JobSearchResult is a job matched by a full-text search
+type JobSearchResult struct {
+	models.Job
+	Rank       float64       `json:"rank"`
+	Highlights JobHighlights `json:"highlights"`
+}
+
+// JobHighlights are HTML excerpts of a job with the search terms wrapped in
+// <mark> and </mark>; everything else in them is escaped
+type JobHighlights struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// GeoPoint is a WGS 84 coordinate in degrees
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
 }
 
 type CacheKey string
@@ -25,4 +271,32 @@ type CacheKey string
 const (
 	JobsCacheKey       CacheKey = "jobs"
 	ApplicantsCacheKey CacheKey = "applicants"
+
+	// JobsGenerationCacheKey counts changes to jobs; job listings are cached under the current count
+	JobsGenerationCacheKey CacheKey = "jobs_generation"
+
+	RefreshFamilyCacheKey CacheKey = "refresh_family"
+	RevokedFamilyCacheKey CacheKey = "revoked_family"
+	RevokedTokenCacheKey  CacheKey = "revoked_token"
+	RevokedUserCacheKey   CacheKey = "revoked_user"
+	SessionSeenCacheKey   CacheKey = "session_seen"
+
+	MFAAttemptsCacheKey     CacheKey = "mfa_attempts"
+	MFAUserAttemptsCacheKey CacheKey = "mfa_user_attempts"
+	MFAUsedCodeCacheKey     CacheKey = "mfa_used_code"
+
+	LoginFailuresCacheKey CacheKey = "login_failures"
+	LoginLockCacheKey     CacheKey = "login_lock"
+
+	PermissionsCacheKey CacheKey = "user_permissions"
+
+	SSOStateCacheKey CacheKey = "sso_state"
+
+	// PublicJobsCacheKey holds rendered public job documents, per job listing generation
+	PublicJobsCacheKey CacheKey = "public_jobs"
+
+	// SchedulerLockCacheKey is held by the instance running a task, and
+	// SchedulerSlotCacheKey by the instance that claimed one scheduled run
+	SchedulerLockCacheKey CacheKey = "scheduler_lock"
+	SchedulerSlotCacheKey CacheKey = "scheduler_slot"
 )
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"synergylabs/models"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// GetAccount returns the user's own account with its roles and, for applicants, the parsed profile
+func (s *UserService) GetAccount(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := s.db.WithContext(ctx).Preload("Roles").Preload("Profile").First(&user, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		s.logger.Error("Failed to fetch account", zap.Error(err))
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateAccount changes the account fields a user manages themselves
+func (s *UserService) UpdateAccount(ctx context.Context, id uint, update AccountUpdate) (*models.User, error) {
+	var user models.User
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		existing := user
+
+		if update.Name != nil {
+			user.Name = *update.Name
+		}
+		if update.Address != nil {
+			user.Address = *update.Address
+		}
+		if update.ProfileHeadline != nil {
+			user.ProfileHeadline = *update.ProfileHeadline
+		}
+
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditActionUserUpdate, "user", user.ID, &existing, &user)
+	})
+	if err != nil {
+		if !errors.Is(err, ErrUserNotFound) {
+			s.logger.Error("Failed to update account", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	s.cache.Delete(ctx, string(ApplicantsCacheKey))
+	s.logger.Info("Account updated", zap.Uint("user_id", id))
+	return &user, nil
+}
+
+// UpdateProfile corrects the profile parsed from the applicant's resume
+func (s *UserService) UpdateProfile(ctx context.Context, id uint, update ProfileUpdate) (*models.Profile, error) {
+	var profile models.Profile
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("applicant_id = ?", id).First(&profile).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProfileNotFound
+			}
+			return err
+		}
+		existing := profile
+
+		if update.Name != nil {
+			profile.Name = *update.Name
+		}
+		if update.Email != nil {
+			profile.Email = *update.Email
+		}
+		if update.Phone != nil {
+			profile.Phone = *update.Phone
+		}
+		if update.Skills != nil {
+			profile.Skills = *update.Skills
+		}
+		if update.Education != nil {
+			profile.Education = *update.Education
+		}
+		if update.Experience != nil {
+			profile.Experience = *update.Experience
+		}
+
+		if err := tx.Save(&profile).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditActionProfileUpdate, "profile", profile.ID, &existing, &profile)
+	})
+	if err != nil {
+		if !errors.Is(err, ErrProfileNotFound) {
+			s.logger.Error("Failed to update profile", zap.Error(err))
+		}
+		return nil, err
+	}
+
+	s.cache.Delete(ctx, string(ApplicantsCacheKey))
+	s.logger.Info("Profile updated", zap.Uint("user_id", id))
+	return &profile, nil
+}
+
+// ChangePassword replaces the password after checking the current one. Wrong
+// guesses count towards the same lockout as failed logins.
+func (s *UserService) ChangePassword(ctx context.Context, id uint, currentPassword, newPassword, ip string) error {
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+
+	user, err := s.GetUser(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if err := s.checkPassword(ctx, user, currentPassword, ip); err != nil {
+		return err
+	}
+
+	hashedPassword, err := hashPassword(newPassword)
+	if err != nil {
+		s.logger.Error("Failed to hash password", zap.Error(err))
+		return err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", id).Update("password_hash", hashedPassword).Error; err != nil {
+			return err
+		}
+		return appendAuditEvent(ctx, tx, userAuditEvent(models.AuditActionPasswordChange, id))
+	})
+	if err != nil {
+		s.logger.Error("Failed to change password", zap.Error(err))
+		return err
+	}
+
+	securityEvent(s.logger, "password_changed", zap.Uint("user_id", id))
+	return nil
+}
+
+// CloseAccount deletes the user's account. Personal data is cleared from the
+// row so the email can be registered again; the audit log keeps the user's ID.
+// Accounts without a password (SSO only) are closed without one.
+func (s *UserService) CloseAccount(ctx context.Context, id uint, password, ip string) error {
+	user, err := s.GetUser(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if user.PasswordHash != "" {
+		if err := s.checkPassword(ctx, user, password, ip); err != nil {
+			return err
+		}
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkNotLastSuperAdmin(tx, id); err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM user_roles WHERE user_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.UserIdentity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("applicant_id = ?", id).Delete(&models.Profile{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.APIKey{}).
+			Where("user_id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"name":              "",
+			"email":             fmt.Sprintf("closed-%d@invalid", id),
+			"address":           "",
+			"profile_headline":  "",
+			"password_hash":     "",
+			"totp_secret":       "",
+			"mfa_enabled_at":    nil,
+			"email_verified_at": nil,
+			"organization_id":   nil,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.User{}, id).Error; err != nil {
+			return err
+		}
+		return appendAuditEvent(ctx, tx, userAuditEvent(models.AuditActionAccountClose, id))
+	})
+	if err != nil {
+		if !errors.Is(err, ErrLastSuperAdmin) {
+			s.logger.Error("Failed to close account", zap.Error(err))
+		}
+		return err
+	}
+
+	s.cache.Delete(ctx, string(ApplicantsCacheKey))
+	s.cache.Delete(ctx, permissionsCacheKey(id))
+	s.logger.Info("Account closed", zap.Uint("user_id", id))
+	return nil
+}
+
+// checkPassword verifies a password for an already authenticated user
+func (s *UserService) checkPassword(ctx context.Context, user *models.User, password, ip string) error {
+	if user.PasswordHash == "" {
+		return ErrNoPassword
+	}
+	subject := normalizeEmail(user.Email)
+	if err := s.checkLoginLockout(ctx, subject, ip); err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		s.recordLoginFailure(ctx, subject, ip)
+		return ErrInvalidCredentials
+	}
+	if err := s.clearLoginFailures(ctx, subject); err != nil {
+		s.logger.Warn("Failed to reset login failures", zap.Error(err))
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"synergylabs/models"
 	"synergylabs/services/cache"
 	"time"
@@ -11,12 +12,15 @@ import (
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserService struct {
 	db     *gorm.DB
 	cache  *cache.Cache
 	logger *zap.Logger
+
+	requireVerifiedEmail bool
 }
 
 var _ UserServiceInterface = (*UserService)(nil)
@@ -29,7 +33,17 @@ func NewUserService(db *gorm.DB, cache *cache.Cache, logger *zap.Logger) *UserSe
 	}
 }
 
+// RequireVerifiedEmail makes ValidateLogin refuse accounts whose email has not been verified
+func (s *UserService) RequireVerifiedEmail(required bool) {
+	s.requireVerifiedEmail = required
+}
+
 func (s *UserService) CreateUser(ctx context.Context, user *models.User) error {
+	if err := validatePassword(user.PasswordHash); err != nil {
+		return err
+	}
+	user.Email = normalizeEmail(user.Email)
+
 	// Start transaction
 	tx := s.db.WithContext(ctx).Begin()
 	if tx.Error != nil {
@@ -38,24 +52,37 @@ func (s *UserService) CreateUser(ctx context.Context, user *models.User) error {
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.PasswordHash), bcrypt.DefaultCost)
+	hashedPassword, err := hashPassword(user.PasswordHash)
 	if err != nil {
 		tx.Rollback()
 		s.logger.Error("Failed to hash password", zap.Error(err))
 		return err
 	}
-	user.PasswordHash = string(hashedPassword)
+	user.PasswordHash = hashedPassword
 
 	// Create user
 	if err := tx.Create(user).Error; err != nil {
 		tx.Rollback()
 		s.logger.Error("Failed to create user", zap.Error(err))
 		if errors.Is(err, gorm.ErrDuplicatedKey) {
-			return errors.New("email already exists")
+			return ErrEmailExists
 		}
 		return err
 	}
 
+	// Assign the role matching the account type
+	if err := assignRoleByName(tx, user, models.DefaultRoleFor(user.UserType)); err != nil {
+		tx.Rollback()
+		s.logger.Error("Failed to assign default role", zap.Error(err))
+		return err
+	}
+
+	if err := recordAudit(ctx, tx, models.AuditActionUserCreate, "user", user.ID, nil, user); err != nil {
+		tx.Rollback()
+		s.logger.Error("Failed to audit user creation", zap.Error(err))
+		return err
+	}
+
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
 		s.logger.Error("Failed to commit transaction", zap.Error(err))
@@ -70,9 +97,9 @@ func (s *UserService) CreateUser(ctx context.Context, user *models.User) error {
 	return nil
 }
 
-func (s *UserService) GetAllApplicants(ctx context.Context, page, pageSize int) (*PaginatedResponse, error) {
+func (s *UserService) GetAllApplicants(ctx context.Context, req PageRequest) (*PaginatedResponse, error) {
 
-	cacheKey := fmt.Sprintf("%s:%d:%d", ApplicantsCacheKey, page, pageSize)
+	cacheKey := tenantCacheKey(ctx, fmt.Sprintf("%s:%d:%d:%t:%s", ApplicantsCacheKey, req.Page, req.PageSize, req.SkipCount, req.Cursor))
 	var response PaginatedResponse
 
 	// Try to get from cache
@@ -81,34 +108,24 @@ func (s *UserService) GetAllApplicants(ctx context.Context, page, pageSize int)
 		return &response, nil
 	}
 
-	// Get total count
-	var total int64
-	if err := s.db.Model(&models.User{}).
-		Where("user_type = ?", models.UserTypeApplicant).
-		Count(&total).Error; err != nil {
-		s.logger.Error("Failed to count applicants", zap.Error(err))
-		return nil, err
+	// Applicants are listed in sign-up order, which an ID cursor can resume
+	query := s.db.WithContext(ctx).Model(&models.User{}).
+		Scopes(applicantTenantScope(ctx)).
+		Where("user_type = ?", models.UserTypeApplicant)
+	keys := &keyset[models.User]{
+		idColumn: "users.id",
+		idOf:     func(user *models.User) uint { return user.ID },
 	}
 
-	// Get paginated applicants
-	var applicants []models.User
-	if err := s.db.WithContext(ctx).
-		Where("user_type = ?", models.UserTypeApplicant).
-		Preload("Profile"). // Eager load profiles
-		Offset((page - 1) * pageSize).
-		Limit(pageSize).
-		Find(&applicants).Error; err != nil {
-		s.logger.Error("Failed to fetch applicants", zap.Error(err))
+	applicants, response, err := paginate(query, req, clause.OrderBy{}, keys, string(ApplicantsCacheKey),
+		func(db *gorm.DB) *gorm.DB { return db.Preload("Profile") }) // Eager load profiles
+	if err != nil {
+		if !errors.Is(err, ErrInvalidCursor) {
+			s.logger.Error("Failed to fetch applicants", zap.Error(err))
+		}
 		return nil, err
 	}
-
-	response = PaginatedResponse{
-		Data:       applicants,
-		Total:      total,
-		Page:       page,
-		PageSize:   pageSize,
-		TotalPages: int(total)/pageSize + 1,
-	}
+	response.Data = applicants
 
 	// Cache the response
 	if err := s.cache.Set(ctx, cacheKey, response, 5*time.Minute); err != nil {
@@ -118,24 +135,80 @@ func (s *UserService) GetAllApplicants(ctx context.Context, page, pageSize int)
 	return &response, nil
 }
 
-func (s *UserService) ValidateLogin(ctx context.Context, email, password string) (*models.User, error) {
+// ValidateLogin checks credentials while enforcing per-account and per-IP lockouts.
+// Unknown emails and wrong passwords fail identically, in time as well as in the error returned.
+func (s *UserService) ValidateLogin(ctx context.Context, email, password, ip string) (*models.User, error) {
+	subject := normalizeEmail(email)
+	if err := s.checkLoginLockout(ctx, subject, ip); err != nil {
+		return nil, err
+	}
+
 	var user models.User
-	if err := s.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
-		s.logger.Error("User not found", zap.Error(err))
+	err := s.db.WithContext(ctx).Scopes(emailScope(email)).First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.Error("Failed to fetch user for login", zap.Error(err))
 		return nil, err
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		s.logger.Error("Invalid password", zap.Error(err))
-		return nil, errors.New("invalid password")
+	passwordHash := dummyPasswordHash
+	if err == nil {
+		passwordHash = []byte(user.PasswordHash)
+	}
+	if bcrypt.CompareHashAndPassword(passwordHash, []byte(password)) != nil || err != nil {
+		s.recordLoginFailure(ctx, subject, ip)
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := s.clearLoginFailures(ctx, subject); err != nil {
+		s.logger.Warn("Failed to reset login failures", zap.Error(err))
+	}
+
+	if s.requireVerifiedEmail && user.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
 	}
 
 	return &user, nil
 }
 
+// UnlockAccount lifts a lockout and resets the account's failed attempts
+func (s *UserService) UnlockAccount(ctx context.Context, id uint) error {
+	var user models.User
+	if err := s.db.WithContext(ctx).Scopes(memberTenantScope(ctx)).First(&user, id).Error; err != nil {
+		s.logger.Error("Failed to fetch user", zap.Error(err))
+		return err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := recordAudit(ctx, tx, models.AuditActionUserUnlock, "user", user.ID, nil, nil); err != nil {
+			return err
+		}
+		return s.clearLoginFailures(ctx, normalizeEmail(user.Email))
+	})
+	if err != nil {
+		s.logger.Error("Failed to unlock account", zap.Error(err))
+		return err
+	}
+
+	securityEvent(s.logger, "account_unlocked", zap.Uint("user_id", user.ID))
+	return nil
+}
+
+func (s *UserService) GetUser(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := s.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		s.logger.Error("Failed to fetch user", zap.Error(err))
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (s *UserService) GetApplicantWithProfile(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	if err := s.db.WithContext(ctx).Preload("Profile").First(&user, id).Error; err != nil {
+	err := s.db.WithContext(ctx).Scopes(applicantTenantScope(ctx)).
+		Where("users.user_type = ?", models.UserTypeApplicant).
+		Preload("Profile").
+		First(&user, id).Error
+	if err != nil {
 		s.logger.Error("Failed to fetch applicant with profile", zap.Error(err))
 		return nil, err
 	}
@@ -143,7 +216,17 @@ func (s *UserService) GetApplicantWithProfile(ctx context.Context, id uint) (*mo
 }
 
 func (s *UserService) UpdateUser(ctx context.Context, user *models.User) error {
-	if err := s.db.WithContext(ctx).Save(user).Error; err != nil {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing models.User
+		if err := tx.First(&existing, user.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditActionUserUpdate, "user", user.ID, &existing, user)
+	})
+	if err != nil {
 		s.logger.Error("Failed to update user", zap.Error(err))
 		return err
 	}
@@ -152,10 +235,52 @@ func (s *UserService) UpdateUser(ctx context.Context, user *models.User) error {
 }
 
 func (s *UserService) DeleteUser(ctx context.Context, id uint) error {
-	if err := s.db.WithContext(ctx).Delete(&models.User{}, id).Error; err != nil {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&user).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, models.AuditActionUserDelete, "user", user.ID, &user, nil)
+	})
+	if err != nil {
 		s.logger.Error("Failed to delete user", zap.Error(err))
 		return err
 	}
 	s.logger.Info("User deleted successfully", zap.Uint("user_id", id))
 	return nil
 }
+
+// normalizeEmail is the form emails are stored, compared and counted in
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// emailScope finds the user with email, whatever its case. It matches the
+// unique index on LOWER(email).
+func emailScope(email string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("LOWER(users.email) = ?", normalizeEmail(email))
+	}
+}
+
+// minPasswordLength is the shortest password any account may be given
+const minPasswordLength = 8
+
+// validatePassword checks a password before it is set, wherever it is set
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("%w: use at least %d characters", ErrPasswordTooShort, minPasswordLength)
+	}
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
@@ -0,0 +1,85 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"synergylabs/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// APIKeyPrefix starts every API key so it can be told apart from a JWT
+const APIKeyPrefix = "sl_"
+
+// ErrInvalidAPIKey is returned by an APIKeyAuthenticator for a key that does
+// not authenticate anyone. Any other error means the key could not be checked.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// APIKeyPrincipal is the identity an API key authenticates as
+type APIKeyPrincipal struct {
+	KeyID          uint
+	UserID         uint
+	UserType       string
+	OrganizationID *uint
+	Scopes         []models.Permission
+}
+
+// APIKeyAuthenticator resolves an API key to the principal it acts for
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*APIKeyPrincipal, error)
+}
+
+var apiKeyAuthenticator APIKeyAuthenticator
+
+// SetAPIKeyAuthenticator enables API key authentication in AuthMiddleware
+func SetAPIKeyAuthenticator(authenticator APIKeyAuthenticator) {
+	apiKeyAuthenticator = authenticator
+}
+
+// apiKeyFromRequest returns the API key sent in X-API-Key or as a bearer token
+func apiKeyFromRequest(r *http.Request) (string, bool) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key, true
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer"))
+	if strings.HasPrefix(token, APIKeyPrefix) {
+		return token, true
+	}
+	return "", false
+}
+
+func authenticateAPIKey(ctx context.Context, key string) (*APIKeyPrincipal, error) {
+	if apiKeyAuthenticator == nil {
+		return nil, ErrInvalidAPIKey
+	}
+	return apiKeyAuthenticator.AuthenticateAPIKey(ctx, key)
+}
+
+func setAPIKeyPrincipal(c echo.Context, principal *APIKeyPrincipal) error {
+	var orgID uint
+	if principal.OrganizationID != nil {
+		orgID = *principal.OrganizationID
+	}
+	if err := scopeTenant(c, principal.UserID, principal.UserType, orgID); err != nil {
+		return err
+	}
+
+	setActor(c, principal.UserID)
+	c.Set("apiKey", principal)
+	c.Set("userId", principal.UserID)
+	c.Set("userType", principal.UserType)
+	return nil
+}
+
+// DenyAPIKeys rejects requests authenticated with an API key, for routes that
+// only make sense for an interactive session such as key management
+func DenyAPIKeys(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if _, ok := c.Get("apiKey").(*APIKeyPrincipal); ok {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "Not available with API key authentication"})
+		}
+		return next(c)
+	}
+}
@@ -0,0 +1,77 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrNoCursorKey   = errors.New("no cursor key configured")
+)
+
+var cursorKey []byte
+
+// SetCursorKey installs the key pagination cursors are signed with. Every
+// instance serving the API must share it, or cursors only work on the
+// instance that issued them.
+func SetCursorKey(key []byte) {
+	cursorKey = key
+}
+
+// NewCursorKey returns a random key for when none is configured
+func NewCursorKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// SignCursor encodes v as an opaque token carrying an HMAC, so clients cannot
+// craft or alter the position it points at
+func SignCursor(v interface{}) (string, error) {
+	if len(cursorKey) == 0 {
+		return "", ErrNoCursorKey
+	}
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(cursorMAC(encoded)), nil
+}
+
+// OpenCursor verifies a token from SignCursor and decodes it into v
+func OpenCursor(token string, v interface{}) error {
+	if len(cursorKey) == 0 {
+		return ErrNoCursorKey
+	}
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, cursorMAC(encoded)) {
+		return ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+func cursorMAC(encoded string) []byte {
+	h := hmac.New(sha256.New, cursorKey)
+	h.Write([]byte(encoded))
+	return h.Sum(nil)
+}
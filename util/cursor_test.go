@@ -0,0 +1,91 @@
+package util
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testCursor struct {
+	Listing string `json:"l"`
+	ID      uint   `json:"id"`
+}
+
+func installCursorKey(t *testing.T, key string) {
+	t.Helper()
+	previous := cursorKey
+	SetCursorKey([]byte(key))
+	t.Cleanup(func() { SetCursorKey(previous) })
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	installCursorKey(t, "first key")
+
+	token, err := SignCursor(testCursor{Listing: "jobs:abc", ID: 42})
+	if err != nil {
+		t.Fatalf("SignCursor: %v", err)
+	}
+	var cursor testCursor
+	if err := OpenCursor(token, &cursor); err != nil {
+		t.Fatalf("OpenCursor: %v", err)
+	}
+	if cursor.Listing != "jobs:abc" || cursor.ID != 42 {
+		t.Errorf("cursor = %+v, want jobs:abc at 42", cursor)
+	}
+}
+
+func TestOpenCursorRejectsAlteredTokens(t *testing.T) {
+	installCursorKey(t, "first key")
+
+	token, err := SignCursor(testCursor{Listing: "jobs:abc", ID: 42})
+	if err != nil {
+		t.Fatalf("SignCursor: %v", err)
+	}
+	encoded, signature, _ := strings.Cut(token, ".")
+	mac, _ := base64.RawURLEncoding.DecodeString(signature)
+	mac[0] ^= 0xff
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"l":"jobs:abc","id":1}`))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"tampered payload", forged + "." + signature},
+		{"tampered signature", encoded + "." + base64.RawURLEncoding.EncodeToString(mac)},
+		{"missing signature", encoded},
+		{"signature not base64", encoded + ".!!"},
+		{"empty", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cursor testCursor
+			if err := OpenCursor(tt.token, &cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("OpenCursor = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestCursorKeyChange(t *testing.T) {
+	installCursorKey(t, "first key")
+	token, err := SignCursor(testCursor{ID: 42})
+	if err != nil {
+		t.Fatalf("SignCursor: %v", err)
+	}
+
+	// Cursors handed out before a key change stop working
+	SetCursorKey([]byte("second key"))
+	var cursor testCursor
+	if err := OpenCursor(token, &cursor); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("OpenCursor with another key = %v, want ErrInvalidCursor", err)
+	}
+
+	SetCursorKey(nil)
+	if _, err := SignCursor(testCursor{ID: 42}); !errors.Is(err, ErrNoCursorKey) {
+		t.Errorf("SignCursor without a key = %v, want ErrNoCursorKey", err)
+	}
+	if err := OpenCursor(token, &cursor); !errors.Is(err, ErrNoCursorKey) {
+		t.Errorf("OpenCursor without a key = %v, want ErrNoCursorKey", err)
+	}
+}
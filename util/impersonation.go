@@ -0,0 +1,38 @@
+package util
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ImpersonatedByHeader is set on every response to an impersonated request
+const ImpersonatedByHeader = "X-Impersonated-By"
+
+// markImpersonation records the impersonating admin on the request and response
+func markImpersonation(c echo.Context, claims *Claims) {
+	c.Set("impersonatorId", claims.ImpersonatorID)
+	c.Set("impersonatedUserId", claims.UserId)
+	c.Response().Header().Set(ImpersonatedByHeader, strconv.FormatUint(uint64(claims.ImpersonatorID), 10))
+}
+
+// readOnlyRequest reports whether the request method only reads data
+func readOnlyRequest(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// DenyImpersonation rejects impersonated requests on routes that change data
+// even though they are reached with GET
+func DenyImpersonation(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if _, ok := c.Get("impersonatorId").(uint); ok {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "Not allowed while impersonating"})
+		}
+		return next(c)
+	}
+}
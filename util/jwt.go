@@ -1,37 +1,195 @@
 package util
 
 import (
-	"os"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 7 * 24 * time.Hour
+	// MFAPendingTokenTTL bounds the time between the password and the second factor
+	MFAPendingTokenTTL = 5 * time.Minute
+)
+
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+	TokenTypeInvite  = "invite"
+	// TokenTypeMFAPending proves the password step of a login that still needs a second factor
+	TokenTypeMFAPending = "mfa_pending"
+)
 
 type Claims struct {
-	UserId   uint   `json:"user_id"`
-	UserType string `json:"user_type"`
+	UserId    uint   `json:"user_id"`
+	UserType  string `json:"user_type"`
+	TokenType string `json:"typ"`
+	Family    string `json:"fam,omitempty"`
+	// OrganizationID is the organization a staff member belongs to
+	OrganizationID uint `json:"org_id,omitempty"`
+	// ImpersonatorID is the admin acting as UserId; such tokens are read-only
+	ImpersonatorID uint `json:"imp,omitempty"`
+	// IssuedAtNano is IssuedAt in nanoseconds, so tokens issued in the same
+	// second as a sign-out-everywhere can be told apart from the ones it revoked
+	IssuedAtNano int64 `json:"iat_ns,omitempty"`
+	jwt.StandardClaims
+}
+
+// Issued returns when the token was issued, as precisely as the token records it
+func (c *Claims) Issued() time.Time {
+	if c.IssuedAtNano != 0 {
+		return time.Unix(0, c.IssuedAtNano)
+	}
+	return time.Unix(c.IssuedAt, 0)
+}
+
+// Impersonated reports whether the token was issued to an admin acting as another user
+func (c *Claims) Impersonated() bool {
+	return c.ImpersonatorID != 0
+}
+
+// InviteClaims identify the invitation an invite token was issued for
+type InviteClaims struct {
+	InviteID  uint   `json:"invite_id"`
+	TokenType string `json:"typ"`
 	jwt.StandardClaims
 }
 
-func GenerateToken(userId uint, userType string) (string, error) {
-	claims := Claims{
-		UserId:   userId,
-		UserType: userType,
+// RevocationChecker reports whether a validated token has been revoked
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, claims *Claims) (bool, error)
+}
+
+var revocationChecker RevocationChecker
+
+// SetRevocationChecker registers the checker AuthMiddleware consults for every request
+func SetRevocationChecker(checker RevocationChecker) {
+	revocationChecker = checker
+}
+
+// TokenOption customizes the claims of a generated token
+type TokenOption func(*Claims)
+
+// WithFamily ties the token to a refresh token family so it can be revoked together with it
+func WithFamily(family string) TokenOption {
+	return func(c *Claims) {
+		c.Family = family
+	}
+}
+
+// WithOrganizationID scopes the token to the user's organization
+func WithOrganizationID(orgID *uint) TokenOption {
+	return func(c *Claims) {
+		if orgID != nil {
+			c.OrganizationID = *orgID
+		}
+	}
+}
+
+// WithImpersonator marks the token as issued to adminID acting as the token's user
+func WithImpersonator(adminID uint) TokenOption {
+	return func(c *Claims) {
+		c.ImpersonatorID = adminID
+	}
+}
+
+// NewTokenID returns a random identifier suitable for a jti or token family
+func NewTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateToken issues a short-lived access token
+func GenerateToken(userId uint, userType string, opts ...TokenOption) (string, error) {
+	claims, err := newClaims(userId, userType, TokenTypeAccess, AccessTokenTTL)
+	if err != nil {
+		return "", err
+	}
+	for _, opt := range opts {
+		opt(claims)
+	}
+	return signClaims(claims)
+}
+
+// GenerateMFAPendingToken issues the intermediate token of a two-step login
+func GenerateMFAPendingToken(userId uint, userType string) (string, error) {
+	claims, err := newClaims(userId, userType, TokenTypeMFAPending, MFAPendingTokenTTL)
+	if err != nil {
+		return "", err
+	}
+	return signClaims(claims)
+}
+
+// GenerateRefreshToken issues a refresh token belonging to the given family
+func GenerateRefreshToken(userId uint, userType string, family string) (string, *Claims, error) {
+	claims, err := newClaims(userId, userType, TokenTypeRefresh, RefreshTokenTTL)
+	if err != nil {
+		return "", nil, err
+	}
+	claims.Family = family
+	token, err := signClaims(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
+}
+
+func newClaims(userId uint, userType, tokenType string, ttl time.Duration) (*Claims, error) {
+	jti, err := NewTokenID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &Claims{
+		UserId:       userId,
+		UserType:     userType,
+		TokenType:    tokenType,
+		IssuedAtNano: now.UnixNano(),
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(24 * time.Hour).Unix(),
+			Id:        jti,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
 		},
+	}, nil
+}
+
+func signClaims(claims jwt.Claims) (string, error) {
+	if keyring == nil {
+		return "", ErrNoSigningKey
 	}
+	key := keyring.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+// verificationKey resolves the public key for a token from its kid header,
+// refusing any algorithm other than the one the key was issued for
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	if keyring == nil {
+		return nil, ErrNoSigningKey
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, ok := keyring.Lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %q", token.Method.Alg())
+	}
+	return key.PublicKey, nil
 }
 
 func ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, verificationKey)
 
 	if err != nil {
 		return nil, err
@@ -43,3 +201,34 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 	return nil, jwt.ErrSignatureInvalid
 }
+
+// GenerateInviteToken issues a signed token for an invitation that expires with it
+func GenerateInviteToken(inviteID uint, expiresAt time.Time) (string, error) {
+	jti, err := NewTokenID()
+	if err != nil {
+		return "", err
+	}
+	return signClaims(&InviteClaims{
+		InviteID:  inviteID,
+		TokenType: TokenTypeInvite,
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+	})
+}
+
+// ValidateInviteToken verifies an invite token's signature and expiry
+func ValidateInviteToken(tokenString string) (*InviteClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &InviteClaims{}, verificationKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*InviteClaims); ok && token.Valid && claims.TokenType == TokenTypeInvite {
+		return claims, nil
+	}
+
+	return nil, jwt.ErrSignatureInvalid
+}
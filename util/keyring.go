@@ -0,0 +1,204 @@
+package util
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var ErrNoSigningKey = errors.New("no JWT signing key configured")
+
+// SigningKey is a single entry of the keyring. Retired keys keep only their
+// public half and are used for verification until their tokens expire.
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+// Keyring holds every key that may have signed a live token, identified by kid
+type Keyring struct {
+	keys   map[string]*SigningKey
+	active *SigningKey
+}
+
+// JWK is the public representation of a key as served from /.well-known/jwks.json
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+var keyring *Keyring
+
+// SetKeyring installs the keyring used to sign and verify every token
+func SetKeyring(k *Keyring) {
+	keyring = k
+}
+
+// CurrentKeyring returns the installed keyring
+func CurrentKeyring() *Keyring {
+	return keyring
+}
+
+// NewKeyring builds a keyring signing with activeKeyID. When activeKeyID is
+// empty and exactly one private key is present, that key becomes active.
+func NewKeyring(activeKeyID string, keys ...*SigningKey) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string]*SigningKey, len(keys))}
+	var signers []*SigningKey
+	for _, key := range keys {
+		if _, exists := k.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate JWT key id %q", key.ID)
+		}
+		k.keys[key.ID] = key
+		if key.PrivateKey != nil {
+			signers = append(signers, key)
+		}
+	}
+
+	switch {
+	case activeKeyID != "":
+		key, ok := k.keys[activeKeyID]
+		if !ok {
+			return nil, fmt.Errorf("active JWT key %q not found", activeKeyID)
+		}
+		if key.PrivateKey == nil {
+			return nil, fmt.Errorf("active JWT key %q has no private key", activeKeyID)
+		}
+		k.active = key
+	case len(signers) == 1:
+		k.active = signers[0]
+	case len(signers) == 0:
+		return nil, ErrNoSigningKey
+	default:
+		return nil, errors.New("multiple JWT private keys configured, set the active key id")
+	}
+
+	return k, nil
+}
+
+// LoadKeyring reads every *.pem file in dir, using the file name without
+// extension as the kid. Files may hold RSA or Ed25519 private keys, or bare
+// public keys for retired signing keys.
+func LoadKeyring(dir, activeKeyID string) (*Keyring, error) {
+	if dir == "" {
+		return nil, ErrNoSigningKey
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var keys []*SigningKey
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		kid := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		key, err := ParseSigningKey(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return NewKeyring(activeKeyID, keys...)
+}
+
+// ParseSigningKey decodes a PEM encoded RSA or Ed25519 key
+func ParseSigningKey(kid string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = EdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.PublicKey = EdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	return key, nil
+}
+
+// Active returns the key new tokens are signed with
+func (k *Keyring) Active() *SigningKey {
+	return k.active
+}
+
+// Lookup returns the key with the given kid
+func (k *Keyring) Lookup(kid string) (*SigningKey, bool) {
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+// JWKS returns the public keys of the keyring in JWK Set format
+func (k *Keyring) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(k.keys))}
+	for _, key := range k.keys {
+		jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+		switch pub := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].KeyID < set.Keys[j].KeyID })
+	return set
+}
@@ -0,0 +1,218 @@
+package util
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func testRSAKey(t *testing.T, kid string) *SigningKey {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+	return &SigningKey{ID: kid, Method: jwt.SigningMethodRS256, PrivateKey: private, PublicKey: &private.PublicKey}
+}
+
+func testEd25519Key(t *testing.T, kid string) *SigningKey {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating Ed25519 key: %v", err)
+	}
+	return &SigningKey{ID: kid, Method: EdDSA, PrivateKey: private, PublicKey: public}
+}
+
+// retired keeps only the public half of a key, as after a rotation
+func retired(key *SigningKey) *SigningKey {
+	return &SigningKey{ID: key.ID, Method: key.Method, PublicKey: key.PublicKey}
+}
+
+func installKeyring(t *testing.T, activeKeyID string, keys ...*SigningKey) {
+	t.Helper()
+	k, err := NewKeyring(activeKeyID, keys...)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	previous := CurrentKeyring()
+	SetKeyring(k)
+	t.Cleanup(func() { SetKeyring(previous) })
+}
+
+func TestTokensSurviveKeyRotation(t *testing.T) {
+	tests := []struct {
+		name   string
+		newKey func(t *testing.T, kid string) *SigningKey
+	}{
+		{"RS256", testRSAKey},
+		{"EdDSA", testEd25519Key},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old, current := tt.newKey(t, "old"), tt.newKey(t, "current")
+
+			installKeyring(t, "", old)
+			before, err := GenerateToken(7, "APPLICANT")
+			if err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+
+			installKeyring(t, "current", current, retired(old))
+			after, err := GenerateToken(8, "APPLICANT")
+			if err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+
+			for token, want := range map[string]struct {
+				user uint
+				kid  string
+			}{before: {7, "old"}, after: {8, "current"}} {
+				claims, err := ValidateToken(token)
+				if err != nil {
+					t.Fatalf("ValidateToken(%s token): %v", want.kid, err)
+				}
+				if claims.UserId != want.user {
+					t.Errorf("user = %d, want %d", claims.UserId, want.user)
+				}
+				parsed, _, err := new(jwt.Parser).ParseUnverified(token, &Claims{})
+				if err != nil {
+					t.Fatalf("ParseUnverified: %v", err)
+				}
+				if parsed.Header["kid"] != want.kid || parsed.Header["alg"] != tt.name {
+					t.Errorf("header = %v, want kid %s and alg %s", parsed.Header, want.kid, tt.name)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateTokenRejectsForgeries(t *testing.T) {
+	key, other := testEd25519Key(t, "current"), testEd25519Key(t, "other")
+	rsaKey := testRSAKey(t, "rsa")
+
+	installKeyring(t, "other", other)
+	unknownKid, err := GenerateToken(7, "APPLICANT")
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	installKeyring(t, "rsa", rsaKey)
+	rsaSigned, err := GenerateToken(7, "APPLICANT")
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	// Same kid, different algorithm
+	rsaSigned = strings.Replace(rsaSigned, strings.Split(rsaSigned, ".")[0], jwt.EncodeSegment([]byte(`{"alg":"RS256","kid":"current","typ":"JWT"}`)), 1)
+
+	installKeyring(t, "current", key)
+	valid, err := GenerateToken(7, "APPLICANT")
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	parts := strings.Split(valid, ".")
+	signature, _ := jwt.DecodeSegment(parts[2])
+	signature[0] ^= 0xff
+	tamperedSignature := parts[0] + "." + parts[1] + "." + jwt.EncodeSegment(signature)
+	tamperedPayload := parts[0] + "." + jwt.EncodeSegment([]byte(`{"user_id":1,"user_type":"ADMIN","typ":"access"}`)) + "." + parts[2]
+
+	if _, err := ValidateToken(valid); err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	for name, token := range map[string]string{
+		"unknown kid":        unknownKid,
+		"tampered signature": tamperedSignature,
+		"tampered payload":   tamperedPayload,
+		"other algorithm":    rsaSigned,
+	} {
+		if _, err := ValidateToken(token); err == nil {
+			t.Errorf("%s: token accepted", name)
+		}
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey, edKey := testRSAKey(t, "a-rsa"), testEd25519Key(t, "b-ed25519")
+	k, err := NewKeyring("b-ed25519", retired(rsaKey), edKey)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+
+	set := k.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("JWKS has %d keys, want 2", len(set.Keys))
+	}
+	rsaPublic := rsaKey.PublicKey.(*rsa.PublicKey)
+	want := []JWK{
+		{
+			KeyType: "RSA", KeyID: "a-rsa", Use: "sig", Algorithm: "RS256",
+			N: base64.RawURLEncoding.EncodeToString(rsaPublic.N.Bytes()),
+			E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaPublic.E)).Bytes()),
+		},
+		{
+			KeyType: "OKP", KeyID: "b-ed25519", Use: "sig", Algorithm: "EdDSA", Curve: "Ed25519",
+			X: base64.RawURLEncoding.EncodeToString(edKey.PublicKey.(ed25519.PublicKey)),
+		},
+	}
+	for i := range want {
+		if set.Keys[i] != want[i] {
+			t.Errorf("key %d = %+v, want %+v", i, set.Keys[i], want[i])
+		}
+	}
+	if want[0].E != "AQAB" {
+		t.Errorf("RSA exponent = %s, want AQAB", want[0].E)
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, edKey := testRSAKey(t, "2025"), testEd25519Key(t, "2026")
+	writePEM := func(name, blockType string, der []byte) {
+		data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	public, err := x509.MarshalPKIXPublicKey(rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, err := x509.MarshalPKCS8PrivateKey(edKey.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM("2025.pem", "PUBLIC KEY", public)
+	writePEM("2026.pem", "PRIVATE KEY", private)
+	writePEM("notes.txt", "PRIVATE KEY", private)
+
+	// The only private key becomes active without being named
+	k, err := LoadKeyring(dir, "")
+	if err != nil {
+		t.Fatalf("LoadKeyring: %v", err)
+	}
+	if k.Active().ID != "2026" || k.Active().Method != EdDSA {
+		t.Errorf("active key = %s (%s), want 2026 (EdDSA)", k.Active().ID, k.Active().Method.Alg())
+	}
+	if key, ok := k.Lookup("2025"); !ok || key.PrivateKey != nil || key.Method != jwt.SigningMethodRS256 {
+		t.Errorf("retired key 2025 = %+v, %v", key, ok)
+	}
+	if len(k.JWKS().Keys) != 2 {
+		t.Errorf("JWKS has %d keys, want 2", len(k.JWKS().Keys))
+	}
+
+	if _, err := LoadKeyring(dir, "2025"); err == nil {
+		t.Error("a public key was accepted as the active key")
+	}
+	if _, err := LoadKeyring(dir, "2027"); err == nil {
+		t.Error("a missing active key was accepted")
+	}
+}
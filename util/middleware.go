@@ -1,6 +1,7 @@
 package util
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 	"synergylabs/models"
@@ -10,6 +11,20 @@ import (
 
 func AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
+		if key, ok := apiKeyFromRequest(c.Request()); ok {
+			principal, err := authenticateAPIKey(c.Request().Context(), key)
+			if errors.Is(err, ErrInvalidAPIKey) {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid API key"})
+			}
+			if err != nil {
+				return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "Could not verify API key"})
+			}
+			if err := setAPIKeyPrincipal(c, principal); err != nil {
+				return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "Could not verify permissions"})
+			}
+			return next(c)
+		}
+
 		authHeader := c.Request().Header.Get("Authorization")
 		if authHeader == "" {
 			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "No token provided"})
@@ -17,32 +32,65 @@ func AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 
 		token := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer"))
 		claims, err := ValidateToken(token)
-		if err != nil {
+		if err != nil || claims.TokenType != TokenTypeAccess {
 			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Invalid token"})
 		}
 
+		if revocationChecker != nil {
+			revoked, err := revocationChecker.IsRevoked(c.Request().Context(), claims)
+			if err != nil {
+				return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "Could not verify token"})
+			}
+			if revoked {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Token has been revoked"})
+			}
+		}
+
+		// Impersonation tokens let an admin see what the user sees without changing anything
+		if claims.Impersonated() {
+			markImpersonation(c, claims)
+			if !readOnlyRequest(c.Request()) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "Not allowed while impersonating"})
+			}
+		}
+
+		if err := scopeTenant(c, claims.UserId, claims.UserType, claims.OrganizationID); err != nil {
+			return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "Could not verify permissions"})
+		}
+
+		actorID := claims.UserId
+		if claims.Impersonated() {
+			actorID = claims.ImpersonatorID
+		}
+		setActor(c, actorID)
+
+		c.Set("claims", claims)
 		c.Set("userId", claims.UserId)
 		c.Set("userType", claims.UserType)
 		return next(c)
 	}
 }
 
-func AdminOnly(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		userType := c.Get("userType").(string)
-		if userType != string(models.UserTypeAdmin) {
-			return c.JSON(http.StatusForbidden, map[string]string{"error": "Admin access required"})
+// scopeTenant scopes the request to the caller's organization. Staff outside
+// any organization see every organization only if they are platform
+// administrators; everyone else outside one sees no organization's staff data.
+func scopeTenant(c echo.Context, userID uint, userType string, orgID uint) error {
+	ctx := c.Request().Context()
+	switch {
+	case orgID != 0:
+		ctx = ContextWithOrganization(ctx, orgID)
+	case userType == string(models.UserTypeAdmin) && platformAdminChecker != nil:
+		platformAdmin, err := platformAdminChecker.IsPlatformAdmin(ctx, userID)
+		if err != nil {
+			return err
 		}
-		return next(c)
-	}
-}
-
-func ApplicantOnly(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		userType := c.Get("userType").(string)
-		if userType != string(models.UserTypeApplicant) {
-			return c.JSON(http.StatusForbidden, map[string]string{"error": "Applicant access required"})
+		if !platformAdmin {
+			return nil
 		}
-		return next(c)
+		ctx = ContextWithAllOrganizations(ctx)
+	default:
+		return nil
 	}
+	c.SetRequest(c.Request().WithContext(ctx))
+	return nil
 }
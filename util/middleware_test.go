@@ -0,0 +1,101 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+type testAPIKeys map[string]error
+
+func (k testAPIKeys) AuthenticateAPIKey(ctx context.Context, key string) (*APIKeyPrincipal, error) {
+	if err, ok := k[key]; ok {
+		return nil, err
+	}
+	return &APIKeyPrincipal{KeyID: 1, UserID: 2, UserType: "APPLICANT"}, nil
+}
+
+func TestAuthMiddlewareAPIKeyErrors(t *testing.T) {
+	SetAPIKeyAuthenticator(testAPIKeys{
+		APIKeyPrefix + "revoked": ErrInvalidAPIKey,
+		APIKeyPrefix + "dbdown":  errors.New("connection refused"),
+	})
+	defer SetAPIKeyAuthenticator(nil)
+
+	handler := AuthMiddleware(func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{APIKeyPrefix + "valid", http.StatusNoContent},
+		{APIKeyPrefix + "revoked", http.StatusUnauthorized},
+		// A lookup that failed says nothing about the key, so the client should retry
+		{APIKeyPrefix + "dbdown", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", tt.key)
+		rec := httptest.NewRecorder()
+		if err := handler(echo.New().NewContext(req, rec)); err != nil {
+			t.Fatalf("%s: %v", tt.key, err)
+		}
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.key, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestImpersonationIsReadOnly(t *testing.T) {
+	installKeyring(t, "", testEd25519Key(t, "test"))
+	impersonated, err := GenerateToken(2, "APPLICANT", WithImpersonator(1))
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	own, err := GenerateToken(2, "APPLICANT")
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusNoContent) }
+	tests := []struct {
+		name    string
+		token   string
+		method  string
+		handler echo.HandlerFunc
+		want    int
+	}{
+		{"read", impersonated, http.MethodGet, AuthMiddleware(ok), http.StatusNoContent},
+		{"head", impersonated, http.MethodHead, AuthMiddleware(ok), http.StatusNoContent},
+		{"create", impersonated, http.MethodPost, AuthMiddleware(ok), http.StatusForbidden},
+		{"update", impersonated, http.MethodPut, AuthMiddleware(ok), http.StatusForbidden},
+		{"patch", impersonated, http.MethodPatch, AuthMiddleware(ok), http.StatusForbidden},
+		{"delete", impersonated, http.MethodDelete, AuthMiddleware(ok), http.StatusForbidden},
+		{"read that changes data", impersonated, http.MethodGet, AuthMiddleware(DenyImpersonation(ok)), http.StatusForbidden},
+		{"own token on a denied route", own, http.MethodGet, AuthMiddleware(DenyImpersonation(ok)), http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rec := httptest.NewRecorder()
+			if err := tt.handler(echo.New().NewContext(req, rec)); err != nil {
+				t.Fatalf("handler: %v", err)
+			}
+			if rec.Code != tt.want {
+				t.Errorf("status %d, want %d", rec.Code, tt.want)
+			}
+			// Every impersonated response names the admin, refused or not
+			want := ""
+			if tt.token == impersonated {
+				want = "1"
+			}
+			if got := rec.Header().Get(ImpersonatedByHeader); got != want {
+				t.Errorf("%s = %q, want %q", ImpersonatedByHeader, got, want)
+			}
+		})
+	}
+}
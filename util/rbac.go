@@ -0,0 +1,94 @@
+package util
+
+import (
+	"context"
+	"net/http"
+	"synergylabs/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PermissionResolver returns the permissions granted to a user through their roles
+type PermissionResolver interface {
+	Permissions(ctx context.Context, userID uint) ([]models.Permission, error)
+}
+
+var permissionResolver PermissionResolver
+
+// SetPermissionResolver registers the resolver RequirePermission consults
+func SetPermissionResolver(resolver PermissionResolver) {
+	permissionResolver = resolver
+}
+
+// RequirePermission allows the request only if the caller holds every listed permission.
+// It must run after AuthMiddleware.
+func RequirePermission(perms ...models.Permission) echo.MiddlewareFunc {
+	return requirePermissions(perms, true)
+}
+
+// RequireAnyPermission allows the request if the caller holds at least one of
+// the listed permissions. Handlers narrow access further with HasPermission.
+func RequireAnyPermission(perms ...models.Permission) echo.MiddlewareFunc {
+	return requirePermissions(perms, false)
+}
+
+func requirePermissions(perms []models.Permission, all bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			granted, err := loadPermissions(c)
+			if err != nil {
+				return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "Could not verify permissions"})
+			}
+
+			matched := 0
+			for _, perm := range perms {
+				if granted[perm] {
+					matched++
+				}
+			}
+			if (all && matched < len(perms)) || (!all && matched == 0) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "Permission denied"})
+			}
+			return next(c)
+		}
+	}
+}
+
+// HasPermission reports whether the caller holds perm. Permissions are
+// resolved once per request.
+func HasPermission(c echo.Context, perm models.Permission) bool {
+	granted, err := loadPermissions(c)
+	return err == nil && granted[perm]
+}
+
+func loadPermissions(c echo.Context) (map[models.Permission]bool, error) {
+	if granted, ok := c.Get("permissions").(map[models.Permission]bool); ok {
+		return granted, nil
+	}
+
+	granted := map[models.Permission]bool{}
+	userID, ok := c.Get("userId").(uint)
+	if ok && permissionResolver != nil {
+		perms, err := permissionResolver.Permissions(c.Request().Context(), userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, perm := range perms {
+			granted[perm] = true
+		}
+	}
+
+	// API keys are limited to their scopes, within what their owner still holds
+	if principal, ok := c.Get("apiKey").(*APIKeyPrincipal); ok {
+		scoped := map[models.Permission]bool{}
+		for _, perm := range principal.Scopes {
+			if granted[perm] {
+				scoped[perm] = true
+			}
+		}
+		granted = scoped
+	}
+
+	c.Set("permissions", granted)
+	return granted, nil
+}
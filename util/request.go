@@ -0,0 +1,70 @@
+package util
+
+import (
+	"context"
+	"net"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequestInfo identifies the HTTP request a service call is made for
+type RequestInfo struct {
+	ID string
+	IP string
+	// ActorID is the authenticated user, or the admin behind an impersonation
+	ActorID uint
+}
+
+type requestInfoContextKey struct{}
+
+// ContextWithRequestInfo attaches the request's ID and client IP to ctx
+func ContextWithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoContextKey{}, info)
+}
+
+// RequestInfoFromContext returns the request info attached to ctx, if any
+func RequestInfoFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoContextKey{}).(RequestInfo)
+	return info
+}
+
+// IPExtractor decides which address c.RealIP returns, and so which address
+// login throttling, sessions and the audit log see. Without trusted proxies the
+// connection's peer address is used and forwarding headers, which any client
+// can set, are ignored. Behind proxies the nearest X-Forwarded-For entry that
+// is not one of them is used.
+func IPExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, network := range trustedProxies {
+		options = append(options, echo.TrustIPRange(network))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// RequestContext puts the request ID and client IP in the request context so
+// services can record them. It must run after echo's RequestID middleware.
+func RequestContext(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id := c.Response().Header().Get(echo.HeaderXRequestID)
+		if id == "" {
+			id = c.Request().Header.Get(echo.HeaderXRequestID)
+		}
+		ctx := ContextWithRequestInfo(c.Request().Context(), RequestInfo{ID: id, IP: c.RealIP()})
+		c.SetRequest(c.Request().WithContext(ctx))
+		return next(c)
+	}
+}
+
+// setActor records the authenticated user in the request info
+func setActor(c echo.Context, actorID uint) {
+	info := RequestInfoFromContext(c.Request().Context())
+	info.ActorID = actorID
+	c.SetRequest(c.Request().WithContext(ContextWithRequestInfo(c.Request().Context(), info)))
+}
@@ -0,0 +1,93 @@
+package util
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func mustParseCIDR(t *testing.T, cidr string) *net.IPNet {
+	t.Helper()
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatalf("parsing %s: %v", cidr, err)
+	}
+	return network
+}
+
+func TestIPExtractor(t *testing.T) {
+	proxies := []*net.IPNet{mustParseCIDR(t, "10.0.0.0/8")}
+
+	tests := []struct {
+		name       string
+		trusted    []*net.IPNet
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"direct", nil, "203.0.113.5:4321", nil, "203.0.113.5"},
+		{"direct ignores X-Forwarded-For", nil, "203.0.113.5:4321", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "203.0.113.5"},
+		{"direct ignores X-Real-IP", nil, "203.0.113.5:4321", map[string]string{"X-Real-IP": "198.51.100.1"}, "203.0.113.5"},
+		{"direct does not trust private peers", nil, "10.1.2.3:4321", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "10.1.2.3"},
+		{"trusted proxy", proxies, "10.1.2.3:4321", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "198.51.100.1"},
+		{"trusted proxy chain", proxies, "10.1.2.3:4321", map[string]string{"X-Forwarded-For": "198.51.100.1, 10.4.5.6"}, "198.51.100.1"},
+		{"client prepends a spoofed entry", proxies, "10.1.2.3:4321", map[string]string{"X-Forwarded-For": "192.0.2.99, 198.51.100.1"}, "198.51.100.1"},
+		{"untrusted peer behind configured proxies", proxies, "203.0.113.5:4321", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "203.0.113.5"},
+		{"X-Real-IP from a trusted proxy is still ignored", proxies, "10.1.2.3:4321", map[string]string{"X-Real-IP": "198.51.100.1"}, "10.1.2.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.IPExtractor = IPExtractor(tt.trusted)
+			req := httptest.NewRequest(http.MethodPost, "/login", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			if got := e.NewContext(req, httptest.NewRecorder()).RealIP(); got != tt.want {
+				t.Errorf("RealIP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// A client changing its forwarding headers on every attempt must keep
+// counting against the same IP, or per-IP login lockouts can be sidestepped
+func TestSpoofedHeadersDoNotResetLockout(t *testing.T) {
+	const maxFailures = 3
+	failures := map[string]int{}
+
+	e := echo.New()
+	e.IPExtractor = IPExtractor(nil)
+	e.Use(RequestContext)
+	e.POST("/login", func(c echo.Context) error {
+		ip := RequestInfoFromContext(c.Request().Context()).IP
+		if failures[ip] >= maxFailures {
+			return c.NoContent(http.StatusTooManyRequests)
+		}
+		failures[ip]++
+		return c.NoContent(http.StatusUnauthorized)
+	})
+
+	spoofed := []string{"198.51.100.1", "198.51.100.2", "198.51.100.3", "198.51.100.4", "198.51.100.5"}
+	var codes []int
+	for _, ip := range spoofed {
+		req := httptest.NewRequest(http.MethodPost, "/login", nil)
+		req.RemoteAddr = "203.0.113.5:4321"
+		req.Header.Set("X-Forwarded-For", ip)
+		req.Header.Set("X-Real-IP", ip)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+
+	if len(failures) != 1 || failures["203.0.113.5"] != maxFailures {
+		t.Errorf("failures counted per IP = %v, want all against 203.0.113.5", failures)
+	}
+	if last := codes[len(codes)-1]; last != http.StatusTooManyRequests {
+		t.Errorf("responses = %v, want the client locked out", codes)
+	}
+}
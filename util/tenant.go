@@ -0,0 +1,47 @@
+package util
+
+import "context"
+
+type organizationContextKey struct{}
+
+type allOrganizationsContextKey struct{}
+
+// ContextWithOrganization scopes ctx to an organization. Services filter every
+// query on staff data by it. A context with neither an organization nor
+// ContextWithAllOrganizations sees no organization's staff data.
+func ContextWithOrganization(ctx context.Context, orgID uint) context.Context {
+	return context.WithValue(ctx, organizationContextKey{}, orgID)
+}
+
+// OrganizationFromContext returns the organization ctx is scoped to
+func OrganizationFromContext(ctx context.Context) (uint, bool) {
+	orgID, ok := ctx.Value(organizationContextKey{}).(uint)
+	return orgID, ok && orgID != 0
+}
+
+// ContextWithAllOrganizations lets ctx see every organization's data. It is
+// for platform administrators and for trusted callers outside a request, such
+// as CLI commands.
+func ContextWithAllOrganizations(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allOrganizationsContextKey{}, true)
+}
+
+// SeesAllOrganizations reports whether ctx may see every organization's data
+func SeesAllOrganizations(ctx context.Context) bool {
+	all, _ := ctx.Value(allOrganizationsContextKey{}).(bool)
+	return all
+}
+
+// PlatformAdminChecker reports whether a staff user outside any organization
+// administers the whole platform
+type PlatformAdminChecker interface {
+	IsPlatformAdmin(ctx context.Context, userID uint) (bool, error)
+}
+
+var platformAdminChecker PlatformAdminChecker
+
+// SetPlatformAdminChecker registers the checker AuthMiddleware consults for
+// staff without an organization
+func SetPlatformAdminChecker(checker PlatformAdminChecker) {
+	platformAdminChecker = checker
+}
@@ -0,0 +1,58 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+type testPlatformAdmins map[uint]bool
+
+func (a testPlatformAdmins) IsPlatformAdmin(ctx context.Context, userID uint) (bool, error) {
+	if userID == 99 {
+		return false, errors.New("unavailable")
+	}
+	return a[userID], nil
+}
+
+func TestScopeTenant(t *testing.T) {
+	SetPlatformAdminChecker(testPlatformAdmins{1: true})
+	defer SetPlatformAdminChecker(nil)
+
+	tests := []struct {
+		name     string
+		userID   uint
+		userType string
+		orgID    uint
+		wantOrg  uint
+		wantAll  bool
+		wantErr  bool
+	}{
+		{"organization member", 2, "ADMIN", 5, 5, false, false},
+		{"platform administrator in an organization", 1, "ADMIN", 5, 5, false, false},
+		{"platform administrator", 1, "ADMIN", 0, 0, true, false},
+		{"staff without an organization", 2, "ADMIN", 0, 0, false, false},
+		{"applicant", 1, "APPLICANT", 0, 0, false, false},
+		{"checker failure", 99, "ADMIN", 0, 0, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+			err := scopeTenant(c, tt.userID, tt.userType, tt.orgID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("scopeTenant error = %v, want error %t", err, tt.wantErr)
+			}
+			ctx := c.Request().Context()
+			if orgID, _ := OrganizationFromContext(ctx); orgID != tt.wantOrg {
+				t.Errorf("organization = %d, want %d", orgID, tt.wantOrg)
+			}
+			if got := SeesAllOrganizations(ctx); got != tt.wantAll {
+				t.Errorf("SeesAllOrganizations = %t, want %t", got, tt.wantAll)
+			}
+		})
+	}
+}